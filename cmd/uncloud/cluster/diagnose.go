@@ -0,0 +1,264 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+	"uncloud/internal/cli"
+	"uncloud/internal/cli/client"
+	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/network"
+)
+
+const (
+	checkPass = "PASS"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+
+	// diagnoseTimeout is the maximum time to wait for a response from a machine for a single check.
+	diagnoseTimeout = 5 * time.Second
+	// handshakeStaleAfter is the age of the latest WireGuard handshake after which the peer link is considered stale.
+	// WireGuard re-handshakes every 2 minutes while there is traffic between the peers.
+	handshakeStaleAfter = 3 * time.Minute
+	// diskWarnPercent and diskFailPercent are the thresholds of the available disk space of the machine data
+	// directory below which the disk check warns or fails.
+	diskWarnPercent = 10
+	diskFailPercent = 2
+)
+
+// checkResult is the result of a single diagnostic check.
+type checkResult struct {
+	check   string
+	machine string
+	status  string
+	details string
+}
+
+type diagnoseOptions struct {
+	cluster string
+}
+
+func NewDiagnoseCommand() *cobra.Command {
+	opts := diagnoseOptions{}
+	cmd := &cobra.Command{
+		Use:   "diagnose",
+		Short: "Run a set of health checks against the cluster and print a report.",
+		Long: "Run a set of health checks against every machine in the cluster: gossip membership, machine daemon " +
+			"availability, cluster store sync, WireGuard peer handshakes, and available disk space. Each check is reported as PASS, " +
+			"WARN, or FAIL with a hint on how to fix it. The command fails if any check fails.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			return diagnose(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func diagnose(ctx context.Context, uncli *cli.CLI, opts diagnoseOptions) error {
+	c, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer c.Close()
+
+	machines, err := c.ListMachines(ctx)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+	sort.Slice(machines, func(i, j int) bool {
+		return machines[i].Machine.Name < machines[j].Machine.Name
+	})
+
+	var results []checkResult
+	for _, m := range machines {
+		results = append(results, checkMembership(m))
+		daemon, info := checkDaemon(ctx, c, m.Machine)
+		results = append(results, daemon)
+		if daemon.status == checkPass {
+			results = append(results, checkStoreSync(ctx, c, m.Machine, machines))
+			results = append(results, checkDisk(info))
+		}
+		results = append(results, checkHandshake(m))
+	}
+
+	if err = writeReport(os.Stdout, results); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.status == checkFail {
+			return errors.New("some checks failed")
+		}
+	}
+	return nil
+}
+
+// checkMembership checks the gossip membership state of the machine in the cluster.
+func checkMembership(m *pb.MachineMember) checkResult {
+	r := checkResult{check: "Membership", machine: m.Machine.Name}
+	switch m.State {
+	case pb.MachineMember_UP:
+		r.status, r.details = checkPass, "Machine is up."
+	case pb.MachineMember_SUSPECT:
+		r.status = checkWarn
+		r.details = "Some cluster members suspect the machine is down. Check its network connectivity."
+	default:
+		r.status = checkFail
+		r.details = "Machine is down. Check that the machine is running and the uncloud and " +
+			"uncloud-corrosion services are active on it."
+	}
+	return r
+}
+
+// checkDaemon checks that the machine daemon API is reachable through the cluster network. It also returns
+// the machine info reported by the daemon if the check passes.
+func checkDaemon(ctx context.Context, c *client.Client, m *pb.MachineInfo) (checkResult, *pb.MachineInfo) {
+	r := checkResult{check: "Daemon", machine: m.Name}
+
+	ctx, cancel := context.WithTimeout(machineContext(ctx, m), diagnoseTimeout)
+	defer cancel()
+	info, err := c.MachineClient.Inspect(ctx, &emptypb.Empty{})
+	if err != nil {
+		r.status = checkFail
+		r.details = fmt.Sprintf("Machine API is unreachable: %v. Check the uncloud service logs on the machine "+
+			"with 'journalctl -u uncloud'.", err)
+		return r, nil
+	}
+	if info.Id != m.Id {
+		r.status = checkFail
+		r.details = fmt.Sprintf("Machine responded with unexpected ID %q. The machine may have been reset, "+
+			"remove it and add to the cluster again.", info.Id)
+		return r, nil
+	}
+
+	r.status, r.details = checkPass, "Machine API is reachable."
+	return r, info
+}
+
+// checkDisk checks the available disk space of the filesystem with the machine data directory.
+func checkDisk(info *pb.MachineInfo) checkResult {
+	r := checkResult{check: "Disk", machine: info.Name}
+	if info.DiskTotal == 0 {
+		r.status, r.details = checkWarn, "Machine didn't report its disk space, it may be running an older version."
+		return r
+	}
+
+	percent := float64(info.DiskAvailable) / float64(info.DiskTotal) * 100
+	details := fmt.Sprintf("%s of %s (%.0f%%) available.",
+		units.BytesSize(float64(info.DiskAvailable)), units.BytesSize(float64(info.DiskTotal)), percent)
+	switch {
+	case percent < diskFailPercent:
+		r.status = checkFail
+		r.details = details + " Free up disk space, e.g. remove unused images with 'uc image prune --all'."
+	case percent < diskWarnPercent:
+		r.status = checkWarn
+		r.details = details + " Consider freeing up disk space, e.g. with 'uc image prune --all'."
+	default:
+		r.status, r.details = checkPass, details
+	}
+	return r
+}
+
+// checkStoreSync checks that the cluster store on the machine has the same machines as the store
+// on the machine serving the requests.
+func checkStoreSync(ctx context.Context, c *client.Client, m *pb.MachineInfo, expected []*pb.MachineMember) checkResult {
+	r := checkResult{check: "Store sync", machine: m.Name}
+
+	ctx, cancel := context.WithTimeout(machineContext(ctx, m), diagnoseTimeout)
+	defer cancel()
+	machines, err := c.ListMachines(ctx)
+	if err != nil {
+		r.status, r.details = checkFail, fmt.Sprintf("List machines from the cluster store: %v.", err)
+		return r
+	}
+
+	ids := make(map[string]struct{}, len(machines))
+	for _, mm := range machines {
+		ids[mm.Machine.Id] = struct{}{}
+	}
+	var missing []string
+	for _, mm := range expected {
+		if _, ok := ids[mm.Machine.Id]; !ok {
+			missing = append(missing, mm.Machine.Name)
+		}
+	}
+	if len(missing) > 0 || len(machines) != len(expected) {
+		r.status = checkWarn
+		r.details = fmt.Sprintf("Cluster store has %d machines, expected %d (missing: %v). The store may still be "+
+			"syncing, check the uncloud-corrosion service logs on the machine if it persists.",
+			len(machines), len(expected), missing)
+		return r
+	}
+
+	r.status, r.details = checkPass, fmt.Sprintf("Cluster store has all %d machines.", len(expected))
+	return r
+}
+
+// checkHandshake checks the latest WireGuard handshake with the machine from the machine serving the requests.
+func checkHandshake(m *pb.MachineMember) checkResult {
+	r := checkResult{check: "WireGuard", machine: m.Machine.Name}
+	if m.LastHandshake == nil {
+		if m.State == pb.MachineMember_DOWN {
+			r.status = checkFail
+			r.details = fmt.Sprintf("No WireGuard handshake with the machine. Check that UDP port %d is open "+
+				"and the machine endpoints are reachable.", network.WireGuardPort)
+		} else {
+			// The serving machine itself or a machine that hasn't exchanged traffic with it yet.
+			r.status, r.details = checkPass, "No handshake needed."
+		}
+		return r
+	}
+
+	since := time.Since(m.LastHandshake.AsTime())
+	if since > handshakeStaleAfter {
+		r.status = checkWarn
+		r.details = fmt.Sprintf("Latest WireGuard handshake was %s ago. Check that UDP port %d is open and "+
+			"the machine endpoints are reachable.", units.HumanDuration(since), network.WireGuardPort)
+		return r
+	}
+
+	r.status = checkPass
+	r.details = fmt.Sprintf("Latest WireGuard handshake was %s ago.", units.HumanDuration(since))
+	return r
+}
+
+// machineContext returns a context that proxies the requests to the given machine.
+func machineContext(ctx context.Context, m *pb.MachineInfo) context.Context {
+	machineIP, _ := m.Network.ManagementIp.ToAddr()
+	return metadata.NewOutgoingContext(ctx, metadata.Pairs("machines", machineIP.String()))
+}
+
+// writeReport writes the check results in a table format followed by a summary.
+func writeReport(w io.Writer, results []checkResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "CHECK\tMACHINE\tSTATUS\tDETAILS"); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.status]++
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.check, r.machine, r.status, r.details); err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed\n",
+		counts[checkPass], counts[checkWarn], counts[checkFail])
+	return err
+}
@@ -0,0 +1,89 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"time"
+	"uncloud/internal/cli"
+	"uncloud/internal/machine/api/pb"
+	machinecluster "uncloud/internal/machine/cluster"
+)
+
+type eventsOptions struct {
+	types   []string
+	cluster string
+}
+
+func NewEventsCommand() *cobra.Command {
+	opts := eventsOptions{}
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Stream container, service, and machine events in the cluster.",
+		Long: "Stream container, service, and machine events in the cluster as they happen until interrupted. " +
+			"Container events are create, start, stop, and remove. Service events are create when its first " +
+			"container is created and remove when its last container is removed. Machine events are join, " +
+			"update, and leave.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			return events(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().StringSliceVarP(
+		&opts.types, "type", "t", nil,
+		"Stream only events of the specified type: container, service, or machine. "+
+			"Can be specified multiple times or as a comma-separated list. (default is all types)",
+	)
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func events(ctx context.Context, uncli *cli.CLI, opts eventsOptions) error {
+	c, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer c.Close()
+
+	machines, err := c.ListMachines(ctx)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+	machineNames := make(map[string]string, len(machines))
+	for _, m := range machines {
+		machineNames[m.Machine.Id] = m.Machine.Name
+	}
+
+	stream, err := c.ClusterClient.Events(ctx, &pb.EventsRequest{Types: opts.types})
+	if err != nil {
+		return fmt.Errorf("stream events: %w", err)
+	}
+	for {
+		e, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("receive event: %w", err)
+		}
+
+		details := ""
+		switch e.Type {
+		case machinecluster.EventTypeMachine:
+			machineNames[e.Id] = e.Name
+		case machinecluster.EventTypeContainer:
+			machine := machineNames[e.MachineId]
+			if machine == "" {
+				machine = e.MachineId
+			}
+			details = fmt.Sprintf(" (service=%s, machine=%s)", e.ServiceName, machine)
+		}
+		fmt.Printf("%s %s %s %s%s\n",
+			e.Time.AsTime().Local().Format(time.RFC3339), e.Type, e.Action, e.Name, details)
+	}
+}
@@ -0,0 +1,19 @@
+package cluster
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewRootCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cluster",
+		Short: "Manage an Uncloud cluster.",
+	}
+	cmd.AddCommand(
+		NewDiagnoseCommand(),
+		NewEventsCommand(),
+		NewStatusCommand(),
+		NewTopologyCommand(),
+	)
+	return cmd
+}
@@ -0,0 +1,97 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"uncloud/internal/cli"
+	"uncloud/internal/cli/client"
+)
+
+type statusOptions struct {
+	cluster string
+}
+
+func NewStatusCommand() *cobra.Command {
+	opts := statusOptions{}
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Display an overview of the cluster health.",
+		Long: "Display an overview of the cluster health: the membership state of each machine, whether its API " +
+			"is reachable through the cluster network, whether its cluster store is in sync with the other " +
+			"machines, and the number of running service containers. Use 'uc cluster diagnose' for detailed " +
+			"checks with hints on how to fix the problems.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			return status(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func status(ctx context.Context, uncli *cli.CLI, opts statusOptions) error {
+	c, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer c.Close()
+
+	st, err := c.ClusterStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("get cluster status: %w", err)
+	}
+	sort.Slice(st.Machines, func(i, j int) bool {
+		return st.Machines[i].Member.Machine.Name < st.Machines[j].Member.Machine.Name
+	})
+
+	if err = writeStatus(os.Stdout, st); err != nil {
+		return err
+	}
+	for _, ms := range st.Machines {
+		if !ms.Reachable || ms.StoreLagging {
+			return errors.New("cluster is not healthy")
+		}
+	}
+	return nil
+}
+
+// writeStatus writes the machine statuses in a table format followed by a summary.
+func writeStatus(w io.Writer, st *client.ClusterStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "MACHINE\tSTATE\tCONTAINERS\tSTORE\tAPI"); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	unhealthy := 0
+	for _, ms := range st.Machines {
+		api, store := "reachable", "in sync"
+		switch {
+		case !ms.Reachable:
+			api, store = fmt.Sprintf("unreachable: %v", ms.Err), "unknown"
+			unhealthy++
+		case ms.StoreLagging:
+			store = "lagging"
+			unhealthy++
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n",
+			ms.Member.Machine.Name, ms.Member.State, ms.Containers, store, api); err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d machines (%d unhealthy), %d services.\n",
+		len(st.Machines), unhealthy, st.Services)
+	return err
+}
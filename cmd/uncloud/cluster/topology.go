@@ -0,0 +1,228 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"uncloud/internal/api"
+	"uncloud/internal/cli"
+	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/network"
+)
+
+const formatDOT = "dot"
+
+type topologyOptions struct {
+	format  string
+	cluster string
+}
+
+func NewTopologyCommand() *cobra.Command {
+	opts := topologyOptions{}
+	cmd := &cobra.Command{
+		Use:   "topology",
+		Short: "Export the cluster topology as a diagram.",
+		Long: "Export the cluster topology as a diagram that shows machines, WireGuard peer links between them " +
+			"with their endpoints, and service containers running on each machine. The peer links are drawn from " +
+			"the latest WireGuard handshakes reported by each machine: links without a recent handshake are " +
+			"dashed and links without any handshake are red, which helps to find a partially connected mesh.\n\n" +
+			"Render the DOT output with Graphviz, for example: uc cluster topology | dot -Tsvg > topology.svg",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			return topology(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().StringVar(
+		&opts.format, "format", formatDOT,
+		"Output format. Only 'dot' (Graphviz) is supported.",
+	)
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func topology(ctx context.Context, uncli *cli.CLI, opts topologyOptions) error {
+	if opts.format != formatDOT {
+		return fmt.Errorf("unsupported format: %q", opts.format)
+	}
+
+	client, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	machines, err := client.ListMachines(ctx)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+	services, err := client.ListServices(ctx)
+	if err != nil {
+		return fmt.Errorf("list services: %w", err)
+	}
+
+	// Each machine only knows its own handshakes with its peers so ask every machine that is not DOWN.
+	handshakes := make(map[string]map[string]time.Time)
+	for _, member := range machines {
+		if member.State == pb.MachineMember_DOWN {
+			continue
+		}
+		machineCtx, cancel := context.WithTimeout(machineContext(ctx, member.Machine), diagnoseTimeout)
+		peers, err := client.ListMachines(machineCtx)
+		cancel()
+		if err != nil {
+			// Print to stderr to not break the diagram written to stdout.
+			fmt.Fprintf(os.Stderr, "WARNING: failed to get WireGuard handshakes from machine '%s': %v\n",
+				member.Machine.Name, err)
+			continue
+		}
+		handshakes[member.Machine.Id] = make(map[string]time.Time)
+		for _, p := range peers {
+			if p.LastHandshake != nil {
+				handshakes[member.Machine.Id][p.Machine.Id] = p.LastHandshake.AsTime()
+			}
+		}
+	}
+
+	return writeDOT(os.Stdout, machines, services, handshakes)
+}
+
+// writeDOT writes the cluster topology in the Graphviz DOT format. The WireGuard peer links between each pair
+// of machines are drawn from the handshakes that maps a machine ID to the latest handshake times with its peers
+// keyed by the peer machine ID. Machines missing in handshakes couldn't be queried. A link is solid if either
+// machine has a recent handshake with the other, dashed if the latest handshake is stale, red if neither machine
+// has ever completed a handshake with the other, and gray dotted if neither machine could be queried.
+func writeDOT(
+	w io.Writer, machines []*pb.MachineMember, services []api.Service, handshakes map[string]map[string]time.Time,
+) error {
+	sort.Slice(machines, func(i, j int) bool {
+		return machines[i].Machine.Name < machines[j].Machine.Name
+	})
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	var b strings.Builder
+	b.WriteString("graph uncloud {\n")
+	b.WriteString("  node [fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n\n")
+
+	// Machine nodes.
+	b.WriteString("  node [shape=box];\n")
+	for _, member := range machines {
+		m := member.Machine
+		subnet, _ := m.Network.Subnet.ToPrefix()
+		subnet = netip.PrefixFrom(network.MachineIP(subnet), subnet.Bits())
+
+		label := []string{m.Name, subnet.String(), strings.ToLower(member.State.String())}
+		style := "solid"
+		if member.State == pb.MachineMember_DOWN {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, style=%s];\n", dotID("machine", m.Id), dotString(label...), style)
+	}
+
+	// WireGuard peer links between each pair of machines labeled with the endpoints of both peers
+	// and the latest handshake between them.
+	b.WriteString("\n")
+	for i, a := range machines {
+		for _, z := range machines[i+1:] {
+			aPeers, aOK := handshakes[a.Machine.Id]
+			zPeers, zOK := handshakes[z.Machine.Id]
+			// A handshake is mutual so the latest one seen by either machine describes the link.
+			latest := aPeers[z.Machine.Id]
+			if t := zPeers[a.Machine.Id]; t.After(latest) {
+				latest = t
+			}
+
+			attrs := "style=solid"
+			var status string
+			switch {
+			case !aOK && !zOK:
+				attrs, status = "style=dotted, color=gray", "handshake unknown"
+			case latest.IsZero():
+				attrs, status = "style=dashed, color=red", "no handshake"
+			default:
+				since := time.Since(latest)
+				status = "handshake " + units.HumanDuration(since) + " ago"
+				if since > handshakeStaleAfter {
+					attrs = "style=dashed"
+				}
+			}
+			label := []string{
+				a.Machine.Name + ": " + endpoints(a.Machine),
+				z.Machine.Name + ": " + endpoints(z.Machine),
+				status,
+			}
+			fmt.Fprintf(&b, "  %s -- %s [label=%s, %s];\n",
+				dotID("machine", a.Machine.Id), dotID("machine", z.Machine.Id), dotString(label...), attrs)
+		}
+	}
+
+	// Service nodes linked to the machines where their containers run.
+	if len(services) > 0 {
+		b.WriteString("\n  node [shape=ellipse];\n")
+	}
+	for _, svc := range services {
+		fmt.Fprintf(&b, "  %s [label=%s];\n", dotID("service", svc.ID), dotString(svc.Name, svc.Mode))
+
+		// Count running and total containers on each machine.
+		running := make(map[string]int)
+		total := make(map[string]int)
+		var machineIDs []string
+		for _, mc := range svc.Containers {
+			if _, ok := total[mc.MachineID]; !ok {
+				machineIDs = append(machineIDs, mc.MachineID)
+			}
+			total[mc.MachineID]++
+			if mc.Container.State == "running" {
+				running[mc.MachineID]++
+			}
+		}
+		sort.Strings(machineIDs)
+		for _, mid := range machineIDs {
+			fmt.Fprintf(&b, "  %s -- %s [label=%s, style=dotted];\n",
+				dotID("service", svc.ID), dotID("machine", mid),
+				dotString(fmt.Sprintf("%d/%d running", running[mid], total[mid])))
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func endpoints(m *pb.MachineInfo) string {
+	if len(m.Network.Endpoints) == 0 {
+		return "no endpoints"
+	}
+	eps := make([]string, len(m.Network.Endpoints))
+	for i, ep := range m.Network.Endpoints {
+		addrPort, _ := ep.ToAddrPort()
+		eps[i] = addrPort.String()
+	}
+	return strings.Join(eps, ", ")
+}
+
+// dotID returns a quoted DOT node ID for an object of the given kind.
+func dotID(kind, id string) string {
+	return dotString(kind + ":" + id)
+}
+
+// dotString returns a quoted DOT string with the lines separated by DOT line breaks.
+func dotString(lines ...string) string {
+	escaped := make([]string, len(lines))
+	for i, l := range lines {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(l, `\`, `\\`), `"`, `\"`)
+	}
+	return `"` + strings.Join(escaped, `\n`) + `"`
+}
@@ -0,0 +1,140 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"uncloud/internal/cli"
+	"uncloud/internal/machine/api/pb"
+)
+
+type pruneOptions struct {
+	all      bool
+	until    string
+	machines []string
+	cluster  string
+}
+
+func NewPruneCommand() *cobra.Command {
+	opts := pruneOptions{}
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove unused images on machines in the cluster.",
+		Long: "Remove unused images on all machines in the cluster or only the specified machines. By default, only " +
+			"dangling images (not tagged and not referenced by other images) are removed. Images used by any " +
+			"container, including stopped service containers, are never removed.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			return prune(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().BoolVarP(&opts.all, "all", "a", false,
+		"Remove all unused images, not just dangling ones.")
+	cmd.Flags().StringVar(&opts.until, "until", "",
+		"Only remove images created before the given timestamp or duration relative to now, e.g. 24h or "+
+			"2024-01-02T15:04:05.")
+	cmd.Flags().StringSliceVarP(&opts.machines, "machine", "m", nil,
+		"Name or ID of the machine to remove images on. Can be specified multiple times. "+
+			"(default is all machines)")
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func prune(ctx context.Context, uncli *cli.CLI, opts pruneOptions) error {
+	client, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	machines, err := client.ListMachines(ctx)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+
+	// Broadcast the prune request to the selected available machines.
+	md := metadata.New(nil)
+	machineNames := make(map[string]string)
+	var selected []*pb.MachineInfo
+	for _, m := range machines {
+		if len(opts.machines) > 0 &&
+			!slices.Contains(opts.machines, m.Machine.Name) && !slices.Contains(opts.machines, m.Machine.Id) {
+			continue
+		}
+		if m.State != pb.MachineMember_UP && m.State != pb.MachineMember_SUSPECT {
+			fmt.Printf("WARNING: skipping machine '%s' which is down.\n", m.Machine.Name)
+			continue
+		}
+		machineIP, _ := m.Machine.Network.ManagementIp.ToAddr()
+		md.Append("machines", machineIP.String())
+		machineNames[machineIP.String()] = m.Machine.Name
+		selected = append(selected, m.Machine)
+	}
+	if len(selected) == 0 {
+		return errors.New("no available machines to prune images on")
+	}
+	pruneCtx := metadata.NewOutgoingContext(ctx, md)
+
+	pruneFilters := filters.NewArgs()
+	if opts.all {
+		pruneFilters.Add("dangling", "false")
+	}
+	if opts.until != "" {
+		pruneFilters.Add("until", opts.until)
+	}
+
+	reports, err := client.PruneImages(pruneCtx, pruneFilters)
+	if err != nil {
+		return fmt.Errorf("prune images: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	if _, err = fmt.Fprintln(tw, "MACHINE\tIMAGES DELETED\tSPACE RECLAIMED"); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	var total uint64
+	for _, r := range reports {
+		// The response has no metadata if the request was proxied to a single machine.
+		machine := selected[0].Name
+		if r.Metadata != nil {
+			machine = r.Metadata.Machine
+			if name, ok := machineNames[r.Metadata.Machine]; ok {
+				machine = name
+			}
+			if r.Metadata.Error != "" {
+				if _, err = fmt.Fprintf(tw, "%s\terror: %s\t\n", machine, r.Metadata.Error); err != nil {
+					return fmt.Errorf("write row: %w", err)
+				}
+				continue
+			}
+		}
+
+		deleted := 0
+		for _, d := range r.Report.ImagesDeleted {
+			if d.Deleted != "" {
+				deleted++
+			}
+		}
+		total += r.Report.SpaceReclaimed
+		if _, err = fmt.Fprintf(tw, "%s\t%d\t%s\n",
+			machine, deleted, units.HumanSize(float64(r.Report.SpaceReclaimed))); err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+	}
+	if err = tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Printf("\nTotal reclaimed space: %s\n", units.HumanSize(float64(total)))
+	return err
+}
@@ -0,0 +1,16 @@
+package image
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func NewRootCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "image",
+		Short: "Manage images on machines in an Uncloud cluster.",
+	}
+	cmd.AddCommand(
+		NewPruneCommand(),
+	)
+	return cmd
+}
@@ -3,14 +3,21 @@ package machine
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"net/netip"
 	"uncloud/internal/cli"
 	"uncloud/internal/cli/config"
+	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/network"
 )
 
 type addOptions struct {
-	name    string
-	sshKey  string
-	cluster string
+	name        string
+	role        string
+	subnet      string
+	wgEndpoint  string
+	secretStdin bool
+	sshKey      string
+	cluster     string
 }
 
 func NewAddCommand() *cobra.Command {
@@ -33,10 +40,56 @@ func NewAddCommand() *cobra.Command {
 				KeyPath: opts.sshKey,
 			}
 
-			return uncli.AddMachine(cmd.Context(), remoteMachine, opts.cluster, opts.name)
+			if err = pb.ValidateMachineRole(opts.role); err != nil {
+				return err
+			}
+			var subnet netip.Prefix
+			if opts.subnet != "" {
+				if subnet, err = netip.ParsePrefix(opts.subnet); err != nil {
+					return fmt.Errorf("parse subnet CIDR: %w", err)
+				}
+			}
+
+			var wgEndpoint netip.AddrPort
+			if opts.wgEndpoint != "" {
+				if wgEndpoint, err = network.ParseEndpoint(opts.wgEndpoint); err != nil {
+					return fmt.Errorf("parse WireGuard endpoint: %w", err)
+				}
+			}
+
+			clusterSecret, err := readClusterSecret(opts.secretStdin)
+			if err != nil {
+				return err
+			}
+
+			return uncli.AddMachine(
+				cmd.Context(), remoteMachine, opts.cluster, opts.name, opts.role, subnet, clusterSecret, wgEndpoint,
+			)
 		},
 	}
 	cmd.Flags().StringVarP(&opts.name, "name", "n", "", "Assign a name to the machine.")
+	cmd.Flags().StringVar(
+		&opts.role, "role", pb.MachineRoleWorker,
+		fmt.Sprintf("Role of the machine in the cluster: either %q (runs service containers) or %q "+
+			"(only runs the cluster components).", pb.MachineRoleWorker, pb.MachineRoleControlPlane),
+	)
+	cmd.Flags().StringVar(
+		&opts.subnet, "subnet", "",
+		"Subnet from the cluster network to assign to the machine, e.g. 10.210.1.0/24, at most /30 "+
+			"(/126 for IPv6). (default is the next available /24 or /64 subnet)",
+	)
+	cmd.Flags().StringVar(
+		&opts.wgEndpoint, "wg-endpoint", "",
+		"WireGuard endpoint HOST[:PORT] of the machine, e.g. a port forwarded on a NAT gateway, that other "+
+			"machines connect to in preference to the auto-discovered endpoints. HOST is resolved to an IP "+
+			"address once. (default port is 51820)",
+	)
+	cmd.Flags().BoolVar(
+		&opts.secretStdin, "cluster-secret-stdin", false,
+		"Read the pre-shared cluster secret from stdin. Required if the cluster was initialised with a secret. "+
+			"The machine verifies that the cluster knows the same secret before joining it. "+
+			"(default is the "+clusterSecretEnvVar+" environment variable)",
+	)
 	cmd.Flags().StringVarP(
 		&opts.sshKey, "ssh-key", "i", "",
 		"path to SSH private key for SSH remote login. (default ~/.ssh/id_*)",
@@ -0,0 +1,74 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"uncloud/internal/cli"
+	"uncloud/internal/machine/api/pb"
+)
+
+type cordonOptions struct {
+	machine string
+	cluster string
+}
+
+func NewCordonCommand() *cobra.Command {
+	opts := cordonOptions{}
+	cmd := &cobra.Command{
+		Use:   "cordon MACHINE",
+		Short: "Mark a machine as unschedulable for new service containers.",
+		Long: "Mark a machine as unschedulable for new service containers. Existing service containers on the " +
+			"machine keep running, use 'uc machine drain' to move them to other machines.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.machine = args[0]
+			return setCordoned(cmd.Context(), uncli, opts, true)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func NewUncordonCommand() *cobra.Command {
+	opts := cordonOptions{}
+	cmd := &cobra.Command{
+		Use:   "uncordon MACHINE",
+		Short: "Mark a machine as schedulable for new service containers.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.machine = args[0]
+			return setCordoned(cmd.Context(), uncli, opts, false)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func setCordoned(ctx context.Context, uncli *cli.CLI, opts cordonOptions, cordoned bool) error {
+	client, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	resp, err := client.UpdateMachine(ctx, &pb.UpdateMachineRequest{Machine: opts.machine, Cordoned: &cordoned})
+	if err != nil {
+		return fmt.Errorf("update machine: %w", err)
+	}
+	if cordoned {
+		fmt.Printf("Machine %q cordoned.\n", resp.Machine.Name)
+	} else {
+		fmt.Printf("Machine %q uncordoned.\n", resp.Machine.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"uncloud/internal/cli"
+)
+
+type drainOptions struct {
+	machine string
+	cluster string
+}
+
+func NewDrainCommand() *cobra.Command {
+	opts := drainOptions{}
+	cmd := &cobra.Command{
+		Use:   "drain MACHINE",
+		Short: "Cordon a machine and move its service containers to other machines.",
+		Long: "Cordon a machine to prevent new service containers from being placed on it and move its existing " +
+			"service containers off it. Containers of replicated services are spread evenly across the other " +
+			"available machines and then removed. Containers of global services are removed. Data in the volumes " +
+			"of the moved containers is not copied.\n\n" +
+			"The command fails without making any changes if there is no other available machine to move " +
+			"the replicated service containers to. The CPU and memory available on the other machines are not " +
+			"checked, make sure they have enough capacity to run the moved containers.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.machine = args[0]
+			return drain(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func drain(ctx context.Context, uncli *cli.CLI, opts drainOptions) error {
+	client, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	if err = client.DrainMachine(ctx, opts.machine); err != nil {
+		return fmt.Errorf("drain machine: %w", err)
+	}
+	fmt.Printf("Machine %q drained.\n", opts.machine)
+
+	return nil
+}
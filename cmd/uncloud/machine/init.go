@@ -6,14 +6,26 @@ import (
 	"net/netip"
 	"uncloud/internal/cli"
 	"uncloud/internal/cli/config"
+	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/caddyfile"
 	"uncloud/internal/machine/cluster"
+	"uncloud/internal/machine/network"
 )
 
 type initOptions struct {
-	name    string
-	network string
-	sshKey  string
-	cluster string
+	name        string
+	network     string
+	iface       string
+	role        string
+	ingress     string
+	publicIP    string
+	subnet      string
+	wgEndpoint  string
+	secretStdin bool
+	dryRun      bool
+	resume      bool
+	sshKey      string
+	cluster     string
 }
 
 func NewInitCommand() *cobra.Command {
@@ -44,13 +56,108 @@ func NewInitCommand() *cobra.Command {
 				return fmt.Errorf("parse network CIDR: %w", err)
 			}
 
-			return uncli.InitCluster(cmd.Context(), remoteMachine, opts.cluster, opts.name, netPrefix)
+			if err = pb.ValidateMachineRole(opts.role); err != nil {
+				return err
+			}
+			if err = caddyfile.ValidateIngress(opts.ingress); err != nil {
+				return err
+			}
+			if err = network.ValidatePublicIPSource(opts.publicIP); err != nil {
+				return err
+			}
+			var subnet netip.Prefix
+			if opts.subnet != "" {
+				if subnet, err = netip.ParsePrefix(opts.subnet); err != nil {
+					return fmt.Errorf("parse subnet CIDR: %w", err)
+				}
+			}
+			var wgEndpoint netip.AddrPort
+			if opts.wgEndpoint != "" {
+				if wgEndpoint, err = network.ParseEndpoint(opts.wgEndpoint); err != nil {
+					return fmt.Errorf("parse WireGuard endpoint: %w", err)
+				}
+			}
+
+			clusterSecret, err := readClusterSecret(opts.secretStdin)
+			if err != nil {
+				return err
+			}
+
+			return uncli.InitCluster(cmd.Context(), remoteMachine, cli.InitClusterOptions{
+				ClusterName:    opts.cluster,
+				MachineName:    opts.name,
+				Network:        netPrefix,
+				Interface:      opts.iface,
+				Role:           opts.role,
+				Ingress:        opts.ingress,
+				PublicIPSource: opts.publicIP,
+				Subnet:         subnet,
+				ClusterSecret:  clusterSecret,
+				DryRun:         opts.dryRun,
+				Resume:         opts.resume,
+				WGEndpoint:     wgEndpoint,
+			})
 		},
 	}
 	cmd.Flags().StringVarP(&opts.name, "name", "n", "", "Assign a name to the machine.")
 	cmd.Flags().StringVar(
 		&opts.network, "network", cluster.DefaultNetwork.String(),
-		"IPv4 network CIDR to use for machines and services.",
+		"IPv4 or IPv6 network CIDR to use for machines and services. Machines are assigned /24 subnets from "+
+			"an IPv4 network and /64 subnets from an IPv6 network.",
+	)
+	cmd.Flags().StringVar(
+		&opts.subnet, "subnet", "",
+		"Subnet from the cluster network to assign to the machine, e.g. 10.210.1.0/24, at most /30 "+
+			"(/126 for IPv6). (default is the next available /24 or /64 subnet)",
+	)
+	cmd.Flags().StringVar(
+		&opts.iface, "interface", "",
+		"Name of the network interface on the machine which IP addresses to use as WireGuard endpoints, "+
+			"e.g. eth1. (default is all routable addresses and the public IP)",
+	)
+	cmd.Flags().StringVar(
+		&opts.publicIP, "public-ip", "",
+		"Source of the machine's public IP address to use as a WireGuard endpoint: 'auto' (query well-known "+
+			"API services), 'none', a fixed IP address, an HTTP(S) URL of a resolver returning the IP in "+
+			"plain text, or 'stun:HOST[:PORT]'. (default is the machine daemon config, 'auto' if not set)",
+	)
+	cmd.Flags().StringVar(
+		&opts.wgEndpoint, "wg-endpoint", "",
+		"WireGuard endpoint HOST[:PORT] of the machine, e.g. a port forwarded on a NAT gateway, that other "+
+			"machines connect to in preference to the auto-discovered endpoints. HOST is resolved to an IP "+
+			"address once. (default port is 51820)",
+	)
+	cmd.Flags().StringVar(
+		&opts.role, "role", pb.MachineRoleWorker,
+		fmt.Sprintf("Role of the machine in the cluster: either %q (runs service containers) or %q "+
+			"(only runs the cluster components).", pb.MachineRoleWorker, pb.MachineRoleControlPlane),
+	)
+	cmd.Flags().StringVar(
+		&opts.ingress, "ingress", caddyfile.IngressCaddy,
+		fmt.Sprintf("Ingress controller for routing external traffic to services: either %q or %q. "+
+			"The machines generate the configuration for the selected reverse proxy. Uncloud deploys Caddy but "+
+			"not Traefik: with %q, you have to run Traefik on each machine yourself with the file provider "+
+			"watching the generated dynamic configuration /var/lib/uncloud/traefik/dynamic.yml and the web, "+
+			"websecure, tcp-<port>, and udp-<port> entry points for each TCP and UDP ingress port defined in its "+
+			"static configuration. TCP and UDP ingress ports are only supported by %q.",
+			caddyfile.IngressCaddy, caddyfile.IngressTraefik, caddyfile.IngressTraefik, caddyfile.IngressTraefik),
+	)
+	cmd.Flags().BoolVar(
+		&opts.secretStdin, "cluster-secret-stdin", false,
+		"Read an optional pre-shared cluster secret from stdin that must be provided to add machines to "+
+			"the cluster. The machines being added only join a cluster that proves it knows the secret. "+
+			"Only a key derived from the secret is stored in the cluster. "+
+			"(default is the "+clusterSecretEnvVar+" environment variable)",
+	)
+	cmd.Flags().BoolVar(
+		&opts.dryRun, "dry-run", false,
+		"Run the preflight checks on the machine and report what would be installed on it and changed "+
+			"in the cluster without making any changes.",
+	)
+	cmd.Flags().BoolVar(
+		&opts.resume, "resume", false,
+		"Continue a previous initialisation of the machine that failed midway. The steps that have already "+
+			"completed are skipped. Use the same flags as in the failed initialisation.",
 	)
 	cmd.Flags().StringVarP(
 		&opts.sshKey, "ssh-key", "i", "",
@@ -0,0 +1,114 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"sort"
+	"strings"
+	"uncloud/internal/cli"
+	"uncloud/internal/machine/api/pb"
+)
+
+func NewLabelCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "label",
+		Short: "Manage labels of a machine.",
+		Long: "Manage labels of a machine. Labels are key-value pairs describing the machine, e.g. its region " +
+			"or hardware.",
+	}
+	cmd.AddCommand(
+		newLabelSetCommand(),
+		newLabelRmCommand(),
+	)
+	return cmd
+}
+
+type labelOptions struct {
+	machine string
+	cluster string
+}
+
+func newLabelSetCommand() *cobra.Command {
+	opts := labelOptions{}
+	cmd := &cobra.Command{
+		Use:   "set MACHINE KEY=VALUE...",
+		Short: "Add or update labels of a machine.",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.machine = args[0]
+
+			req := &pb.UpdateMachineLabelsRequest{
+				Machine: opts.machine,
+				Set:     make(map[string]string, len(args)-1),
+			}
+			for _, l := range args[1:] {
+				key, value, ok := strings.Cut(l, "=")
+				if !ok {
+					return fmt.Errorf("invalid label '%s': must be in the format key=value", l)
+				}
+				if err := pb.ValidateMachineLabel(key); err != nil {
+					return err
+				}
+				req.Set[key] = value
+			}
+			return updateLabels(cmd.Context(), uncli, req, opts.cluster)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func newLabelRmCommand() *cobra.Command {
+	opts := labelOptions{}
+	cmd := &cobra.Command{
+		Use:     "rm MACHINE KEY...",
+		Aliases: []string{"remove"},
+		Short:   "Remove labels from a machine.",
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.machine = args[0]
+
+			req := &pb.UpdateMachineLabelsRequest{
+				Machine: opts.machine,
+				Remove:  args[1:],
+			}
+			return updateLabels(cmd.Context(), uncli, req, opts.cluster)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func updateLabels(ctx context.Context, uncli *cli.CLI, req *pb.UpdateMachineLabelsRequest, clusterName string) error {
+	client, err := uncli.ConnectCluster(ctx, clusterName)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	resp, err := client.UpdateMachineLabels(ctx, req)
+	if err != nil {
+		return fmt.Errorf("update machine labels: %w", err)
+	}
+
+	labels := make([]string, 0, len(resp.Machine.Labels))
+	for k, v := range resp.Machine.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	if len(labels) == 0 {
+		labels = []string{"none"}
+	}
+	fmt.Printf("Machine %q labels updated: %s.\n", resp.Machine.Name, strings.Join(labels, ", "))
+
+	return nil
+}
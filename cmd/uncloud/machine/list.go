@@ -3,11 +3,13 @@ package machine
 import (
 	"context"
 	"fmt"
+	"github.com/docker/go-units"
 	"github.com/spf13/cobra"
 	"net/netip"
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 	"uncloud/internal/cli"
 	"uncloud/internal/machine/network"
 	"uncloud/internal/secret"
@@ -46,7 +48,7 @@ func list(ctx context.Context, uncli *cli.CLI, clusterName string) error {
 	// Print the list of machines in a table format.
 	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
 	// Print header.
-	if _, err = fmt.Fprintln(tw, "NAME\tSTATE\tADDRESS\tPUBLIC KEY\tENDPOINTS"); err != nil {
+	if _, err = fmt.Fprintln(tw, "NAME\tSTATE\tLAST SEEN\tROLE\tADDRESS\tPUBLIC KEY\tENDPOINTS"); err != nil {
 		return fmt.Errorf("write header: %w", err)
 	}
 	// Print rows.
@@ -60,8 +62,19 @@ func list(ctx context.Context, uncli *cli.CLI, clusterName string) error {
 			endpoints[i] = addrPort.String()
 		}
 		publicKey := secret.Secret(m.Network.PublicKey)
+		// The last seen time is the latest WireGuard handshake with the machine from the machine serving
+		// the request. It's not known for the serving machine itself.
+		lastSeen := "-"
+		if member.LastHandshake != nil {
+			lastSeen = units.HumanDuration(time.Since(member.LastHandshake.AsTime())) + " ago"
+		}
+		role := m.MachineRole()
+		if m.Cordoned {
+			role += " (cordoned)"
+		}
 		if _, err = fmt.Fprintf(
-			tw, "%s\t%s\t%s\t%s\t%s\n", m.Name, capitalise(member.State.String()), subnet, publicKey, strings.Join(endpoints, ", "),
+			tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", m.Name, capitalise(member.State.String()), lastSeen,
+			role, subnet, publicKey, strings.Join(endpoints, ", "),
 		); err != nil {
 			return fmt.Errorf("write row: %w", err)
 		}
@@ -0,0 +1,71 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"uncloud/internal/cli"
+	"uncloud/internal/machine/api/pb"
+)
+
+type reconfigureNetworkOptions struct {
+	machine string
+	cluster string
+}
+
+func NewReconfigureNetworkCommand() *cobra.Command {
+	opts := reconfigureNetworkOptions{}
+	cmd := &cobra.Command{
+		Use:   "reconfigure-network MACHINE",
+		Short: "Force a machine to reconfigure its WireGuard network peers.",
+		Long: "Force a machine to reconfigure its WireGuard network peers with the current machines from the " +
+			"cluster store. Use it to recover the network on the machine from a bad state, e.g. stale peers " +
+			"or wrong endpoints, without restarting the machine daemon.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.machine = args[0]
+			return reconfigureNetwork(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func reconfigureNetwork(ctx context.Context, uncli *cli.CLI, opts reconfigureNetworkOptions) error {
+	client, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	machines, err := client.ListMachines(ctx)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+	var m *pb.MachineInfo
+	for _, mm := range machines {
+		if mm.Machine.Id == opts.machine || mm.Machine.Name == opts.machine {
+			m = mm.Machine
+			break
+		}
+	}
+	if m == nil {
+		return fmt.Errorf("machine %q not found", opts.machine)
+	}
+
+	// Proxy the request to the target machine.
+	machineIP, _ := m.Network.ManagementIp.ToAddr()
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("machines", machineIP.String()))
+	if _, err = client.MachineClient.ReconfigureNetwork(ctx, &emptypb.Empty{}); err != nil {
+		return fmt.Errorf("reconfigure network on machine %q: %w", m.Name, err)
+	}
+	fmt.Printf("Network peers reconfigured on machine %q.\n", m.Name)
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package machine
+
+import (
+	"github.com/spf13/cobra"
+	"uncloud/internal/cli"
+)
+
+type rmOptions struct {
+	machine string
+	noDrain bool
+	purge   bool
+	sshKey  string
+	cluster string
+}
+
+func NewRmCommand() *cobra.Command {
+	opts := rmOptions{}
+	cmd := &cobra.Command{
+		Use:     "rm MACHINE",
+		Aliases: []string{"remove", "delete"},
+		Short:   "Remove a machine from a cluster.",
+		Long: "Remove a machine from a cluster. The machine is first drained: containers of replicated services " +
+			"are spread across the other available machines and containers of global services are removed. Then " +
+			"the machine is removed from the cluster and the remaining machines stop peering with it. " +
+			"It's safe to re-run the command if it failed midway.\n\n" +
+			"Ports published in host mode on the removed machine stop being served. Moved containers publish them " +
+			"on the machine they are moved to, so update the DNS records or firewall rules pointing to " +
+			"the removed machine. Ports published through the ingress keep being served by the other machines.\n\n" +
+			"With --no-drain, service containers on the machine are left running unless --purge is specified.\n\n" +
+			"Without --purge, the Uncloud daemons on the removed machine are stopped and disabled over SSH so that " +
+			"it stops syncing its containers to the cluster. Its data, network configuration, and service " +
+			"containers are left in place.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.machine = args[0]
+			return uncli.RemoveMachine(cmd.Context(), opts.cluster, opts.machine, !opts.noDrain, opts.purge,
+				opts.sshKey)
+		},
+	}
+	cmd.Flags().BoolVar(
+		&opts.noDrain, "no-drain", false,
+		"Remove the machine without moving its service containers to other machines, e.g. if the machine "+
+			"is unreachable.",
+	)
+	cmd.Flags().BoolVar(
+		&opts.purge, "purge", false,
+		"Also uninstall Uncloud from the machine over SSH: stop and remove the systemd services, service "+
+			"containers, Docker network, WireGuard interface, iptables rules, binaries, and data directory.",
+	)
+	cmd.Flags().StringVarP(
+		&opts.sshKey, "ssh-key", "i", "",
+		"path to SSH private key for SSH remote login when stopping or purging the machine. (default ~/.ssh/id_*)",
+	)
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
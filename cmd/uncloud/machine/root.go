@@ -11,9 +11,17 @@ func NewRootCommand() *cobra.Command {
 	}
 	cmd.AddCommand(
 		NewAddCommand(),
+		NewCordonCommand(),
+		NewDrainCommand(),
 		NewInitCommand(),
+		NewLabelCommand(),
 		NewListCommand(),
+		NewReconfigureNetworkCommand(),
+		NewRmCommand(),
+		NewSetCommand(),
+		NewSetEndpointCommand(),
 		NewTokenCommand(),
+		NewUncordonCommand(),
 	)
 	return cmd
 }
@@ -0,0 +1,31 @@
+package machine
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// clusterSecretEnvVar is the environment variable with the pre-shared cluster secret.
+const clusterSecretEnvVar = "UNCLOUD_CLUSTER_SECRET"
+
+// readClusterSecret reads the pre-shared cluster secret from the first line of stdin if fromStdin is true or from
+// the UNCLOUD_CLUSTER_SECRET environment variable otherwise. The secret isn't accepted as a flag value so that it
+// doesn't show up in the process list and shell history.
+func readClusterSecret(fromStdin bool) (string, error) {
+	if !fromStdin {
+		return os.Getenv(clusterSecretEnvVar), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("read cluster secret from stdin: %w", err)
+	}
+	clusterSecret := strings.TrimSpace(line)
+	if clusterSecret == "" {
+		return "", errors.New("cluster secret read from stdin is empty")
+	}
+	return clusterSecret, nil
+}
@@ -0,0 +1,68 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"uncloud/internal/cli"
+	"uncloud/internal/machine/api/pb"
+)
+
+type setOptions struct {
+	machine string
+	role    string
+	cluster string
+}
+
+func NewSetCommand() *cobra.Command {
+	opts := setOptions{}
+	cmd := &cobra.Command{
+		Use:   "set MACHINE",
+		Short: "Update the configuration of a machine in a cluster.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.machine = args[0]
+
+			req := &pb.UpdateMachineRequest{Machine: opts.machine}
+			if cmd.Flags().Changed("role") {
+				if err := pb.ValidateMachineRole(opts.role); err != nil {
+					return err
+				}
+				req.Role = &opts.role
+			}
+			if req.Role == nil {
+				return fmt.Errorf("nothing to update, specify at least one of the flags")
+			}
+
+			return set(cmd.Context(), uncli, req, opts.cluster)
+		},
+	}
+	cmd.Flags().StringVar(
+		&opts.role, "role", "",
+		fmt.Sprintf("Role of the machine in the cluster: either %q (runs service containers) or %q "+
+			"(only runs the cluster components). Existing service containers on the machine are not moved.",
+			pb.MachineRoleWorker, pb.MachineRoleControlPlane),
+	)
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func set(ctx context.Context, uncli *cli.CLI, req *pb.UpdateMachineRequest, clusterName string) error {
+	client, err := uncli.ConnectCluster(ctx, clusterName)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	resp, err := client.UpdateMachine(ctx, req)
+	if err != nil {
+		return fmt.Errorf("update machine: %w", err)
+	}
+	fmt.Printf("Machine %q updated, role: %s.\n", resp.Machine.Name, resp.Machine.MachineRole())
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"uncloud/internal/cli"
+	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/network"
+)
+
+type setEndpointOptions struct {
+	machine  string
+	endpoint string
+	clear    bool
+	cluster  string
+}
+
+func NewSetEndpointCommand() *cobra.Command {
+	opts := setEndpointOptions{}
+	cmd := &cobra.Command{
+		Use:   "set-endpoint MACHINE [HOST[:PORT]]",
+		Short: "Set the WireGuard endpoint of a machine that other machines connect to.",
+		Long: "Set the WireGuard endpoint of a machine, e.g. a port forwarded on a NAT gateway, that other " +
+			"machines connect to in preference to the auto-discovered endpoints. HOST is resolved to an IP " +
+			"address once. The default port is 51820. The other machines reconfigure their WireGuard peers " +
+			"once the change is synced.",
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.machine = args[0]
+			if len(args) > 1 {
+				opts.endpoint = args[1]
+			}
+			return setEndpoint(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.clear, "clear", false,
+		"Remove the WireGuard endpoint set for the machine and use only the auto-discovered endpoints.")
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func setEndpoint(ctx context.Context, uncli *cli.CLI, opts setEndpointOptions) error {
+	req := &pb.UpdateMachineRequest{Machine: opts.machine}
+	switch {
+	case opts.clear && opts.endpoint != "":
+		return errors.New("specify either an endpoint or --clear, not both")
+	case opts.clear:
+		req.ClearManualEndpoint = true
+	case opts.endpoint == "":
+		return errors.New("endpoint not specified, use --clear to remove the endpoint")
+	default:
+		endpoint, err := network.ParseEndpoint(opts.endpoint)
+		if err != nil {
+			return fmt.Errorf("parse WireGuard endpoint: %w", err)
+		}
+		req.ManualEndpoint = pb.NewIPPort(endpoint)
+	}
+
+	client, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	resp, err := client.UpdateMachine(ctx, req)
+	if err != nil {
+		return fmt.Errorf("update machine: %w", err)
+	}
+	if req.ClearManualEndpoint {
+		fmt.Printf("WireGuard endpoint of machine %q cleared.\n", resp.Machine.Name)
+		return nil
+	}
+	endpoint, _ := resp.Machine.Network.ManualEndpoint.ToAddrPort()
+	fmt.Printf("WireGuard endpoint of machine %q set to %s.\n", resp.Machine.Name, endpoint)
+	return nil
+}
@@ -8,7 +8,8 @@ import (
 )
 
 type tokenOptions struct {
-	dataDir string
+	dataDir  string
+	sockPath string
 }
 
 func NewTokenCommand() *cobra.Command {
@@ -17,7 +18,7 @@ func NewTokenCommand() *cobra.Command {
 		Use:   "token",
 		Short: "Print the local machine's token for adding it to a cluster.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			token, err := daemon.MachineToken(opts.dataDir)
+			token, err := daemon.MachineToken(cmd.Context(), opts.sockPath)
 			if err != nil {
 				return fmt.Errorf("get machine token: %w", err)
 			}
@@ -32,7 +33,10 @@ func NewTokenCommand() *cobra.Command {
 
 	cmd.Flags().StringVarP(&opts.dataDir, "data-dir", "d", machine.DefaultDataDir,
 		"Directory for storing persistent machine state.")
-	_ = cmd.MarkFlagDirname("data-dir")
+	_ = cmd.Flags().MarkDeprecated("data-dir", "the token is now requested from the uncloudd daemon, use --socket "+
+		"if the daemon listens on a non-default machine API socket.")
+	cmd.Flags().StringVar(&opts.sockPath, "socket", machine.DefaultMachineSockPath,
+		"Path to the machine API unix socket of the uncloudd daemon.")
 
 	return cmd
 }
@@ -6,8 +6,11 @@ import (
 	"github.com/spf13/cobra"
 	"os"
 	"strings"
+	"uncloud/cmd/uncloud/cluster"
+	"uncloud/cmd/uncloud/image"
 	"uncloud/cmd/uncloud/machine"
 	"uncloud/cmd/uncloud/service"
+	"uncloud/cmd/uncloud/volume"
 	"uncloud/internal/cli"
 )
 
@@ -41,12 +44,18 @@ func main() {
 	_ = cmd.MarkPersistentFlagFilename("uncloud-config", "toml")
 
 	cmd.AddCommand(
+		cluster.NewRootCommand(),
+		cluster.NewEventsCommand(),
+		image.NewRootCommand(),
 		machine.NewRootCommand(),
 		service.NewRootCommand(),
+		service.NewCpCommand(),
 		service.NewInspectCommand(),
 		service.NewListCommand(),
 		service.NewRmCommand(),
 		service.NewRunCommand(),
+		service.NewStatsCommand(),
+		volume.NewRootCommand(),
 	)
 	cobra.CheckErr(cmd.Execute())
 }
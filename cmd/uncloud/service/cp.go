@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+	"path/filepath"
+	"strings"
+	"uncloud/internal/cli"
+	"uncloud/internal/cli/client"
+)
+
+type cpOptions struct {
+	src        string
+	dst        string
+	archive    bool
+	container  string
+	followLink bool
+	cluster    string
+}
+
+func NewCpCommand() *cobra.Command {
+	opts := cpOptions{}
+	cmd := &cobra.Command{
+		Use:   "cp [OPTIONS] SERVICE:SRC_PATH DEST_PATH\n  cp [OPTIONS] SRC_PATH SERVICE:DEST_PATH",
+		Short: "Copy files or directories between a service container and the local filesystem.",
+		Long: "Copy files or directories between a service container and the local filesystem. Directories are " +
+			"copied recursively preserving the file modes. Copying to a service copies to all its containers " +
+			"across the machines unless --container is specified. Copying from a service with multiple " +
+			"containers requires --container to select one of them.\n\n" +
+			"A local path must be absolute or start with '.' if it contains a colon.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.src, opts.dst = args[0], args[1]
+			return cp(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().BoolVarP(&opts.archive, "archive", "a", false,
+		"Archive mode: preserve the UID and GID of the copied files when copying to a container.")
+	cmd.Flags().StringVar(&opts.container, "container", "",
+		"ID or ID prefix of the service container to copy to or from. (default is all containers when copying "+
+			"to a service and the only container when copying from a service)")
+	cmd.Flags().BoolVarP(&opts.followLink, "follow-link", "L", false,
+		"Always follow a symbolic link in SRC_PATH.")
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func cp(ctx context.Context, uncli *cli.CLI, opts cpOptions) error {
+	srcService, srcPath := splitCpArg(opts.src)
+	dstService, dstPath := splitCpArg(opts.dst)
+	if (srcService == "") == (dstService == "") {
+		return errors.New("exactly one of the source and destination must be a service path in the format " +
+			"SERVICE:PATH")
+	}
+
+	c, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer c.Close()
+
+	if srcService != "" {
+		return copyFromService(ctx, c, srcService, srcPath, dstPath, opts)
+	}
+	return copyToService(ctx, c, srcPath, dstService, dstPath, opts)
+}
+
+// splitCpArg splits a 'SERVICE:PATH' argument into the service name and path. The service is empty for a local
+// path. Absolute paths and paths starting with '.' are always local to allow colons in local file names.
+func splitCpArg(arg string) (service, path string) {
+	if filepath.IsAbs(arg) || strings.HasPrefix(arg, ".") {
+		return "", arg
+	}
+	service, path, ok := strings.Cut(arg, ":")
+	if !ok {
+		return "", arg
+	}
+	return service, path
+}
+
+// copyToService copies a local file or directory to the containers of the service.
+func copyToService(ctx context.Context, c *client.Client, srcPath, service, dstPath string, opts cpOptions) error {
+	containers, err := serviceContainers(ctx, c, service, opts.container)
+	if err != nil {
+		return err
+	}
+
+	srcInfo, err := archive.CopyInfoSourcePath(srcPath, opts.followLink)
+	if err != nil {
+		return fmt.Errorf("stat source path: %w", err)
+	}
+
+	for _, sc := range containers {
+		// The archive is consumed by the copy so create a new one for each container.
+		content, err := archive.TarResource(srcInfo)
+		if err != nil {
+			return fmt.Errorf("archive source path: %w", err)
+		}
+		err = c.CopyToContainer(sc.ctx, sc.id, dstPath, srcInfo, content, container.CopyToContainerOptions{
+			CopyUIDGID: opts.archive,
+		})
+		content.Close()
+		if err != nil {
+			return fmt.Errorf("copy to container '%s': %w", sc.id, err)
+		}
+		fmt.Printf("Copied '%s' to container '%s' of service %q.\n", srcPath, stringid.TruncateID(sc.id), service)
+	}
+	return nil
+}
+
+// copyFromService copies a file or directory from a container of the service to the local filesystem.
+func copyFromService(ctx context.Context, c *client.Client, service, srcPath, dstPath string, opts cpOptions) error {
+	containers, err := serviceContainers(ctx, c, service, opts.container)
+	if err != nil {
+		return err
+	}
+	if len(containers) > 1 {
+		return fmt.Errorf("service %q has %d containers, specify the one to copy from with --container",
+			service, len(containers))
+	}
+	sc := containers[0]
+
+	content, stat, err := c.CopyFromContainer(sc.ctx, sc.id, srcPath)
+	if err != nil {
+		return fmt.Errorf("copy from container '%s': %w", sc.id, err)
+	}
+	defer content.Close()
+
+	srcInfo := archive.CopyInfo{
+		Path:   srcPath,
+		Exists: true,
+		IsDir:  stat.Mode.IsDir(),
+	}
+	if err = archive.CopyTo(content, srcInfo, dstPath); err != nil {
+		return fmt.Errorf("extract archive to '%s': %w", dstPath, err)
+	}
+	return nil
+}
+
+// serviceContainer is a container of a service with a context that proxies requests to its machine.
+type serviceContainer struct {
+	id  string
+	ctx context.Context
+}
+
+// serviceContainers returns the containers of the service optionally filtered by the container ID prefix.
+func serviceContainers(
+	ctx context.Context, c *client.Client, service, containerPrefix string,
+) ([]serviceContainer, error) {
+	svc, err := c.InspectService(ctx, service)
+	if err != nil {
+		if errors.Is(err, client.ErrNotFound) {
+			return nil, fmt.Errorf("service %q not found", service)
+		}
+		return nil, fmt.Errorf("inspect service: %w", err)
+	}
+
+	machines, err := c.ListMachines(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list machines: %w", err)
+	}
+	machineIPs := make(map[string]string, len(machines))
+	for _, m := range machines {
+		machineIP, _ := m.Machine.Network.ManagementIp.ToAddr()
+		machineIPs[m.Machine.Id] = machineIP.String()
+	}
+
+	var containers []serviceContainer
+	for _, mc := range svc.Containers {
+		if !strings.HasPrefix(mc.Container.ID, containerPrefix) {
+			continue
+		}
+		machineIP, ok := machineIPs[mc.MachineID]
+		if !ok {
+			return nil, fmt.Errorf("machine not found by ID: %s", mc.MachineID)
+		}
+		containers = append(containers, serviceContainer{
+			id:  mc.Container.ID,
+			ctx: metadata.NewOutgoingContext(ctx, metadata.Pairs("machines", machineIP)),
+		})
+	}
+	if len(containers) == 0 {
+		if containerPrefix != "" {
+			return nil, fmt.Errorf("container '%s' not found in service %q", containerPrefix, service)
+		}
+		return nil, fmt.Errorf("service %q has no containers", service)
+	}
+	return containers, nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"uncloud/internal/cli"
+)
+
+func NewCreateCommand() *cobra.Command {
+	opts := runOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "create IMAGE [COMMAND...]",
+		Short: "Create a service without starting it.",
+		Long: "Create a service without starting it. Only the service spec is stored in the cluster, no containers " +
+			"are created, so that the service can be reviewed first and then started with 'service start'.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+
+			opts.image = args[0]
+			if len(args) > 1 {
+				opts.command = args[1:]
+			}
+			// Zero is a valid stop grace period that kills containers immediately, so it must be distinguished
+			// from the unset flag.
+			opts.stopGracePeriodSet = cmd.Flags().Changed("stop-grace-period")
+
+			return create(cmd.Context(), uncli, opts)
+		},
+	}
+
+	addRunFlags(cmd, &opts)
+	return cmd
+}
+
+func create(ctx context.Context, uncli *cli.CLI, opts runOptions) error {
+	spec, err := opts.serviceSpec()
+	if err != nil {
+		return err
+	}
+
+	client, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	resp, err := client.CreateService(ctx, spec)
+	if err != nil {
+		return fmt.Errorf("create service: %w", err)
+	}
+	fmt.Printf("Service %q created. Start it with 'uc service start %s'.\n", resp.Name, resp.Name)
+
+	return nil
+}
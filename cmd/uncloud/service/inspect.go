@@ -1,21 +1,34 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/go-units"
+	"google.golang.org/grpc/metadata"
+	"io"
 	"os"
+	"slices"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
+	"uncloud/internal/api"
 	"uncloud/internal/cli"
 )
 
 type inspectOptions struct {
-	service string
-	cluster string
+	service          string
+	showDockerConfig bool
+	watch            bool
+	cluster          string
 }
 
 func NewInspectCommand() *cobra.Command {
@@ -30,6 +43,15 @@ func NewInspectCommand() *cobra.Command {
 			return inspect(cmd.Context(), uncli, opts)
 		},
 	}
+	cmd.Flags().BoolVar(
+		&opts.showDockerConfig, "show-docker-config", false,
+		"Show the Docker container, host, and networking configs each service container was created with. "+
+			"Values of environment variables are redacted.",
+	)
+	cmd.Flags().BoolVarP(
+		&opts.watch, "watch", "w", false,
+		"Watch the service and redraw its information every time its containers change until interrupted.",
+	)
 	cmd.Flags().StringVarP(
 		&opts.cluster, "cluster", "c", "",
 		"Name of the cluster. (default is the current cluster)",
@@ -44,9 +66,8 @@ func inspect(ctx context.Context, uncli *cli.CLI, opts inspectOptions) error {
 	}
 	defer client.Close()
 
-	svc, err := client.InspectService(ctx, opts.service)
-	if err != nil {
-		return fmt.Errorf("inspect service: %w", err)
+	if opts.watch && opts.showDockerConfig {
+		return errors.New("--show-docker-config can't be used with --watch")
 	}
 
 	machines, err := client.ListMachines(ctx)
@@ -54,18 +75,86 @@ func inspect(ctx context.Context, uncli *cli.CLI, opts inspectOptions) error {
 		return fmt.Errorf("list machines: %w", err)
 	}
 	machinesNamesByID := make(map[string]string)
+	machineManagementIPByID := make(map[string]string)
 	for _, m := range machines {
 		machinesNamesByID[m.Machine.Id] = m.Machine.Name
+		machineIP, _ := m.Machine.Network.ManagementIp.ToAddr()
+		machineManagementIPByID[m.Machine.Id] = machineIP.String()
+	}
+
+	if opts.watch {
+		updates, err := client.WatchService(ctx, opts.service)
+		if err != nil {
+			return fmt.Errorf("watch service: %w", err)
+		}
+		for msg := range updates {
+			if msg.Err != nil {
+				return fmt.Errorf("watch service: %w", msg.Err)
+			}
+			if msg.Service.ID == "" {
+				fmt.Println("Service has been removed.")
+				return nil
+			}
+
+			var buf bytes.Buffer
+			// Clear the screen and move the cursor to the top left corner before redrawing the service.
+			buf.WriteString("\033[2J\033[H")
+			if err = writeService(&buf, msg.Service, machinesNamesByID); err != nil {
+				return err
+			}
+			if _, err = io.Copy(os.Stdout, &buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	svc, err := client.InspectService(ctx, opts.service)
+	if err != nil {
+		return fmt.Errorf("inspect service: %w", err)
+	}
+	if err = writeService(os.Stdout, svc, machinesNamesByID); err != nil {
+		return err
+	}
+
+	if !opts.showDockerConfig {
+		return nil
+	}
+	for _, ctr := range svc.Containers {
+		machine := machinesNamesByID[ctr.MachineID]
+		if machine == "" {
+			machine = ctr.MachineID
+		}
+		machineIP, ok := machineManagementIPByID[ctr.MachineID]
+		if !ok {
+			return fmt.Errorf("machine not found by ID: %s", ctr.MachineID)
+		}
+
+		inspectCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("machines", machineIP))
+		ctrJSON, err := client.InspectContainer(inspectCtx, ctr.Container.ID)
+		if err != nil {
+			return fmt.Errorf("inspect container '%s' on machine '%s': %w", ctr.Container.ID, machine, err)
+		}
+
+		dockerConfig, err := json.MarshalIndent(newDockerConfig(ctrJSON), "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal Docker config: %w", err)
+		}
+		fmt.Printf("\nContainer %s on %s:\n%s\n", stringid.TruncateID(ctr.Container.ID), machine, dockerConfig)
 	}
 
-	fmt.Printf("ID:    %s\n", svc.ID)
-	fmt.Printf("Name:  %s\n", svc.Name)
-	fmt.Printf("Mode:  %s\n", svc.Mode)
-	fmt.Println()
+	return nil
+}
+
+// writeService writes the service information followed by its containers in a table format.
+func writeService(w io.Writer, svc api.Service, machinesNamesByID map[string]string) error {
+	if _, err := fmt.Fprintf(w, "ID:    %s\nName:  %s\nMode:  %s\n%s\n\n",
+		svc.ID, svc.Name, svc.Mode, replicasSummary(svc, machinesNamesByID)); err != nil {
+		return fmt.Errorf("write service: %w", err)
+	}
 
-	// Print the list of containers in a table format.
-	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	if _, err = fmt.Fprintln(tw, "CONTAINER ID\tIMAGE\tCREATED\tSTATUS\tMACHINE"); err != nil {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "CONTAINER ID\tIMAGE\tCREATED\tSTATUS\tMACHINE"); err != nil {
 		return fmt.Errorf("write header: %w", err)
 	}
 
@@ -78,7 +167,7 @@ func inspect(ctx context.Context, uncli *cli.CLI, opts inspectOptions) error {
 			machine = ctr.MachineID
 		}
 
-		_, err = fmt.Fprintf(
+		_, err := fmt.Fprintf(
 			tw,
 			"%s\t%s\t%s\t%s\t%s\n",
 			stringid.TruncateID(ctr.Container.ID),
@@ -93,3 +182,81 @@ func inspect(ctx context.Context, uncli *cli.CLI, opts inspectOptions) error {
 	}
 	return tw.Flush()
 }
+
+// dockerConfig is the Docker configuration a container was created with.
+type dockerConfig struct {
+	Config           *container.Config
+	HostConfig       *container.HostConfig
+	NetworkingConfig *network.NetworkingConfig
+}
+
+// newDockerConfig reconstructs the Docker configuration a container was created with from the inspect response.
+// Values of environment variables are redacted as they may contain secrets.
+func newDockerConfig(ctr types.ContainerJSON) dockerConfig {
+	cfg := dockerConfig{
+		Config:     ctr.Config,
+		HostConfig: ctr.HostConfig,
+	}
+	if cfg.Config != nil {
+		cfg.Config.Env = redactEnv(cfg.Config.Env)
+	}
+	if ctr.NetworkSettings != nil {
+		cfg.NetworkingConfig = &network.NetworkingConfig{EndpointsConfig: ctr.NetworkSettings.Networks}
+	}
+	return cfg
+}
+
+// redactEnv replaces the values of environment variables in the KEY=VALUE format with a placeholder.
+func redactEnv(env []string) []string {
+	redacted := make([]string, len(env))
+	for i, e := range env {
+		key, _, ok := strings.Cut(e, "=")
+		if ok {
+			e = key + "=<redacted>"
+		}
+		redacted[i] = e
+	}
+	return redacted
+}
+
+// replicasSummary returns a one-line summary of how the service containers are distributed across machines and
+// how many of them are running and healthy, e.g. "5 replicas: node1=2, node2=2, node3=1 (running=5, healthy=5)".
+func replicasSummary(svc api.Service, machinesNamesByID map[string]string) string {
+	if len(svc.Containers) == 0 {
+		return "0 replicas"
+	}
+
+	perMachine := make(map[string]int)
+	running, healthy := 0, 0
+	for _, ctr := range svc.Containers {
+		machine := machinesNamesByID[ctr.MachineID]
+		if machine == "" {
+			machine = ctr.MachineID
+		}
+		perMachine[machine]++
+
+		if ctr.Container.State == "running" {
+			running++
+		}
+		if ctr.Container.Healthy() {
+			healthy++
+		}
+	}
+
+	machines := make([]string, 0, len(perMachine))
+	for m := range perMachine {
+		machines = append(machines, m)
+	}
+	slices.Sort(machines)
+	distribution := make([]string, len(machines))
+	for i, m := range machines {
+		distribution[i] = fmt.Sprintf("%s=%d", m, perMachine[m])
+	}
+
+	replicas := "replicas"
+	if len(svc.Containers) == 1 {
+		replicas = "replica"
+	}
+	return fmt.Sprintf("%d %s: %s (running=%d, healthy=%d)",
+		len(svc.Containers), replicas, strings.Join(distribution, ", "), running, healthy)
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
+	"slices"
 	"text/tabwriter"
+	"uncloud/internal/api"
 	"uncloud/internal/cli"
 )
 
@@ -38,6 +40,10 @@ func list(ctx context.Context, uncli *cli.CLI, clusterName string) error {
 	if err != nil {
 		return fmt.Errorf("list services: %w", err)
 	}
+	specs, err := client.ListServiceSpecs(ctx)
+	if err != nil {
+		return fmt.Errorf("list service specs: %w", err)
+	}
 
 	// Print the list of services in a table format.
 	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
@@ -49,5 +55,18 @@ func list(ctx context.Context, uncli *cli.CLI, clusterName string) error {
 			return fmt.Errorf("write row: %w", err)
 		}
 	}
+	// Services that have been created but not started yet only have a spec stored in the cluster.
+	for _, r := range specs {
+		if slices.ContainsFunc(services, func(s api.Service) bool { return s.ID == r.ID }) {
+			continue
+		}
+		mode := r.Spec.Mode
+		if mode == "" {
+			mode = api.ServiceModeReplicated
+		}
+		if _, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.ID, r.Spec.Name, mode, "0 (created)"); err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+	}
 	return tw.Flush()
 }
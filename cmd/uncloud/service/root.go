@@ -10,9 +10,13 @@ func NewRootCommand() *cobra.Command {
 		Short: "Manage services in an Uncloud cluster.",
 	}
 	cmd.AddCommand(
+		NewCpCommand(),
+		NewCreateCommand(),
 		NewListCommand(),
 		NewRmCommand(),
 		NewRunCommand(),
+		NewStartCommand(),
+		NewStatsCommand(),
 	)
 	return cmd
 }
@@ -2,20 +2,54 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/compose-spec/compose-go/v2/dotenv"
+	"github.com/docker/go-units"
 	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"time"
 	"uncloud/internal/api"
 	"uncloud/internal/cli"
 )
 
 type runOptions struct {
-	command []string
-	image   string
-	machine string
-	mode    string
-	name    string
-	publish []string
-	volumes []string
+	addHosts              []string
+	basicAuth             []string
+	capAdd                []string
+	capDrop               []string
+	command               []string
+	controlPlane          bool
+	env                   []string
+	envFiles              []string
+	healthCmd             string
+	healthInterval        time.Duration
+	healthRetries         int
+	healthStartPeriod     time.Duration
+	healthTimeout         time.Duration
+	httpsRedirect         bool
+	image                 string
+	ingressHealthInterval time.Duration
+	ingressHealthPath     string
+	labels                []string
+	machine               string
+	mode                  string
+	name                  string
+	noHealthcheck         bool
+	pidsLimit             int64
+	publish               []string
+	pull                  string
+	readOnly              bool
+	restart               string
+	runtime               string
+	stickySessions        bool
+	stopGracePeriod       time.Duration
+	stopGracePeriodSet    bool
+	stopSignal            string
+	sysctls               []string
+	ulimits               []string
+	volumes               []string
 
 	cluster string
 }
@@ -34,30 +68,122 @@ func NewRunCommand() *cobra.Command {
 			if len(args) > 1 {
 				opts.command = args[1:]
 			}
+			// Zero is a valid stop grace period that kills containers immediately, so it must be distinguished
+			// from the unset flag.
+			opts.stopGracePeriodSet = cmd.Flags().Changed("stop-grace-period")
 
 			return run(cmd.Context(), uncli, opts)
 		},
 	}
 
+	addRunFlags(cmd, &opts)
+	return cmd
+}
+
+// addRunFlags adds flags for configuring a service spec to the command that runs or creates a service.
+func addRunFlags(cmd *cobra.Command, opts *runOptions) {
 	// TODO: implement placement constraints and translate --machine to a constraint.
 	//cmd.Flags().StringVarP(
 	//	&opts.machine, "machine", "m", "",
 	//	"Name or ID of the machine to run the service on. (default is first available)",
 	//)
+	cmd.Flags().StringSliceVar(&opts.addHosts, "add-host", nil,
+		"Add a custom host-to-IP mapping to /etc/hosts in service containers using the format host:ip. "+
+			"Use the special host-gateway IP to map the host to the IP of the machine, e.g. "+
+			"host.docker.internal:host-gateway. Can be specified multiple times.")
+	cmd.Flags().StringSliceVar(&opts.basicAuth, "basic-auth", nil,
+		"Require HTTP basic authentication to access the HTTP(S) ports of the service through the ingress "+
+			"using the format username:password. The password is hashed with bcrypt before it's sent "+
+			"to the cluster. Can be specified multiple times.")
+	cmd.Flags().StringSliceVar(&opts.capAdd, "cap-add", nil,
+		"Add a Linux capability to service containers, e.g. NET_ADMIN. Can be specified multiple times.")
+	cmd.Flags().StringSliceVar(&opts.capDrop, "cap-drop", nil,
+		"Drop a Linux capability from service containers, e.g. ALL. Can be specified multiple times.")
+	cmd.Flags().BoolVar(&opts.controlPlane, "control-plane", false,
+		fmt.Sprintf("Also run a container of a %q service on control-plane machines, e.g. for monitoring agents. "+
+			"Control-plane machines don't run service containers by default.", api.ServiceModeGlobal))
+	cmd.Flags().StringSliceVarP(&opts.env, "env", "e", nil,
+		"Set an environment variable in service containers using the format KEY=VALUE. If only KEY is "+
+			"specified, the value is taken from the local environment. Can be specified multiple times.")
+	cmd.Flags().StringSliceVar(&opts.envFiles, "env-file", nil,
+		"Read environment variables for service containers from a file in the .env format. Values set with "+
+			"--env take precedence over the files. Can be specified multiple times.")
+	cmd.Flags().StringVar(&opts.healthCmd, "health-cmd", "",
+		"Shell command to run in a service container to check its health. Overrides the image's healthcheck.")
+	cmd.Flags().BoolVar(&opts.noHealthcheck, "no-healthcheck", false,
+		"Disable any container healthcheck including the one defined in the image.")
+	cmd.Flags().DurationVar(&opts.healthInterval, "health-interval", 0,
+		"Time between running the healthcheck, e.g. 10s. (default is 30s)")
+	cmd.Flags().IntVar(&opts.healthRetries, "health-retries", 0,
+		"Number of consecutive healthcheck failures needed to report a container as unhealthy. (default is 3)")
+	cmd.Flags().DurationVar(&opts.healthStartPeriod, "health-start-period", 0,
+		"Time for a container to initialise before healthcheck failures count towards the retries. "+
+			"(default is 0s)")
+	cmd.Flags().DurationVar(&opts.healthTimeout, "health-timeout", 0,
+		"Maximum time to allow one healthcheck to run, e.g. 5s. (default is 30s)")
+	cmd.MarkFlagsMutuallyExclusive("health-cmd", "no-healthcheck")
+	for _, f := range []string{"health-interval", "health-retries", "health-start-period", "health-timeout"} {
+		cmd.MarkFlagsMutuallyExclusive(f, "no-healthcheck")
+	}
+	cmd.Flags().BoolVar(&opts.httpsRedirect, "https-redirect", false,
+		"Permanently redirect plain HTTP requests to the HTTPS hostnames of the service to HTTPS instead of "+
+			"serving them over HTTP. Requires a published HTTPS port.")
+	cmd.Flags().DurationVar(&opts.ingressHealthInterval, "ingress-health-interval", 0,
+		"Time between the ingress health probes of service containers, e.g. 10s. Requires --ingress-health-path. "+
+			"(default is 30s)")
+	cmd.Flags().StringVar(&opts.ingressHealthPath, "ingress-health-path", "",
+		"HTTP path the ingress periodically requests on service containers, e.g. /healthz, to stop routing "+
+			"requests to containers that don't respond with a 2xx or 3xx status code. "+
+			"(default is to only stop routing to containers after failed requests)")
+	cmd.Flags().StringSliceVarP(&opts.labels, "label", "l", nil,
+		"Add a custom label to service containers using the format key=value. Labels with the 'uncloud.' "+
+			"prefix are reserved. Can be specified multiple times.")
 	cmd.Flags().StringVar(&opts.mode, "mode", api.ServiceModeReplicated,
 		fmt.Sprintf("Replication mode of the service: either %q (a specified number of containers across "+
 			"the machines) or %q (one container on every machine).",
 			api.ServiceModeReplicated, api.ServiceModeGlobal))
 	cmd.Flags().StringVarP(&opts.name, "name", "n", "",
 		"Assign a name to the service. A random name is generated if not specified.")
+	cmd.Flags().Int64Var(&opts.pidsLimit, "pids-limit", 0,
+		"Maximum number of processes a service container can run. (default is unlimited)")
+	cmd.Flags().StringVar(&opts.pull, "pull", api.PullPolicyMissing,
+		fmt.Sprintf("Pull the image on the target machines: %q (only if it's missing on a machine), %q (on all "+
+			"machines concurrently before creating any containers), or %q (the image must be present).",
+			api.PullPolicyMissing, api.PullPolicyAlways, api.PullPolicyNever))
 	cmd.Flags().StringSliceVarP(&opts.publish, "publish", "p", nil,
 		"Publish a service port to make it accessible outside the cluster. Can be specified multiple times.\n"+
-			"Format: [hostname:][load_balancer_port:]container_port[/protocol] or [host_ip:]:host_port:container_port[/protocol]@host\n"+
+			"Format: [hostname:][load_balancer_port:]container_port[/protocol] or [host_ip:]:host_port[-end]:container_port[-end][/protocol]@host\n"+
 			"Supported protocols: tcp, udp, http, https (default is tcp). If a hostname for http(s) port is not specified, a random hostname is generated.\n"+
+			"TCP and UDP ports via load balancer require the traefik cluster ingress with a tcp-<load_balancer_port> or udp-<load_balancer_port> entry point.\n"+
 			"Examples:\n"+
 			"  -p app.example.com:8080/https  Publish port 8080 as HTTPS via load balancer with custom hostname\n"+
 			"  -p 9000:8080                   Publish port 8080 as TCP port 9000 via load balancer\n"+
-			"  -p 53:5353/udp@host            Bind UDP port 5353 to host port 53")
+			"  -p 53:5353/udp@host            Bind UDP port 5353 to host port 53\n"+
+			"  -p 8000-8010:8000-8010@host    Bind TCP ports 8000-8010 to the same host ports")
+	cmd.Flags().BoolVar(&opts.readOnly, "read-only", false,
+		"Mount the root filesystem of service containers as read only. Use volumes for writable directories.")
+	cmd.Flags().StringVar(&opts.restart, "restart", "",
+		"Restart policy to apply when a service container exits: 'no', 'always', 'unless-stopped', or "+
+			"'on-failure[:max-retries]'. (default is 'no')")
+	cmd.Flags().StringVar(&opts.runtime, "runtime", "",
+		"OCI runtime to run service containers with, e.g. runsc for gVisor. The runtime must be configured "+
+			"in the Docker daemon on the machines. (default is the Docker default runtime)")
+	cmd.Flags().DurationVar(&opts.stopGracePeriod, "stop-grace-period", 0,
+		"Time to wait for a container to stop gracefully after sending the stop signal before killing it, "+
+			"e.g. 30s or 1m. Must be 0s to kill immediately or at least 1s, fractions of a second are rounded up. "+
+			"(default is 10s)")
+	cmd.Flags().BoolVar(&opts.stickySessions, "sticky-sessions", false,
+		"Route HTTP(S) requests from the same client to the same service container using a cookie. "+
+			"(default is to load balance requests across all containers)")
+	cmd.Flags().StringVar(&opts.stopSignal, "stop-signal", "",
+		"Signal to send to a container to stop it, e.g. SIGINT or SIGQUIT. (default is the image's stop "+
+			"signal or SIGTERM)")
+	cmd.Flags().StringSliceVar(&opts.sysctls, "sysctl", nil,
+		"Set a namespaced kernel parameter in service containers using the format name=value, "+
+			"e.g. net.core.somaxconn=1024. Can be specified multiple times.")
+	cmd.Flags().StringSliceVar(&opts.ulimits, "ulimit", nil,
+		"Resource limit for the processes in a service container using the format name=soft[:hard], "+
+			"e.g. nofile=65536:65536. Can be specified multiple times.")
 	cmd.Flags().StringSliceVarP(&opts.volumes, "volume", "v", nil,
 		"Bind mount a host file or directory into a service container using the format "+
 			"/host/path:/container/path[:ro]. Can be specified multiple times.")
@@ -66,22 +192,40 @@ func NewRunCommand() *cobra.Command {
 		&opts.cluster, "cluster", "c", "",
 		"Name of the cluster to run the service in. (default is the current cluster)",
 	)
-
-	return cmd
 }
 
 func run(ctx context.Context, uncli *cli.CLI, opts runOptions) error {
+	spec, err := opts.serviceSpec()
+	if err != nil {
+		return err
+	}
+
+	client, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	if _, err = client.RunService(ctx, spec); err != nil {
+		return fmt.Errorf("run service: %w", err)
+	}
+
+	return nil
+}
+
+// serviceSpec builds and validates a service spec from the options.
+func (opts *runOptions) serviceSpec() (api.ServiceSpec, error) {
 	switch opts.mode {
 	case "", api.ServiceModeReplicated, api.ServiceModeGlobal:
 	default:
-		return fmt.Errorf("invalid replication mode: %q", opts.mode)
+		return api.ServiceSpec{}, fmt.Errorf("invalid replication mode: %q", opts.mode)
 	}
 
 	ports := make([]api.PortSpec, len(opts.publish))
 	for i, publishPort := range opts.publish {
 		port, err := api.ParsePortSpec(publishPort)
 		if err != nil {
-			return fmt.Errorf("invalid service port '%s': %w", publishPort, err)
+			return api.ServiceSpec{}, fmt.Errorf("invalid service port '%s': %w", publishPort, err)
 		}
 		ports[i] = port
 	}
@@ -89,27 +233,131 @@ func run(ctx context.Context, uncli *cli.CLI, opts runOptions) error {
 
 	spec := api.ServiceSpec{
 		Container: api.ContainerSpec{
-			Command: opts.command,
-			Image:   opts.image,
-			Volumes: opts.volumes,
+			CapAdd:         opts.capAdd,
+			CapDrop:        opts.capDrop,
+			Command:        opts.command,
+			ExtraHosts:     opts.addHosts,
+			Image:          opts.image,
+			PullPolicy:     opts.pull,
+			ReadOnlyRootfs: opts.readOnly,
+			Runtime:        opts.runtime,
+			StopSignal:     opts.stopSignal,
+			Volumes:        opts.volumes,
 		},
-		Mode:  opts.mode,
-		Name:  opts.name,
-		Ports: ports,
+		ControlPlane:   opts.controlPlane,
+		Mode:           opts.mode,
+		Name:           opts.name,
+		Ports:          ports,
+		HTTPSRedirect:  opts.httpsRedirect,
+		StickySessions: opts.stickySessions,
 	}
-	if err := spec.Validate(); err != nil {
-		return fmt.Errorf("invalid service configuration: %w", err)
+	if opts.healthCmd != "" || opts.noHealthcheck || opts.healthInterval != 0 || opts.healthRetries != 0 ||
+		opts.healthStartPeriod != 0 || opts.healthTimeout != 0 {
+		spec.Container.Healthcheck = &api.HealthcheckSpec{
+			Disable:     opts.noHealthcheck,
+			Test:        opts.healthCmd,
+			Interval:    opts.healthInterval,
+			Timeout:     opts.healthTimeout,
+			Retries:     opts.healthRetries,
+			StartPeriod: opts.healthStartPeriod,
+		}
 	}
-
-	client, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if opts.ingressHealthPath != "" {
+		spec.IngressHealthCheck = &api.IngressHealthCheckSpec{
+			Path:     opts.ingressHealthPath,
+			Interval: opts.ingressHealthInterval,
+		}
+	} else if opts.ingressHealthInterval != 0 {
+		return spec, errors.New("--ingress-health-interval requires --ingress-health-path")
+	}
+	for _, u := range opts.basicAuth {
+		username, password, ok := strings.Cut(u, ":")
+		if !ok {
+			return spec, errors.New("invalid basic auth user: must be in the format username:password")
+		}
+		spec.BasicAuth = append(spec.BasicAuth, api.BasicAuthUser{Username: username, Password: password})
+	}
+	if opts.restart != "" {
+		policy, err := api.ParseRestartPolicy(opts.restart)
+		if err != nil {
+			return spec, fmt.Errorf("parse restart policy '%s': %w", opts.restart, err)
+		}
+		spec.Container.RestartPolicy = &policy
+	}
+	env, err := opts.environment()
 	if err != nil {
-		return fmt.Errorf("connect to cluster: %w", err)
+		return spec, err
+	}
+	spec.Container.Env = env
+	for _, l := range opts.labels {
+		key, value, _ := strings.Cut(l, "=")
+		if spec.Container.Labels == nil {
+			spec.Container.Labels = make(map[string]string)
+		}
+		spec.Container.Labels[key] = value
+	}
+	for _, s := range opts.sysctls {
+		name, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return spec, fmt.Errorf("invalid sysctl '%s': must be in the format name=value", s)
+		}
+		if spec.Container.Sysctls == nil {
+			spec.Container.Sysctls = make(map[string]string)
+		}
+		spec.Container.Sysctls[name] = value
+	}
+	for _, u := range opts.ulimits {
+		ulimit, err := units.ParseUlimit(u)
+		if err != nil {
+			return spec, fmt.Errorf("invalid ulimit '%s': %w", u, err)
+		}
+		spec.Container.Ulimits = append(spec.Container.Ulimits, api.Ulimit{
+			Name: ulimit.Name,
+			Soft: ulimit.Soft,
+			Hard: ulimit.Hard,
+		})
+	}
+	if opts.pidsLimit != 0 {
+		spec.Container.PidsLimit = &opts.pidsLimit
+	}
+	if opts.stopGracePeriodSet {
+		spec.Container.StopGracePeriod = &opts.stopGracePeriod
+	}
+	if err = spec.Validate(); err != nil {
+		return spec, fmt.Errorf("invalid service configuration: %w", err)
 	}
-	defer client.Close()
 
-	if _, err = client.RunService(ctx, spec); err != nil {
-		return fmt.Errorf("run service: %w", err)
+	return spec, nil
+}
+
+// environment merges the environment variables from the env files and the env options. Later files override
+// earlier ones and the env options override the files. Variables in the files can reference the local environment
+// and the variables defined earlier using the same semantics as docker-compose.
+func (opts *runOptions) environment() (api.EnvVars, error) {
+	localEnv := make(map[string]string)
+	for _, e := range os.Environ() {
+		if k, v, ok := strings.Cut(e, "="); ok {
+			localEnv[k] = v
+		}
 	}
 
-	return nil
+	env, err := dotenv.GetEnvFromFile(localEnv, opts.envFiles)
+	if err != nil {
+		return nil, fmt.Errorf("read env file: %w", err)
+	}
+	for _, e := range opts.env {
+		k, v, ok := strings.Cut(e, "=")
+		if !ok {
+			// Take the value from the local environment and skip the variable if it's not set like docker run.
+			if v, ok = localEnv[k]; !ok {
+				continue
+			}
+		}
+		env[k] = v
+	}
+
+	if len(env) == 0 {
+		return nil, nil
+	}
+	return env, nil
 }
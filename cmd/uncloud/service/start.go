@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"uncloud/internal/cli"
+)
+
+type startOptions struct {
+	services []string
+	cluster  string
+}
+
+func NewStartCommand() *cobra.Command {
+	opts := startOptions{}
+	cmd := &cobra.Command{
+		Use:   "start SERVICE [SERVICE...]",
+		Short: "Start one or more services that have been created but not started yet.",
+		Long: "Start one or more services created with 'service create'. The service containers are created and " +
+			"started on the cluster machines according to the service spec stored in the cluster.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.services = args
+			return start(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func start(ctx context.Context, uncli *cli.CLI, opts startOptions) error {
+	client, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	for _, s := range opts.services {
+		if _, err = client.StartService(ctx, s); err != nil {
+			return fmt.Errorf("start service %q: %w", s, err)
+		}
+		fmt.Printf("Service %q started.\n", s)
+	}
+
+	return nil
+}
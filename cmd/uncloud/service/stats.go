@@ -0,0 +1,226 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+	"uncloud/internal/api"
+	"uncloud/internal/cli"
+	"uncloud/internal/machine/api/pb"
+)
+
+// statsRefreshInterval is how often the live stats table is redrawn.
+const statsRefreshInterval = time.Second
+
+type statsOptions struct {
+	services []string
+	noStream bool
+	cluster  string
+}
+
+func NewStatsCommand() *cobra.Command {
+	opts := statsOptions{}
+	cmd := &cobra.Command{
+		Use:   "stats [SERVICE...]",
+		Short: "Display a live stream of resource usage statistics of service containers.",
+		Long: "Display a live stream of CPU, memory, network, and block I/O usage of service containers across " +
+			"all machines. If no services are specified, the statistics of all services are displayed.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.services = args
+			return stats(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.noStream, "no-stream", false,
+		"Display the first statistics result only instead of streaming the live statistics.")
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+// containerStats is the latest resource usage statistics of a service container.
+type containerStats struct {
+	service   string
+	container string
+	machine   string
+	stats     *pb.ContainerStatsResponse
+	err       error
+}
+
+func stats(ctx context.Context, uncli *cli.CLI, opts statsOptions) error {
+	c, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer c.Close()
+
+	var services []api.Service
+	if len(opts.services) == 0 {
+		if services, err = c.ListServices(ctx); err != nil {
+			return fmt.Errorf("list services: %w", err)
+		}
+	} else {
+		for _, name := range opts.services {
+			svc, err := c.InspectService(ctx, name)
+			if err != nil {
+				return fmt.Errorf("inspect service %q: %w", name, err)
+			}
+			services = append(services, svc)
+		}
+	}
+
+	machines, err := c.ListMachines(ctx)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+	machinesByID := make(map[string]*pb.MachineInfo, len(machines))
+	for _, m := range machines {
+		machinesByID[m.Machine.Id] = m.Machine
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		rows []*containerStats
+		wg   sync.WaitGroup
+	)
+	for _, svc := range services {
+		for _, mc := range svc.Containers {
+			row := &containerStats{
+				service:   svc.Name,
+				container: stringid.TruncateID(mc.Container.ID),
+				machine:   mc.MachineID,
+			}
+			if len(mc.Container.Names) > 0 {
+				row.container = strings.TrimPrefix(mc.Container.Names[0], "/")
+			}
+			rows = append(rows, row)
+
+			m, ok := machinesByID[mc.MachineID]
+			if !ok {
+				row.err = fmt.Errorf("machine not found by ID: %s", mc.MachineID)
+				continue
+			}
+			row.machine = m.Name
+			machineIP, _ := m.Network.ManagementIp.ToAddr()
+			machineCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("machines", machineIP.String()))
+
+			statsCh, err := c.ContainerStats(machineCtx, mc.Container.ID, !opts.noStream)
+			if err != nil {
+				row.err = err
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range statsCh {
+					mu.Lock()
+					row.stats, row.err = msg.Stats, msg.Err
+					mu.Unlock()
+				}
+			}()
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].service != rows[j].service {
+			return rows[i].service < rows[j].service
+		}
+		return rows[i].container < rows[j].container
+	})
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	if opts.noStream {
+		<-done
+		return writeStats(os.Stdout, rows)
+	}
+
+	ticker := time.NewTicker(statsRefreshInterval)
+	defer ticker.Stop()
+	for {
+		finished := false
+		select {
+		case <-ticker.C:
+		case <-done:
+			// All stats streams ended, e.g. the containers were removed. Draw the final stats and exit.
+			finished = true
+		case <-ctx.Done():
+			return nil
+		}
+
+		var buf bytes.Buffer
+		// Clear the screen and move the cursor to the top left corner before redrawing the table.
+		buf.WriteString("\033[2J\033[H")
+		mu.Lock()
+		err = writeStats(&buf, rows)
+		mu.Unlock()
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(os.Stdout, &buf); err != nil {
+			return err
+		}
+		if finished {
+			return nil
+		}
+	}
+}
+
+// writeStats writes the container stats in a table format.
+func writeStats(w io.Writer, rows []*containerStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "SERVICE\tCONTAINER\tMACHINE\tCPU %\tMEM USAGE / LIMIT\tMEM %\tNET I/O\t"+
+		"BLOCK I/O\tPIDS"); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	for _, r := range rows {
+		var err error
+		switch {
+		case r.stats != nil:
+			s := r.stats
+			memPercent := 0.0
+			if s.MemoryLimit > 0 {
+				memPercent = float64(s.MemoryUsage) / float64(s.MemoryLimit) * 100
+			}
+			_, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%.2f%%\t%s / %s\t%.2f%%\t%s / %s\t%s / %s\t%d\n",
+				r.service, r.container, r.machine,
+				s.CpuPercent,
+				units.BytesSize(float64(s.MemoryUsage)), units.BytesSize(float64(s.MemoryLimit)),
+				memPercent,
+				units.HumanSizeWithPrecision(float64(s.NetworkRx), 3),
+				units.HumanSizeWithPrecision(float64(s.NetworkTx), 3),
+				units.HumanSizeWithPrecision(float64(s.BlockRead), 3),
+				units.HumanSizeWithPrecision(float64(s.BlockWrite), 3),
+				s.Pids)
+		case r.err != nil:
+			_, err = fmt.Fprintf(tw, "%s\t%s\t%s\terror: %v\n", r.service, r.container, r.machine, r.err)
+		default:
+			_, err = fmt.Fprintf(tw, "%s\t%s\t%s\t--\t-- / --\t--\t-- / --\t-- / --\t--\n",
+				r.service, r.container, r.machine)
+		}
+		if err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+	}
+	return tw.Flush()
+}
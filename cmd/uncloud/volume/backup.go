@@ -0,0 +1,97 @@
+package volume
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/errdefs"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"uncloud/internal/cli"
+)
+
+type backupOptions struct {
+	name    string
+	machine string
+	output  string
+	cluster string
+}
+
+func NewBackupCommand() *cobra.Command {
+	opts := backupOptions{}
+	cmd := &cobra.Command{
+		Use:   "backup VOLUME",
+		Short: "Back up the contents of a volume on a machine to a tar archive.",
+		Long: "Back up the contents of a Docker volume on a machine to a tar archive written to a local file or " +
+			"stdout. The archive contains a single 'volume' directory with the volume contents and can be restored " +
+			"with 'uc volume restore'. The volume is mounted read-only, stop the containers writing to the volume " +
+			"to get a consistent backup.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.name = args[0]
+			return backup(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.machine, "machine", "m", "",
+		"Name or ID of the machine the volume is on.")
+	_ = cmd.MarkFlagRequired("machine")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "",
+		"Write the archive to a file instead of stdout.")
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func backup(ctx context.Context, uncli *cli.CLI, opts backupOptions) error {
+	out := os.Stdout
+	if opts.output == "" {
+		if stat, err := os.Stdout.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 {
+			return errors.New("refusing to write the archive to a terminal, redirect stdout or use --output")
+		}
+	}
+
+	c, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer c.Close()
+
+	ctx, m, err := machineContext(ctx, c, opts.machine)
+	if err != nil {
+		return err
+	}
+	content, err := c.BackupVolume(ctx, opts.name)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return fmt.Errorf("volume %q not found on machine %q", opts.name, m.Name)
+		}
+		return fmt.Errorf("back up volume: %w", err)
+	}
+	defer content.Close()
+
+	if opts.output != "" {
+		if out, err = os.Create(opts.output); err != nil {
+			return fmt.Errorf("create output file: %w", err)
+		}
+		defer out.Close()
+	}
+	if _, err = io.Copy(out, content); err != nil {
+		if opts.output != "" {
+			// Don't leave a truncated archive that looks like a valid backup.
+			out.Close()
+			os.Remove(opts.output)
+		}
+		return fmt.Errorf("write archive: %w", err)
+	}
+	if opts.output != "" {
+		if err = out.Close(); err != nil {
+			return fmt.Errorf("close output file: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Volume %q on machine %q backed up to '%s'.\n", opts.name, m.Name, opts.output)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package volume
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+	"os"
+	"slices"
+	"sort"
+	"text/tabwriter"
+	"time"
+	"uncloud/internal/cli"
+	"uncloud/internal/machine/api/pb"
+)
+
+type listOptions struct {
+	size        bool
+	sizeTimeout time.Duration
+	machines    []string
+	cluster     string
+}
+
+func NewListCommand() *cobra.Command {
+	opts := listOptions{}
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List volumes on machines in the cluster.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			return list(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().BoolVarP(&opts.size, "size", "s", false,
+		"Display the disk usage and the number of containers using each volume. Calculating the size can be slow "+
+			"for large volumes, so it's only done when requested and in parallel on all machines.")
+	cmd.Flags().BoolVar(&opts.size, "usage", false, "Alias for --size.")
+	cmd.Flags().DurationVar(&opts.sizeTimeout, "size-timeout", time.Minute,
+		"Maximum time each machine can spend calculating the disk usage with --size. Machines that exceed it are "+
+			"reported with a warning and their volumes are not listed.")
+	cmd.Flags().StringSliceVarP(&opts.machines, "machine", "m", nil,
+		"Name or ID of the machine to list volumes on. Can be specified multiple times. (default is all machines)")
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+// volumeRow is a volume on a machine to display in the list.
+type volumeRow struct {
+	machine  string
+	name     string
+	driver   string
+	size     int64
+	refCount int64
+}
+
+func list(ctx context.Context, uncli *cli.CLI, opts listOptions) error {
+	client, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer client.Close()
+
+	machines, err := client.ListMachines(ctx)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+
+	// Broadcast the list request to the selected available machines.
+	md := metadata.New(nil)
+	machineNames := make(map[string]string)
+	var selected []*pb.MachineInfo
+	for _, m := range machines {
+		if len(opts.machines) > 0 &&
+			!slices.Contains(opts.machines, m.Machine.Name) && !slices.Contains(opts.machines, m.Machine.Id) {
+			continue
+		}
+		if m.State != pb.MachineMember_UP && m.State != pb.MachineMember_SUSPECT {
+			fmt.Printf("WARNING: skipping machine '%s' which is down.\n", m.Machine.Name)
+			continue
+		}
+		machineIP, _ := m.Machine.Network.ManagementIp.ToAddr()
+		md.Append("machines", machineIP.String())
+		machineNames[machineIP.String()] = m.Machine.Name
+		selected = append(selected, m.Machine)
+	}
+	if len(selected) == 0 {
+		return errors.New("no available machines to list volumes on")
+	}
+	listCtx := metadata.NewOutgoingContext(ctx, md)
+
+	machineVolumes, err := client.ListVolumes(listCtx, opts.size, opts.sizeTimeout)
+	if err != nil {
+		return fmt.Errorf("list volumes: %w", err)
+	}
+
+	var rows []volumeRow
+	for _, mv := range machineVolumes {
+		// The response has no metadata if the request was proxied to a single machine.
+		machine := selected[0].Name
+		if mv.Metadata != nil {
+			machine = mv.Metadata.Machine
+			if name, ok := machineNames[mv.Metadata.Machine]; ok {
+				machine = name
+			}
+			if mv.Metadata.Error != "" {
+				fmt.Printf("WARNING: failed to list volumes on machine '%s': %s\n", machine, mv.Metadata.Error)
+				continue
+			}
+		}
+
+		for _, v := range mv.Volumes {
+			row := volumeRow{machine: machine, name: v.Name, driver: v.Driver, size: -1, refCount: -1}
+			if v.UsageData != nil {
+				row.size, row.refCount = v.UsageData.Size, v.UsageData.RefCount
+			}
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].machine != rows[j].machine {
+			return rows[i].machine < rows[j].machine
+		}
+		return rows[i].name < rows[j].name
+	})
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	header := "MACHINE\tNAME\tDRIVER"
+	if opts.size {
+		header += "\tSIZE\tLINKS"
+	}
+	if _, err = fmt.Fprintln(tw, header); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	for _, r := range rows {
+		line := fmt.Sprintf("%s\t%s\t%s", r.machine, r.name, r.driver)
+		if opts.size {
+			// Docker reports -1 if the usage data is not available, e.g. for volumes of non-local drivers.
+			size, refCount := "N/A", "N/A"
+			if r.size >= 0 {
+				size = units.HumanSize(float64(r.size))
+			}
+			if r.refCount >= 0 {
+				refCount = fmt.Sprintf("%d", r.refCount)
+			}
+			line += fmt.Sprintf("\t%s\t%s", size, refCount)
+		}
+		if _, err = fmt.Fprintln(tw, line); err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+	}
+	return tw.Flush()
+}
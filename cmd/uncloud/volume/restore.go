@@ -0,0 +1,75 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"uncloud/internal/cli"
+)
+
+type restoreOptions struct {
+	name         string
+	machine      string
+	input        string
+	requireEmpty bool
+	cluster      string
+}
+
+func NewRestoreCommand() *cobra.Command {
+	opts := restoreOptions{}
+	cmd := &cobra.Command{
+		Use:   "restore VOLUME",
+		Short: "Restore the contents of a volume on a machine from a tar archive.",
+		Long: "Restore the contents of a Docker volume on a machine from a tar archive created by 'uc volume backup' " +
+			"read from a local file or stdin. The volume is created if it doesn't exist. Files from the archive " +
+			"overwrite existing files in the volume preserving their modes and ownership.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uncli := cmd.Context().Value("cli").(*cli.CLI)
+			opts.name = args[0]
+			return restore(cmd.Context(), uncli, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.machine, "machine", "m", "",
+		"Name or ID of the machine to restore the volume on.")
+	_ = cmd.MarkFlagRequired("machine")
+	cmd.Flags().StringVarP(&opts.input, "input", "i", "",
+		"Read the archive from a file instead of stdin.")
+	cmd.Flags().BoolVar(&opts.requireEmpty, "require-empty", false,
+		"Fail if the volume already has data.")
+	cmd.Flags().StringVarP(
+		&opts.cluster, "cluster", "c", "",
+		"Name of the cluster. (default is the current cluster)",
+	)
+	return cmd
+}
+
+func restore(ctx context.Context, uncli *cli.CLI, opts restoreOptions) error {
+	var in io.Reader = os.Stdin
+	if opts.input != "" {
+		f, err := os.Open(opts.input)
+		if err != nil {
+			return fmt.Errorf("open input file: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	c, err := uncli.ConnectCluster(ctx, opts.cluster)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer c.Close()
+
+	ctx, m, err := machineContext(ctx, c, opts.machine)
+	if err != nil {
+		return err
+	}
+	if err = c.RestoreVolume(ctx, opts.name, in, opts.requireEmpty); err != nil {
+		return fmt.Errorf("restore volume: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Volume %q on machine %q restored.\n", opts.name, m.Name)
+	return nil
+}
@@ -0,0 +1,44 @@
+package volume
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+	"uncloud/internal/cli/client"
+	"uncloud/internal/machine/api/pb"
+)
+
+func NewRootCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "volume",
+		Short: "Manage Docker volumes on machines in an Uncloud cluster.",
+	}
+	cmd.AddCommand(
+		NewBackupCommand(),
+		NewListCommand(),
+		NewRestoreCommand(),
+	)
+	return cmd
+}
+
+// machineContext returns a context that proxies the requests to the machine with the given name or ID.
+func machineContext(ctx context.Context, c *client.Client, nameOrID string) (context.Context, *pb.MachineInfo, error) {
+	machines, err := c.ListMachines(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list machines: %w", err)
+	}
+	var m *pb.MachineInfo
+	for _, mm := range machines {
+		if mm.Machine.Id == nameOrID || mm.Machine.Name == nameOrID {
+			m = mm.Machine
+			break
+		}
+	}
+	if m == nil {
+		return nil, nil, fmt.Errorf("machine %q not found", nameOrID)
+	}
+
+	machineIP, _ := m.Network.ManagementIp.ToAddr()
+	return metadata.NewOutgoingContext(ctx, metadata.Pairs("machines", machineIP.String())), m, nil
+}
@@ -10,6 +10,8 @@ import (
 	"uncloud/internal/daemon"
 	"uncloud/internal/log"
 	"uncloud/internal/machine"
+	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/network"
 )
 
 func main() {
@@ -18,14 +20,14 @@ func main() {
 	}))
 	slog.SetDefault(logger)
 
-	var dataDir string
+	var config machine.Config
 	cmd := &cobra.Command{
 		Use:           "uncloudd",
 		Short:         "Uncloud machine daemon.",
 		SilenceUsage:  true,
 		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			d, err := daemon.New(dataDir)
+			d, err := daemon.New(&config)
 			if err != nil {
 				return err
 			}
@@ -35,9 +37,45 @@ func main() {
 			return err
 		},
 	}
-	cmd.PersistentFlags().StringVarP(&dataDir, "data-dir", "d", machine.DefaultDataDir,
+	cmd.PersistentFlags().StringVarP(&config.DataDir, "data-dir", "d", machine.DefaultDataDir,
 		"Directory for storing persistent machine state")
 	_ = cmd.MarkFlagDirname("data-dir")
+	cmd.Flags().StringVar(&config.PublicIPSource, "public-ip", network.PublicIPSourceAuto,
+		"Source of the machine's public IP address to use as a WireGuard endpoint: 'auto' (query well-known "+
+			"API services), 'none', a fixed IP address, an HTTP(S) URL of a resolver returning the IP in "+
+			"plain text, or 'stun:HOST[:PORT]'. Can be overridden when initialising a cluster.")
+	cmd.Flags().BoolVar(&config.PreferIPv6Endpoints, "prefer-ipv6", false,
+		"Advertise the machine's IPv6 WireGuard endpoints before the IPv4 ones so that other machines connect "+
+			"to it over IPv6 first when it has both.")
+	cmd.Flags().IntVar(&config.WireGuardMTU, "wireguard-mtu", network.DefaultWireGuardMTU,
+		"MTU of the WireGuard interface. Lower it, e.g. to 1380, if services on other machines are reachable by "+
+			"ping but TLS handshakes or large transfers hang, which happens on PPPoE links and some cloud networks "+
+			"with a smaller path MTU. The change is applied on restart without recreating the interface.")
+	cmd.Flags().StringVar(&config.ClusterSecretFile, "cluster-secret-file", "",
+		"Path to a file with the pre-shared cluster secret. If set, the machine only joins a cluster that "+
+			"proves it knows the secret, regardless of the secret provided by the client adding the machine.")
+	cmd.Flags().BoolVar(&config.CaddyTLS.Internal, "caddy-tls-internal", false,
+		"Make the Caddy ingress issue TLS certificates from its own locally-trusted CA instead of a public ACME CA, "+
+			"e.g. for air-gapped clusters.")
+	cmd.Flags().StringVar(&config.CaddyTLS.CertFile, "caddy-tls-cert", "",
+		"Path to a PEM-encoded TLS certificate for the Caddy ingress to use for the hostnames it's valid for "+
+			"instead of obtaining certificates from a public ACME CA. Requires --caddy-tls-key.")
+	cmd.Flags().StringVar(&config.CaddyTLS.KeyFile, "caddy-tls-key", "",
+		"Path to the PEM-encoded private key for the certificate specified with --caddy-tls-cert.")
+	cmd.MarkFlagsRequiredTogether("caddy-tls-cert", "caddy-tls-key")
+	cmd.MarkFlagsMutuallyExclusive("caddy-tls-internal", "caddy-tls-cert")
+	cmd.Flags().BoolVar(&config.StopContainersOnShutdown, "stop-containers-on-shutdown", false,
+		"Gracefully stop all service containers on the machine when the daemon stops. "+
+			"By default, containers are left running.")
+	cmd.Flags().DurationVar(&config.StopContainersTimeout, "stop-containers-timeout",
+		machine.DefaultStopContainersTimeout,
+		"Maximum time to wait for the service containers to stop with --stop-containers-on-shutdown. It should be "+
+			"longer than the largest stop grace period of the containers and shorter than the systemd stop timeout "+
+			"of the uncloud service.")
+	cmd.Flags().IntVar(&config.MaxMessageSize, "grpc-max-message-size", pb.DefaultMaxMessageSize,
+		"Maximum size in bytes of a gRPC message the machine API can send or receive. Increase it if requests "+
+			"fail with a 'message larger than max' error on clusters with many containers. Also set "+
+			"grpc_max_message_size for the cluster in the uncloud CLI config to the same value.")
 
 	// ctx is canceled when the daemon command is interrupted.
 	ctx, cancel := context.WithCancel(context.Background())
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// linuxCapabilities is the set of Linux capability names without the CAP_ prefix.
+// See https://man7.org/linux/man-pages/man7/capabilities.7.html
+var linuxCapabilities = map[string]struct{}{
+	"AUDIT_CONTROL":      {},
+	"AUDIT_READ":         {},
+	"AUDIT_WRITE":        {},
+	"BLOCK_SUSPEND":      {},
+	"BPF":                {},
+	"CHECKPOINT_RESTORE": {},
+	"CHOWN":              {},
+	"DAC_OVERRIDE":       {},
+	"DAC_READ_SEARCH":    {},
+	"FOWNER":             {},
+	"FSETID":             {},
+	"IPC_LOCK":           {},
+	"IPC_OWNER":          {},
+	"KILL":               {},
+	"LEASE":              {},
+	"LINUX_IMMUTABLE":    {},
+	"MAC_ADMIN":          {},
+	"MAC_OVERRIDE":       {},
+	"MKNOD":              {},
+	"NET_ADMIN":          {},
+	"NET_BIND_SERVICE":   {},
+	"NET_BROADCAST":      {},
+	"NET_RAW":            {},
+	"PERFMON":            {},
+	"SETFCAP":            {},
+	"SETGID":             {},
+	"SETPCAP":            {},
+	"SETUID":             {},
+	"SYSLOG":             {},
+	"SYS_ADMIN":          {},
+	"SYS_BOOT":           {},
+	"SYS_CHROOT":         {},
+	"SYS_MODULE":         {},
+	"SYS_NICE":           {},
+	"SYS_PACCT":          {},
+	"SYS_PTRACE":         {},
+	"SYS_RAWIO":          {},
+	"SYS_RESOURCE":       {},
+	"SYS_TIME":           {},
+	"SYS_TTY_CONFIG":     {},
+	"WAKE_ALARM":         {},
+}
+
+// ValidateCapability checks that the capability is a known Linux capability name with or without the CAP_ prefix,
+// case-insensitive, or the special value ALL.
+func ValidateCapability(capability string) error {
+	name := strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+	if name == "ALL" {
+		return nil
+	}
+	if _, ok := linuxCapabilities[name]; !ok {
+		return fmt.Errorf("unknown capability: %q", capability)
+	}
+	return nil
+}
@@ -1,17 +1,45 @@
 package api
 
 import (
+	"fmt"
 	"github.com/docker/docker/api/types"
 	"regexp"
 	"strings"
+	"time"
 )
 
 const (
+	// LabelPrefix is the prefix of the labels reserved for Uncloud.
+	LabelPrefix = "uncloud."
+
 	LabelManaged      = "uncloud.managed"
 	LabelServiceID    = "uncloud.service.id"
 	LabelServiceName  = "uncloud.service.name"
 	LabelServiceMode  = "uncloud.service.mode"
 	LabelServicePorts = "uncloud.service.ports"
+	// LabelServiceStickySessions is set on containers of services which HTTP(S) ingress routes requests from
+	// the same client to the same container.
+	LabelServiceStickySessions = "uncloud.service.sticky-sessions"
+	// LabelServiceIngressHealthPath is the HTTP path the ingress actively probes on containers of the service
+	// to check they are able to serve requests.
+	LabelServiceIngressHealthPath = "uncloud.service.ingress-health-path"
+	// LabelServiceIngressHealthInterval is the interval between the active ingress health probes.
+	LabelServiceIngressHealthInterval = "uncloud.service.ingress-health-interval"
+	// LabelServiceBasicAuth is a comma-separated list of username:bcrypt_hash pairs of the users allowed
+	// to access the HTTP(S) ports of the service through the ingress.
+	LabelServiceBasicAuth = "uncloud.service.basic-auth"
+	// LabelServiceHTTPSRedirect is set on containers of services which HTTPS hostnames should redirect plain HTTP
+	// requests to HTTPS.
+	LabelServiceHTTPSRedirect = "uncloud.service.https-redirect"
+	// LabelDesiredState is the state the container should be in after it's created: DesiredStateRunning or
+	// DesiredStateCreated. It allows the machine to start containers that were created but never started.
+	LabelDesiredState = "uncloud.desired-state"
+	// LabelMovedFrom is the ID of the container that the container replaces after being moved off a drained machine.
+	// It allows a re-run of the drain to skip the containers that already have a replacement.
+	LabelMovedFrom = "uncloud.moved-from"
+
+	DesiredStateCreated = "created"
+	DesiredStateRunning = "running"
 )
 
 type Container struct {
@@ -33,6 +61,67 @@ func (c *Container) ServiceMode() string {
 	return c.Labels[LabelServiceMode]
 }
 
+// DesiredState returns the state the container should be in after it's created. Containers created without
+// the desired state label are considered to be DesiredStateCreated.
+func (c *Container) DesiredState() string {
+	if state := c.Labels[LabelDesiredState]; state != "" {
+		return state
+	}
+	return DesiredStateCreated
+}
+
+// StickySessions returns true if the ingress should route requests from the same client to this container.
+func (c *Container) StickySessions() bool {
+	_, ok := c.Labels[LabelServiceStickySessions]
+	return ok
+}
+
+// HTTPSRedirect returns true if the ingress should redirect HTTP requests to the HTTPS hostnames of this container
+// to HTTPS.
+func (c *Container) HTTPSRedirect() bool {
+	_, ok := c.Labels[LabelServiceHTTPSRedirect]
+	return ok
+}
+
+// IngressHealthCheck returns the active health check the ingress should perform on this container or nil
+// if the service doesn't define one.
+func (c *Container) IngressHealthCheck() (*IngressHealthCheckSpec, error) {
+	path, ok := c.Labels[LabelServiceIngressHealthPath]
+	if !ok {
+		return nil, nil
+	}
+
+	hc := &IngressHealthCheckSpec{Path: path}
+	if interval := c.Labels[LabelServiceIngressHealthInterval]; interval != "" {
+		var err error
+		if hc.Interval, err = time.ParseDuration(interval); err != nil {
+			return nil, fmt.Errorf("parse ingress health check interval: %w", err)
+		}
+	}
+	return hc, nil
+}
+
+// BasicAuth returns the users with password hashes allowed to access the HTTP(S) ports of this container through
+// the ingress. If empty, access is not restricted.
+func (c *Container) BasicAuth() ([]BasicAuthUser, error) {
+	encoded, ok := c.Labels[LabelServiceBasicAuth]
+	if !ok || encoded == "" {
+		return nil, nil
+	}
+
+	pairs := strings.Split(encoded, ",")
+	users := make([]BasicAuthUser, len(pairs))
+	for i, p := range pairs {
+		username, hash, ok := strings.Cut(p, ":")
+		if !ok || username == "" || hash == "" {
+			return nil, fmt.Errorf("invalid basic auth user %q: must be in the format username:password_hash",
+				username)
+		}
+		users[i] = BasicAuthUser{Username: username, PasswordHash: hash}
+	}
+	return users, nil
+}
+
 // ServicePorts returns the ports this container publishes as part of its service.
 func (c *Container) ServicePorts() ([]PortSpec, error) {
 	encoded, ok := c.Labels[LabelServicePorts]
@@ -3,7 +3,9 @@ package api
 import (
 	"github.com/docker/docker/api/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestContainer_Healthy(t *testing.T) {
@@ -90,3 +92,46 @@ func TestContainer_Healthy(t *testing.T) {
 		assert.False(t, c.Healthy())
 	})
 }
+
+func TestContainer_IngressHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not set", func(t *testing.T) {
+		t.Parallel()
+		c := &Container{Container: types.Container{Labels: map[string]string{}}}
+		hc, err := c.IngressHealthCheck()
+		require.NoError(t, err)
+		assert.Nil(t, hc)
+	})
+
+	t.Run("path only", func(t *testing.T) {
+		t.Parallel()
+		c := &Container{Container: types.Container{Labels: map[string]string{
+			LabelServiceIngressHealthPath: "/healthz",
+		}}}
+		hc, err := c.IngressHealthCheck()
+		require.NoError(t, err)
+		assert.Equal(t, &IngressHealthCheckSpec{Path: "/healthz"}, hc)
+	})
+
+	t.Run("path and interval", func(t *testing.T) {
+		t.Parallel()
+		c := &Container{Container: types.Container{Labels: map[string]string{
+			LabelServiceIngressHealthPath:     "/healthz",
+			LabelServiceIngressHealthInterval: "10s",
+		}}}
+		hc, err := c.IngressHealthCheck()
+		require.NoError(t, err)
+		assert.Equal(t, &IngressHealthCheckSpec{Path: "/healthz", Interval: 10 * time.Second}, hc)
+	})
+
+	t.Run("invalid interval", func(t *testing.T) {
+		t.Parallel()
+		c := &Container{Container: types.Container{Labels: map[string]string{
+			LabelServiceIngressHealthPath:     "/healthz",
+			LabelServiceIngressHealthInterval: "often",
+		}}}
+		_, err := c.IngressHealthCheck()
+		assert.ErrorContains(t, err, "parse ingress health check interval")
+	})
+}
@@ -25,8 +25,14 @@ type PortSpec struct {
 	// PublishedPort is the port number exposed outside the container.
 	// In ingress mode, this is the load balancer port. In host mode, this is the port bound on the host.
 	PublishedPort uint16
+	// PublishedPortEnd is the last port of the published port range starting at PublishedPort. Zero means
+	// a single port is published. Only valid in host mode.
+	PublishedPortEnd uint16
 	// ContainerPort is the port inside the container that the service listens on.
 	ContainerPort uint16
+	// ContainerPortEnd is the last port of the container port range starting at ContainerPort. Zero means
+	// a single port. Only valid in host mode and must be specified together with PublishedPortEnd.
+	ContainerPortEnd uint16
 	// Protocol specifies the network protocol.
 	Protocol string
 	// Mode specifies how the port is published.
@@ -47,6 +53,25 @@ func (p *PortSpec) Validate() error {
 			p.Protocol, ProtocolHTTP, ProtocolHTTPS, ProtocolTCP, ProtocolUDP)
 	}
 
+	if p.IsRange() {
+		if p.Mode != PortModeHost {
+			return fmt.Errorf("port ranges are only supported in %s mode", PortModeHost)
+		}
+		if p.PublishedPortEnd == 0 || p.ContainerPortEnd == 0 {
+			return fmt.Errorf("both published and container port ranges must be specified")
+		}
+		if p.PublishedPortEnd < p.PublishedPort {
+			return fmt.Errorf("invalid published port range %d-%d", p.PublishedPort, p.PublishedPortEnd)
+		}
+		if p.ContainerPortEnd < p.ContainerPort {
+			return fmt.Errorf("invalid container port range %d-%d", p.ContainerPort, p.ContainerPortEnd)
+		}
+		if p.PublishedPortEnd-p.PublishedPort != p.ContainerPortEnd-p.ContainerPort {
+			return fmt.Errorf("published port range %d-%d and container port range %d-%d must be of equal length",
+				p.PublishedPort, p.PublishedPortEnd, p.ContainerPort, p.ContainerPortEnd)
+		}
+	}
+
 	switch p.Mode {
 	case "":
 		return fmt.Errorf("mode must be specified")
@@ -83,10 +108,37 @@ func (p *PortSpec) Validate() error {
 	return nil
 }
 
+// IsRange returns true if the spec publishes a range of ports.
+func (p *PortSpec) IsRange() bool {
+	return p.PublishedPortEnd != 0 || p.ContainerPortEnd != 0
+}
+
+// Len returns the number of ports the spec publishes.
+func (p *PortSpec) Len() int {
+	if !p.IsRange() {
+		return 1
+	}
+	return int(p.ContainerPortEnd-p.ContainerPort) + 1
+}
+
+// Overlaps returns true if the spec and other spec publish any of the same host ports with the same protocol
+// in host mode.
+func (p *PortSpec) Overlaps(other PortSpec) bool {
+	if p.Mode != PortModeHost || other.Mode != PortModeHost || p.Protocol != other.Protocol {
+		return false
+	}
+	if p.HostIP.IsValid() && other.HostIP.IsValid() && p.HostIP != other.HostIP {
+		return false
+	}
+	end := int(p.PublishedPort) + p.Len() - 1
+	otherEnd := int(other.PublishedPort) + other.Len() - 1
+	return int(p.PublishedPort) <= otherEnd && int(other.PublishedPort) <= end
+}
+
 // String returns the port specification in the -p/--publish flag format.
 // Format:
 // [hostname:][load_balancer_port:]container_port/protocol for ingress mode (default) or
-// [host_ip:]:host_port:container_port/protocol@host for host mode.
+// [host_ip:]:host_port[-host_port_end]:container_port[-container_port_end]/protocol@host for host mode.
 func (p *PortSpec) String() (string, error) {
 	if err := p.Validate(); err != nil {
 		return "", err
@@ -113,8 +165,8 @@ func (p *PortSpec) String() (string, error) {
 				parts = append(parts, p.HostIP.String())
 			}
 		}
-		parts = append(parts, fmt.Sprint(p.PublishedPort))
-		parts = append(parts, fmt.Sprint(p.ContainerPort))
+		parts = append(parts, formatPortRange(p.PublishedPort, p.PublishedPortEnd))
+		parts = append(parts, formatPortRange(p.ContainerPort, p.ContainerPortEnd))
 
 		return fmt.Sprintf("%s/%s@host", strings.Join(parts, ":"), p.Protocol), nil
 	default:
@@ -165,12 +217,12 @@ func ParsePortSpec(port string) (PortSpec, error) {
 
 	switch len(parts) {
 	case 1: // Just container port.
-		if spec.ContainerPort, err = parsePort(parts[0]); err != nil {
+		if spec.ContainerPort, spec.ContainerPortEnd, err = parsePortRange(parts[0]); err != nil {
 			return spec, fmt.Errorf("invalid container port '%s': %w", parts[0], err)
 		}
 
 	case 2: // hostname:container_port or [load_balancer_port|host_port]:container_port
-		if spec.ContainerPort, err = parsePort(parts[1]); err != nil {
+		if spec.ContainerPort, spec.ContainerPortEnd, err = parsePortRange(parts[1]); err != nil {
 			return spec, fmt.Errorf("invalid container port '%s': %w", parts[1], err)
 		}
 
@@ -179,8 +231,8 @@ func ParsePortSpec(port string) (PortSpec, error) {
 				"hostname:container_port or published_port:container_port")
 		}
 		// Try to parse the first part as port.
-		if publishedPort, err := parsePort(parts[0]); err == nil {
-			spec.PublishedPort = publishedPort
+		if publishedPort, publishedPortEnd, err := parsePortRange(parts[0]); err == nil {
+			spec.PublishedPort, spec.PublishedPortEnd = publishedPort, publishedPortEnd
 		} else {
 			// It's a hostname.
 			if spec.Mode == PortModeHost {
@@ -190,10 +242,10 @@ func ParsePortSpec(port string) (PortSpec, error) {
 		}
 
 	case 3: // hostname:load_balancer_port:container_port or host_ip:host_port:container_port
-		if spec.ContainerPort, err = parsePort(parts[2]); err != nil {
+		if spec.ContainerPort, spec.ContainerPortEnd, err = parsePortRange(parts[2]); err != nil {
 			return spec, fmt.Errorf("invalid container port '%s': %w", parts[2], err)
 		}
-		if spec.PublishedPort, err = parsePort(parts[1]); err != nil {
+		if spec.PublishedPort, spec.PublishedPortEnd, err = parsePortRange(parts[1]); err != nil {
 			return spec, fmt.Errorf("invalid published port '%s': %w", parts[1], err)
 		}
 
@@ -257,6 +309,29 @@ func parsePort(s string) (uint16, error) {
 	return uint16(port), nil
 }
 
+// parsePortRange parses a single port or a port range in the start-end format. The returned end is zero
+// for a single port.
+func parsePortRange(s string) (uint16, uint16, error) {
+	startStr, endStr, ok := strings.Cut(s, "-")
+	start, err := parsePort(startStr)
+	if err != nil || !ok {
+		return start, 0, err
+	}
+	end, err := parsePort(endStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// formatPortRange formats a single port or a port range in the start-end format if end is not zero.
+func formatPortRange(start, end uint16) string {
+	if end == 0 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
 func validateHostname(hostname string) error {
 	if hostname == "" {
 		return fmt.Errorf("must not be empty")
@@ -119,6 +119,17 @@ func TestPortSpec_Validate(t *testing.T) {
 			},
 		},
 
+		{
+			name: "host mode port range",
+			spec: PortSpec{
+				PublishedPort:    8000,
+				PublishedPortEnd: 8010,
+				ContainerPort:    9000,
+				ContainerPortEnd: 9010,
+				Protocol:         ProtocolTCP,
+				Mode:             PortModeHost,
+			},
+		},
 		// Error cases.
 		{
 			name: "missing container port",
@@ -232,6 +243,53 @@ func TestPortSpec_Validate(t *testing.T) {
 			},
 			wantErr: "unsupported protocol 'https' in host mode",
 		},
+		{
+			name: "port range in ingress mode",
+			spec: PortSpec{
+				PublishedPort:    8000,
+				PublishedPortEnd: 8010,
+				ContainerPort:    8000,
+				ContainerPortEnd: 8010,
+				Protocol:         ProtocolTCP,
+				Mode:             PortModeIngress,
+			},
+			wantErr: "port ranges are only supported in host mode",
+		},
+		{
+			name: "port ranges of different length",
+			spec: PortSpec{
+				PublishedPort:    8000,
+				PublishedPortEnd: 8010,
+				ContainerPort:    8000,
+				ContainerPortEnd: 8005,
+				Protocol:         ProtocolTCP,
+				Mode:             PortModeHost,
+			},
+			wantErr: "must be of equal length",
+		},
+		{
+			name: "only published port range",
+			spec: PortSpec{
+				PublishedPort:    8000,
+				PublishedPortEnd: 8010,
+				ContainerPort:    8000,
+				Protocol:         ProtocolTCP,
+				Mode:             PortModeHost,
+			},
+			wantErr: "both published and container port ranges must be specified",
+		},
+		{
+			name: "reversed port range",
+			spec: PortSpec{
+				PublishedPort:    8010,
+				PublishedPortEnd: 8000,
+				ContainerPort:    8010,
+				ContainerPortEnd: 8000,
+				Protocol:         ProtocolTCP,
+				Mode:             PortModeHost,
+			},
+			wantErr: "invalid published port range 8010-8000",
+		},
 	}
 
 	for _, tt := range tests {
@@ -381,6 +439,18 @@ func TestPortSpec_String(t *testing.T) {
 			},
 			expected: "[2001:db8::1234:5678]:80:8080/tcp@host",
 		},
+		{
+			name: "host mode port range",
+			spec: PortSpec{
+				PublishedPort:    8000,
+				PublishedPortEnd: 8010,
+				ContainerPort:    9000,
+				ContainerPortEnd: 9010,
+				Protocol:         ProtocolUDP,
+				Mode:             PortModeHost,
+			},
+			expected: "8000-8010:9000-9010/udp@host",
+		},
 	}
 
 	for _, tt := range tests {
@@ -545,6 +615,31 @@ func TestParsePortSpec(t *testing.T) {
 				Mode:          PortModeHost,
 			},
 		},
+		{
+			name: "host mode port range",
+			port: "8000-8010:9000-9010@host",
+			expected: PortSpec{
+				PublishedPort:    8000,
+				PublishedPortEnd: 8010,
+				ContainerPort:    9000,
+				ContainerPortEnd: 9010,
+				Protocol:         ProtocolTCP,
+				Mode:             PortModeHost,
+			},
+		},
+		{
+			name: "host mode port range with IP and protocol",
+			port: "127.0.0.1:53-54:5353-5354/udp@host",
+			expected: PortSpec{
+				HostIP:           netip.MustParseAddr("127.0.0.1"),
+				PublishedPort:    53,
+				PublishedPortEnd: 54,
+				ContainerPort:    5353,
+				ContainerPortEnd: 5354,
+				Protocol:         ProtocolUDP,
+				Mode:             PortModeHost,
+			},
+		},
 
 		// Error cases.
 		{
@@ -678,6 +773,16 @@ func TestParsePortSpec(t *testing.T) {
 			port:    "app.example.com:invalid:8080@host",
 			wantErr: "invalid published port",
 		},
+		{
+			name:    "port range in ingress mode",
+			port:    "8000-8010:8000-8010",
+			wantErr: "port ranges are only supported in host mode",
+		},
+		{
+			name:    "port ranges of different length",
+			port:    "8000-8010:8000-8001@host",
+			wantErr: "must be of equal length",
+		},
 	}
 
 	for _, tt := range tests {
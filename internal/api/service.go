@@ -2,23 +2,60 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+	"github.com/moby/sys/signal"
+	"golang.org/x/crypto/bcrypt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 	"uncloud/internal/machine/api/pb"
 )
 
 const (
 	ServiceModeReplicated = "replicated"
 	ServiceModeGlobal     = "global"
+
+	// PullPolicyMissing pulls the image on a machine only if it's missing there.
+	PullPolicyMissing = "missing"
+	// PullPolicyAlways pulls the image on all target machines concurrently before creating any containers.
+	PullPolicyAlways = "always"
+	// PullPolicyNever never pulls the image, it must already be present on the target machines.
+	PullPolicyNever = "never"
+
+	// ExtraHostGateway is the special IP value of an extra host that Docker resolves to the IP of the host,
+	// e.g. host.docker.internal:host-gateway.
+	ExtraHostGateway = "host-gateway"
 )
 
 type ServiceSpec struct {
 	Container ContainerSpec
+	// ControlPlane allows a global service to also run containers on control-plane machines that don't run
+	// service containers by default. Only valid in ServiceModeGlobal.
+	ControlPlane bool
 	// Mode is the replication mode of the service. Default is ServiceModeReplicated if empty.
 	Mode string
 	Name string
 	// Ports defines what service ports to publish to make the service accessible outside the cluster.
 	Ports []PortSpec
+	// StickySessions makes the ingress route HTTP(S) requests from the same client to the same container using
+	// a cookie. By default, requests are load balanced across all containers.
+	StickySessions bool
+	// IngressHealthCheck makes the ingress actively probe the containers of the service over HTTP and stop routing
+	// requests to the ones that fail the probes. If nil, the ingress only marks containers as unavailable after
+	// failing to proxy requests to them.
+	IngressHealthCheck *IngressHealthCheckSpec
+	// BasicAuth restricts access to the HTTP(S) ports of the service through the ingress to the users that
+	// provide valid credentials using HTTP basic authentication. If empty, access is not restricted.
+	BasicAuth []BasicAuthUser
+	// HTTPSRedirect makes the ingress permanently redirect plain HTTP requests to the HTTPS hostnames
+	// of the service to HTTPS instead of serving them over HTTP.
+	HTTPSRedirect bool
 }
 
 func (s *ServiceSpec) Validate() error {
@@ -32,16 +69,157 @@ func (s *ServiceSpec) Validate() error {
 		return fmt.Errorf("invalid mode: %q", s.Mode)
 	}
 
-	// TODO: validate there is no conflict between ports.
+	if s.ControlPlane && s.Mode != ServiceModeGlobal {
+		return fmt.Errorf("running on control-plane machines is only supported in %s mode", ServiceModeGlobal)
+	}
+
+	for i, p := range s.Ports {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("invalid port: %w", err)
+		}
+		for _, other := range s.Ports[i+1:] {
+			if p.Overlaps(other) {
+				return fmt.Errorf("host ports of port specs overlap: %s, %s", portString(p), portString(other))
+			}
+		}
+	}
+	// TODO: validate there is no conflict between ingress ports.
+
+	if s.HTTPSRedirect && !slices.ContainsFunc(s.Ports, func(p PortSpec) bool {
+		return p.Protocol == ProtocolHTTPS
+	}) {
+		return errors.New("HTTPS redirect requires at least one published HTTPS port")
+	}
+
+	if s.IngressHealthCheck != nil {
+		if err := s.IngressHealthCheck.Validate(); err != nil {
+			return fmt.Errorf("invalid ingress health check: %w", err)
+		}
+	}
+
+	usernames := make(map[string]struct{}, len(s.BasicAuth))
+	for _, u := range s.BasicAuth {
+		if err := u.Validate(); err != nil {
+			return fmt.Errorf("invalid basic auth user: %w", err)
+		}
+		if _, ok := usernames[u.Username]; ok {
+			return fmt.Errorf("invalid basic auth user: duplicate username %q", u.Username)
+		}
+		usernames[u.Username] = struct{}{}
+	}
+
+	return nil
+}
 
+// BasicAuthUser is a user allowed to access a service through the ingress using HTTP basic authentication.
+type BasicAuthUser struct {
+	Username string
+	// Password is the plaintext password of the user. It's hashed with HashPassword before being stored
+	// in the cluster so the plaintext never leaves the client.
+	Password string
+	// PasswordHash is the bcrypt hash of the password. It's used when Password is empty.
+	PasswordHash string
+}
+
+func (u *BasicAuthUser) Validate() error {
+	if u.Username == "" {
+		return errors.New("username must not be empty")
+	}
+	if strings.ContainsAny(u.Username, ":,") {
+		return fmt.Errorf("invalid username %q: must not contain colons or commas", u.Username)
+	}
+	if (u.Password == "") == (u.PasswordHash == "") {
+		return fmt.Errorf("either password or password hash must be specified for user %q", u.Username)
+	}
+	if u.PasswordHash != "" {
+		if _, err := bcrypt.Cost([]byte(u.PasswordHash)); err != nil {
+			return fmt.Errorf("invalid password hash for user %q: must be a bcrypt hash", u.Username)
+		}
+	}
+	return nil
+}
+
+// HashPassword replaces the plaintext password of the user with its bcrypt hash.
+func (u *BasicAuthUser) HashPassword() error {
+	if u.Password == "" {
+		return nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password for user %q: %w", u.Username, err)
+	}
+	u.Password = ""
+	u.PasswordHash = string(hash)
+	return nil
+}
+
+// IngressHealthCheckSpec defines how the ingress actively checks that the containers of a service are able
+// to serve HTTP requests.
+type IngressHealthCheckSpec struct {
+	// Path is the HTTP path to request on the container port, e.g. /healthz. A container is considered healthy
+	// if it responds with a 2xx or 3xx status code.
+	Path string
+	// Interval is the time to wait between probes. If zero, the ingress default (30 seconds) is used.
+	Interval time.Duration
+}
+
+func (s *IngressHealthCheckSpec) Validate() error {
+	if !strings.HasPrefix(s.Path, "/") {
+		return fmt.Errorf("invalid path: %q, must start with /", s.Path)
+	}
+	if strings.ContainsAny(s.Path, ", ") {
+		return fmt.Errorf("invalid path: %q, must not contain commas or spaces", s.Path)
+	}
+	if s.Interval < 0 {
+		return fmt.Errorf("invalid interval: %s, must be positive", s.Interval)
+	}
 	return nil
 }
 
 type ContainerSpec struct {
+	// CapAdd is a list of Linux capabilities to add to the container's default set, e.g. NET_ADMIN.
+	CapAdd []string
+	// CapDrop is a list of Linux capabilities to drop from the container's default set, e.g. ALL.
+	CapDrop []string
 	Command []string
-	Image   string
+	// Env defines the environment variables to set in the container.
+	Env EnvVars
+	// ExtraHosts are additional entries to add to /etc/hosts in the container in the format host:ip. The IP can be
+	// ExtraHostGateway to map the host to the IP of the machine.
+	ExtraHosts []string
+	// Healthcheck overrides the healthcheck defined in the image. If nil, the image's healthcheck is used.
+	Healthcheck *HealthcheckSpec
+	Image       string
 	// Run a custom init inside the container. If nil, use the daemon's configured settings.
 	Init *bool
+	// Labels are custom labels to add to the container. Labels with the reserved LabelPrefix are not allowed.
+	Labels map[string]string
+	// PidsLimit is the maximum number of processes the container can run. If nil, the number is unlimited.
+	PidsLimit *int64
+	// PullPolicy defines when to pull the image on the target machines: PullPolicyMissing, PullPolicyAlways,
+	// or PullPolicyNever. Default is PullPolicyMissing if empty.
+	PullPolicy string
+	// ReadOnlyRootfs mounts the container's root filesystem as read only. Bind mounted volumes can still be
+	// writable to provide scratch directories.
+	ReadOnlyRootfs bool
+	// RestartPolicy defines when Docker restarts the container after it exits. If nil, the container is not
+	// restarted automatically.
+	RestartPolicy *RestartPolicySpec
+	// Runtime is the name of the OCI runtime to run the container with, e.g. runsc for gVisor. The runtime must be
+	// configured in the Docker daemon on the machine. If empty, the Docker default runtime is used.
+	Runtime string
+	// StopGracePeriod is the time to wait for the container to stop gracefully after sending the stop signal
+	// before killing it. Zero kills the container immediately. Docker only supports whole seconds, so a non-zero
+	// value must be at least 1 second and is rounded up to whole seconds. If nil, use the Docker default (10 seconds).
+	StopGracePeriod *time.Duration
+	// StopSignal is the signal to send to the container to stop it, e.g. SIGTERM or SIGINT. If empty, the signal
+	// defined in the image or the Docker default (SIGTERM) is used.
+	StopSignal string
+	// Sysctls sets namespaced kernel parameters in the container, e.g. net.core.somaxconn.
+	Sysctls map[string]string
+	// Ulimits overrides the default resource limits for the container processes, e.g. the maximum number
+	// of open files (nofile).
+	Ulimits []Ulimit
 	// List of volumes to bind mount into the container.
 	Volumes []string
 }
@@ -52,9 +230,249 @@ func (s *ContainerSpec) Validate() error {
 		return fmt.Errorf("invalid image: %w", err)
 	}
 
+	switch s.PullPolicy {
+	case "", PullPolicyMissing, PullPolicyAlways, PullPolicyNever:
+	default:
+		return fmt.Errorf("invalid pull policy: %q, must be one of %q, %q, or %q",
+			s.PullPolicy, PullPolicyMissing, PullPolicyAlways, PullPolicyNever)
+	}
+
+	if s.StopGracePeriod != nil {
+		if *s.StopGracePeriod < 0 {
+			return fmt.Errorf("invalid stop grace period: %s", s.StopGracePeriod)
+		}
+		if *s.StopGracePeriod > 0 && *s.StopGracePeriod < time.Second {
+			return fmt.Errorf("invalid stop grace period: %s: must be 0s or at least 1s", s.StopGracePeriod)
+		}
+	}
+
+	for _, c := range s.CapAdd {
+		if err = ValidateCapability(c); err != nil {
+			return fmt.Errorf("invalid cap add: %w", err)
+		}
+	}
+	for _, c := range s.CapDrop {
+		if err = ValidateCapability(c); err != nil {
+			return fmt.Errorf("invalid cap drop: %w", err)
+		}
+	}
+
+	for k := range s.Env {
+		if k == "" || strings.Contains(k, "=") {
+			return fmt.Errorf("invalid environment variable name: %q", k)
+		}
+	}
+
+	for _, h := range s.ExtraHosts {
+		host, ip, ok := strings.Cut(h, ":")
+		if !ok || host == "" {
+			return fmt.Errorf("invalid extra host %q: must be in the format host:ip", h)
+		}
+		if ip == ExtraHostGateway {
+			continue
+		}
+		if _, err = netip.ParseAddr(ip); err != nil {
+			return fmt.Errorf("invalid extra host %q: invalid IP address: %w", h, err)
+		}
+	}
+
+	for k := range s.Labels {
+		if k == "" {
+			return errors.New("invalid label: key must not be empty")
+		}
+		if strings.HasPrefix(k, LabelPrefix) {
+			return fmt.Errorf("invalid label %q: labels with the %q prefix are reserved", k, LabelPrefix)
+		}
+	}
+
+	if s.StopSignal != "" {
+		if _, err = signal.ParseSignal(s.StopSignal); err != nil {
+			return fmt.Errorf("invalid stop signal: %q", s.StopSignal)
+		}
+	}
+
+	if s.PidsLimit != nil && *s.PidsLimit <= 0 {
+		return fmt.Errorf("invalid PIDs limit: %d, must be positive", *s.PidsLimit)
+	}
+
+	for k := range s.Sysctls {
+		if err = validateSysctl(k); err != nil {
+			return err
+		}
+	}
+
+	for _, u := range s.Ulimits {
+		if err = u.Validate(); err != nil {
+			return fmt.Errorf("invalid ulimit %q: %w", u.Name, err)
+		}
+	}
+
+	if s.Healthcheck != nil {
+		if err = s.Healthcheck.Validate(); err != nil {
+			return fmt.Errorf("invalid healthcheck: %w", err)
+		}
+	}
+
+	if s.RestartPolicy != nil {
+		if err = s.RestartPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HealthcheckSpec defines how to check that a container is healthy.
+type HealthcheckSpec struct {
+	// Disable turns off any healthcheck including the one defined in the image.
+	Disable bool
+	// Test is the shell command to run in the container to check its health. The container is considered healthy
+	// if the command exits with 0. If empty, the test command defined in the image is used.
+	Test string
+	// Interval is the time to wait between checks. If zero, the Docker default (30 seconds) is used.
+	Interval time.Duration
+	// Timeout is the time to wait before considering a check to have hung. If zero, the Docker default
+	// (30 seconds) is used.
+	Timeout time.Duration
+	// Retries is the number of consecutive failures needed to consider the container unhealthy. If zero,
+	// the Docker default (3) is used.
+	Retries int
+	// StartPeriod is the time for the container to initialise before failed checks count towards the retries.
+	// If zero, the Docker default (0 seconds) is used.
+	StartPeriod time.Duration
+}
+
+func (s *HealthcheckSpec) Validate() error {
+	if s.Disable {
+		if s.Test != "" || s.Interval != 0 || s.Timeout != 0 || s.Retries != 0 || s.StartPeriod != 0 {
+			return errors.New("disable and other healthcheck options are mutually exclusive")
+		}
+		return nil
+	}
+	if s.Test == "" && s.Interval == 0 && s.Timeout == 0 && s.Retries == 0 && s.StartPeriod == 0 {
+		return errors.New("test command or at least one of the healthcheck options must be specified " +
+			"if healthcheck is not disabled")
+	}
+	if s.Interval < 0 {
+		return fmt.Errorf("invalid interval: %s, must be positive", s.Interval)
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("invalid timeout: %s, must be positive", s.Timeout)
+	}
+	if s.Retries < 0 {
+		return fmt.Errorf("invalid retries: %d, must be positive", s.Retries)
+	}
+	if s.StartPeriod < 0 {
+		return fmt.Errorf("invalid start period: %s, must be positive", s.StartPeriod)
+	}
 	return nil
 }
 
+// portString returns the port spec in the -p/--publish flag format or a Go representation if it's invalid.
+func portString(p PortSpec) string {
+	if s, err := p.String(); err == nil {
+		return s
+	}
+	return fmt.Sprintf("%+v", p)
+}
+
+// ipcSysctls are the sysctls namespaced by the IPC namespace that can be set per container.
+var ipcSysctls = []string{
+	"kernel.msgmax", "kernel.msgmnb", "kernel.msgmni", "kernel.sem", "kernel.shmall", "kernel.shmmax",
+	"kernel.shmmni", "kernel.shm_rmid_forced",
+}
+
+// validateSysctl checks that the sysctl is namespaced and can be set per container. Docker rejects sysctls
+// that are not namespaced as they would change the kernel parameters of the host.
+func validateSysctl(name string) error {
+	if slices.Contains(ipcSysctls, name) || strings.HasPrefix(name, "fs.mqueue.") {
+		return nil
+	}
+	// Service containers are attached to the uncloud bridge network which has its own network namespace.
+	if strings.HasPrefix(name, "net.") {
+		return nil
+	}
+	return fmt.Errorf("invalid sysctl %q: only namespaced sysctls (kernel.msg*, kernel.sem, kernel.shm*, "+
+		"fs.mqueue.*, net.*) are supported", name)
+}
+
+// EnvVars is a map of environment variable names to their values.
+type EnvVars map[string]string
+
+// ToSlice returns the environment variables in the KEY=VALUE format sorted by name.
+func (e EnvVars) ToSlice() []string {
+	env := make([]string, 0, len(e))
+	for k, v := range e {
+		env = append(env, k+"="+v)
+	}
+	slices.Sort(env)
+	return env
+}
+
+// Ulimit is a resource limit for the container processes. A limit of -1 means unlimited.
+type Ulimit struct {
+	// Name is the name of the Linux resource limit without the RLIMIT_ prefix, e.g. nofile or nproc.
+	Name string
+	Soft int64
+	Hard int64
+}
+
+func (u *Ulimit) Validate() error {
+	if _, err := u.DockerUlimit().GetRlimit(); err != nil {
+		return err
+	}
+	if u.Hard != -1 && (u.Soft == -1 || u.Soft > u.Hard) {
+		return fmt.Errorf("soft limit must be less than or equal to hard limit: %d > %d", u.Soft, u.Hard)
+	}
+	return nil
+}
+
+// DockerUlimit returns the Docker ulimit for the spec.
+func (u *Ulimit) DockerUlimit() *units.Ulimit {
+	return &units.Ulimit{Name: u.Name, Soft: u.Soft, Hard: u.Hard}
+}
+
+// RestartPolicySpec defines when Docker restarts a container after it exits.
+type RestartPolicySpec struct {
+	// Name is the restart policy: "no", "always", "unless-stopped", or "on-failure".
+	Name string
+	// MaximumRetryCount is the maximum number of restarts for the "on-failure" policy. Zero means unlimited.
+	MaximumRetryCount int
+}
+
+func (s *RestartPolicySpec) Validate() error {
+	if s.Name == "" {
+		return errors.New("invalid restart policy: name must be specified")
+	}
+	return container.ValidateRestartPolicy(s.DockerRestartPolicy())
+}
+
+// DockerRestartPolicy returns the Docker restart policy for the spec.
+func (s *RestartPolicySpec) DockerRestartPolicy() container.RestartPolicy {
+	return container.RestartPolicy{
+		Name:              container.RestartPolicyMode(s.Name),
+		MaximumRetryCount: s.MaximumRetryCount,
+	}
+}
+
+// ParseRestartPolicy parses a restart policy in the format used by the docker run --restart flag:
+// no, always, unless-stopped, or on-failure[:max-retries].
+func ParseRestartPolicy(policy string) (RestartPolicySpec, error) {
+	name, retries, ok := strings.Cut(policy, ":")
+	spec := RestartPolicySpec{Name: name}
+	if ok {
+		n, err := strconv.Atoi(retries)
+		if err != nil {
+			return spec, fmt.Errorf("invalid maximum retry count: %q", retries)
+		}
+		spec.MaximumRetryCount = n
+	}
+	if err := spec.Validate(); err != nil {
+		return spec, err
+	}
+	return spec, nil
+}
+
 type Service struct {
 	ID         string
 	Name       string
@@ -62,6 +480,12 @@ type Service struct {
 	Containers []MachineContainer
 }
 
+// ServiceSpecRecord is the desired spec of a service stored in the cluster independently of its containers.
+type ServiceSpecRecord struct {
+	ID   string
+	Spec ServiceSpec
+}
+
 type MachineContainer struct {
 	MachineID string
 	Container Container
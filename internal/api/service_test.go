@@ -0,0 +1,190 @@
+package api
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestParseRestartPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		policy   string
+		expected RestartPolicySpec
+		wantErr  string
+	}{
+		{
+			name:     "no",
+			policy:   "no",
+			expected: RestartPolicySpec{Name: "no"},
+		},
+		{
+			name:     "always",
+			policy:   "always",
+			expected: RestartPolicySpec{Name: "always"},
+		},
+		{
+			name:     "unless-stopped",
+			policy:   "unless-stopped",
+			expected: RestartPolicySpec{Name: "unless-stopped"},
+		},
+		{
+			name:     "on-failure",
+			policy:   "on-failure",
+			expected: RestartPolicySpec{Name: "on-failure"},
+		},
+		{
+			name:     "on-failure with max retries",
+			policy:   "on-failure:5",
+			expected: RestartPolicySpec{Name: "on-failure", MaximumRetryCount: 5},
+		},
+		{
+			name:    "empty",
+			policy:  "",
+			wantErr: "name must be specified",
+		},
+		{
+			name:    "unknown policy",
+			policy:  "sometimes",
+			wantErr: "invalid restart policy",
+		},
+		{
+			name:    "max retries with always",
+			policy:  "always:3",
+			wantErr: "maximum retry count can only be used with 'on-failure'",
+		},
+		{
+			name:    "negative max retries",
+			policy:  "on-failure:-1",
+			wantErr: "maximum retry count cannot be negative",
+		},
+		{
+			name:    "invalid max retries",
+			policy:  "on-failure:many",
+			wantErr: "invalid maximum retry count",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec, err := ParseRestartPolicy(tt.policy)
+			if tt.wantErr != "" {
+				require.Error(t, err, "Expected error: %s, got nil, spec: %+v", tt.wantErr, spec)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, spec)
+		})
+	}
+}
+
+func TestServiceSpec_Validate_PortOverlap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		ports   []string
+		wantErr bool
+	}{
+		{
+			name:  "distinct host ports",
+			ports: []string{"8000-8010:8000-8010@host", "8011:8011@host"},
+		},
+		{
+			name:  "same host port different protocols",
+			ports: []string{"53:53/tcp@host", "53:53/udp@host"},
+		},
+		{
+			name:  "same host port different host IPs",
+			ports: []string{"127.0.0.1:80:80@host", "127.0.0.2:80:80@host"},
+		},
+		{
+			name:    "overlapping port ranges",
+			ports:   []string{"8000-8010:8000-8010@host", "8010-8020:9000-9010@host"},
+			wantErr: true,
+		},
+		{
+			name:    "port within range",
+			ports:   []string{"8000-8010:8000-8010@host", "127.0.0.1:8005:80@host"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec := ServiceSpec{
+				Container: ContainerSpec{Image: "busybox:latest"},
+				Name:      "test",
+			}
+			for _, p := range tt.ports {
+				port, err := ParsePortSpec(p)
+				require.NoError(t, err)
+				spec.Ports = append(spec.Ports, port)
+			}
+
+			err := spec.Validate()
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "host ports of port specs overlap")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBasicAuthUser_HashPassword(t *testing.T) {
+	t.Parallel()
+
+	u := BasicAuthUser{Username: "admin", Password: "secret"}
+	require.NoError(t, u.Validate())
+	require.NoError(t, u.HashPassword())
+
+	assert.Empty(t, u.Password)
+	assert.True(t, strings.HasPrefix(u.PasswordHash, "$2a$"), "expected bcrypt hash, got %q", u.PasswordHash)
+	assert.NoError(t, u.Validate())
+
+	assert.ErrorContains(t, (&BasicAuthUser{Username: "admin"}).Validate(), "either password or password hash")
+	assert.ErrorContains(t, (&BasicAuthUser{Username: "admin", PasswordHash: "plain"}).Validate(),
+		"must be a bcrypt hash")
+	assert.ErrorContains(t, (&BasicAuthUser{Username: "ad:min", Password: "secret"}).Validate(),
+		"must not contain colons or commas")
+}
+
+func TestContainerSpec_Validate_ExtraHosts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		host    string
+		wantErr bool
+	}{
+		{host: "db.internal:10.210.0.2"},
+		{host: "db.internal:::1"},
+		{host: "host.docker.internal:host-gateway"},
+		{host: "db.internal", wantErr: true},
+		{host: ":10.210.0.2", wantErr: true},
+		{host: "db.internal:gateway", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			t.Parallel()
+
+			spec := ContainerSpec{Image: "busybox:latest", ExtraHosts: []string{tt.host}}
+			err := spec.Validate()
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "invalid extra host")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -1,17 +1,23 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/charmbracelet/huh"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"net/netip"
+	"slices"
+	"time"
 	"uncloud/internal/cli/client"
 	"uncloud/internal/cli/client/connector"
 	"uncloud/internal/cli/config"
 	"uncloud/internal/machine"
 	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/caddyfile"
+	"uncloud/internal/machine/network"
 	"uncloud/internal/sshexec"
 )
 
@@ -90,37 +96,71 @@ func (cli *CLI) ConnectCluster(ctx context.Context, clusterName string) (*client
 			return nil, fmt.Errorf("parse SSH connection %q: %w", conn.SSH, err)
 		}
 		sshConfig := &connector.SSHConnectorConfig{
-			User: user,
-			Host: host,
-			Port: port,
+			User:           user,
+			Host:           host,
+			Port:           port,
+			MaxMessageSize: cfg.GRPCMaxMessageSize,
 		}
 		return client.New(ctx, connector.NewSSHConnector(sshConfig))
 	} else if conn.TCP.IsValid() {
-		return client.New(ctx, connector.NewTCPConnector(conn.TCP))
+		return client.New(ctx, connector.NewTCPConnector(conn.TCP, cfg.GRPCMaxMessageSize))
 	}
 	return nil, errors.New("no valid connection configuration found for the cluster")
 }
 
-func (cli *CLI) InitCluster(
-	ctx context.Context, remoteMachine *RemoteMachine, clusterName, machineName string, netPrefix netip.Prefix,
-) error {
+// InitClusterOptions configures a new cluster and its first machine.
+type InitClusterOptions struct {
+	// ClusterName is the name of the cluster in the local config. Default is "default".
+	ClusterName string
+	// MachineName is the name of the machine. A random name is generated if not specified.
+	MachineName string
+	// Network is the IPv4 or IPv6 network CIDR to use for machines and services.
+	Network netip.Prefix
+	// Interface is the name of the network interface which addresses are used as WireGuard endpoints.
+	Interface string
+	// Role is the role of the machine in the cluster.
+	Role string
+	// Ingress is the ingress controller used in the cluster.
+	Ingress string
+	// PublicIPSource specifies how the machine determines its public IP address to use as a WireGuard endpoint.
+	PublicIPSource string
+	// Subnet is the subnet to assign to the machine from the cluster network. If not valid, the subnet
+	// is allocated automatically.
+	Subnet netip.Prefix
+	// ClusterSecret is an optional pre-shared secret that machines must present to be added to the cluster.
+	ClusterSecret string
+	// DryRun reports what would be installed on the machine and changed in the cluster without making changes.
+	DryRun bool
+	// Resume continues a previous initialisation that failed midway. Each step is skipped if it's already done.
+	Resume bool
+	// WGEndpoint is the WireGuard endpoint of the machine that takes precedence over the auto-discovered
+	// endpoints if valid.
+	WGEndpoint netip.AddrPort
+}
+
+func (cli *CLI) InitCluster(ctx context.Context, remoteMachine *RemoteMachine, opts InitClusterOptions) error {
 	if remoteMachine != nil {
-		return cli.initRemoteMachine(ctx, *remoteMachine, clusterName, machineName, netPrefix)
+		if opts.DryRun {
+			return cli.planInitRemoteMachine(ctx, *remoteMachine, opts)
+		}
+		return cli.initRemoteMachine(ctx, *remoteMachine, opts)
 	}
 	// TODO: implement local machine initialisation
 	return fmt.Errorf("local machine initialisation is not implemented yet")
 }
 
-func (cli *CLI) initRemoteMachine(
-	ctx context.Context, remoteMachine RemoteMachine, clusterName, machineName string, netPrefix netip.Prefix,
-) error {
+func (cli *CLI) initRemoteMachine(ctx context.Context, remoteMachine RemoteMachine, opts InitClusterOptions) error {
+	clusterName := opts.ClusterName
 	if clusterName == "" {
 		clusterName = defaultClusterName
 	}
-	if _, ok := cli.config.Clusters[clusterName]; ok {
-		return fmt.Errorf("cluster %q already exists", clusterName)
+	_, clusterExists := cli.config.Clusters[clusterName]
+	if clusterExists && !opts.Resume {
+		return fmt.Errorf("cluster %q already exists, use --resume to continue its failed initialisation",
+			clusterName)
 	}
 
+	// Provisioning is idempotent so it's safe to re-run when resuming.
 	machineClient, err := cli.provisionRemoteMachine(ctx, remoteMachine)
 	if err != nil {
 		return err
@@ -128,19 +168,40 @@ func (cli *CLI) initRemoteMachine(
 	defer machineClient.Close()
 
 	// Check if the machine is already initialised as a cluster member and prompt the user to reset it first.
+	// When resuming, the machine continues or confirms its initialisation instead.
 	minfo, err := machineClient.Inspect(ctx, &emptypb.Empty{})
 	if err != nil {
 		return fmt.Errorf("inspect machine: %w", err)
 	}
 	if minfo.Id != "" {
-		if err = cli.promptResetMachine(); err != nil {
-			return err
+		resumable := false
+		if opts.Resume {
+			if resumable, err = cli.resumableMachine(ctx, machineClient, minfo, clusterName); err != nil {
+				return err
+			}
+		}
+		if !resumable {
+			if err = cli.promptResetMachine(); err != nil {
+				return err
+			}
 		}
 	}
 
 	req := &pb.InitClusterRequest{
-		MachineName: machineName,
-		Network:     pb.NewIPPrefix(netPrefix),
+		MachineName:    opts.MachineName,
+		Network:        pb.NewIPPrefix(opts.Network),
+		Interface:      opts.Interface,
+		Role:           opts.Role,
+		Ingress:        opts.Ingress,
+		PublicIpSource: opts.PublicIPSource,
+		ClusterSecret:  opts.ClusterSecret,
+		Resume:         opts.Resume,
+	}
+	if opts.Subnet.IsValid() {
+		req.Subnet = pb.NewIPPrefix(opts.Subnet)
+	}
+	if opts.WGEndpoint.IsValid() {
+		req.WgEndpoint = pb.NewIPPort(opts.WGEndpoint)
 	}
 	resp, err := machineClient.InitCluster(ctx, req)
 	if err != nil {
@@ -148,8 +209,10 @@ func (cli *CLI) initRemoteMachine(
 	}
 	fmt.Printf("Cluster %q initialised with machine %q\n", clusterName, resp.Machine.Name)
 
-	if err = cli.CreateCluster(clusterName); err != nil {
-		return fmt.Errorf("save cluster to config: %w", err)
+	if !clusterExists {
+		if err = cli.CreateCluster(clusterName); err != nil {
+			return fmt.Errorf("save cluster to config: %w", err)
+		}
 	}
 	// Set the current cluster to the just created one if it is the only cluster in the config.
 	if len(cli.config.Clusters) == 1 {
@@ -159,16 +222,112 @@ func (cli *CLI) initRemoteMachine(
 	}
 	// Save the machine's SSH connection details in the cluster config.
 	connCfg := config.MachineConnection{
-		SSH: config.NewSSHDestination(remoteMachine.User, remoteMachine.Host, remoteMachine.Port),
+		SSH:       config.NewSSHDestination(remoteMachine.User, remoteMachine.Host, remoteMachine.Port),
+		PublicKey: resp.Machine.Network.PublicKey,
 	}
-	cli.config.Clusters[clusterName].Connections = append(cli.config.Clusters[clusterName].Connections, connCfg)
+	cfg := cli.config.Clusters[clusterName]
+	if machineConnectionIndex(cfg.Connections, resp.Machine) != -1 {
+		// The connection was saved by the initialisation being resumed.
+		return nil
+	}
+	cfg.Connections = append(cfg.Connections, connCfg)
 	if err = cli.config.Save(); err != nil {
 		return fmt.Errorf("save config: %w", err)
 	}
 	return nil
 }
 
-func (cli *CLI) AddMachine(ctx context.Context, remoteMachine RemoteMachine, clusterName, machineName string) error {
+// planInitRemoteMachine runs the preflight checks on the remote machine and reports what would be installed on it
+// and changed in the cluster and local config by initialising a cluster, without making any changes.
+func (cli *CLI) planInitRemoteMachine(ctx context.Context, remoteMachine RemoteMachine, opts InitClusterOptions) error {
+	clusterName := opts.ClusterName
+	if clusterName == "" {
+		clusterName = defaultClusterName
+	}
+	if _, ok := cli.config.Clusters[clusterName]; ok {
+		return fmt.Errorf("cluster %q already exists", clusterName)
+	}
+
+	sshClient, err := sshexec.Connect(remoteMachine.User, remoteMachine.Host, remoteMachine.Port, remoteMachine.KeyPath)
+	if err != nil {
+		return fmt.Errorf(
+			"SSH login to remote machine %s: %w",
+			config.NewSSHDestination(remoteMachine.User, remoteMachine.Host, remoteMachine.Port), err,
+		)
+	}
+	defer sshClient.Close()
+
+	if err = provisionMachine(ctx, sshexec.NewRemote(sshClient), true); err != nil {
+		return fmt.Errorf("provision machine (dry run): %w", err)
+	}
+
+	fmt.Println()
+	fmt.Println("Cluster changes:")
+	// Check if the machine daemon is already running and initialised as a cluster member. Errors are ignored
+	// as the daemon is not expected to be running if it hasn't been installed yet.
+	machineClient, err := client.New(ctx, connector.NewSSHConnectorFromClient(sshClient))
+	if err == nil {
+		minfo, iErr := machineClient.Inspect(ctx, &emptypb.Empty{})
+		if iErr == nil && minfo.Id != "" {
+			fmt.Printf("- Machine is already a member of a cluster as %q and would need to be reset first.\n",
+				minfo.Name)
+		}
+		_ = machineClient.Close()
+	}
+
+	machineName := opts.MachineName
+	if machineName == "" {
+		machineName = "<random name>"
+	}
+	role := opts.Role
+	if role == "" {
+		role = pb.MachineRoleWorker
+	}
+	endpoints := "all routable addresses and the public IP"
+	switch opts.PublicIPSource {
+	case "", network.PublicIPSourceAuto:
+	case network.PublicIPSourceNone:
+		endpoints = "all routable addresses"
+	default:
+		endpoints = fmt.Sprintf("all routable addresses and the public IP from %q", opts.PublicIPSource)
+	}
+	if opts.Interface != "" {
+		endpoints = fmt.Sprintf("addresses of interface %q", opts.Interface)
+	}
+	ingress := opts.Ingress
+	if ingress == "" {
+		ingress = caddyfile.IngressCaddy
+	}
+	fmt.Printf("- Would initialise a new cluster with network %s and %s ingress.\n", opts.Network, ingress)
+	if opts.ClusterSecret != "" {
+		fmt.Println("- Would require the cluster secret to add machines to the cluster.")
+	}
+	subnet := "an automatically allocated subnet"
+	if opts.Subnet.IsValid() {
+		subnet = "subnet " + opts.Subnet.String()
+	}
+	fmt.Printf("- Would add machine %q with role %q and %s to the cluster using %s as WireGuard endpoints.\n",
+		machineName, role, subnet, endpoints)
+	fmt.Printf("- Would add cluster %q to the local config %s", clusterName, cli.config.Path())
+	if len(cli.config.Clusters) == 0 {
+		fmt.Print(" and set it as the current cluster")
+	}
+	fmt.Println(".")
+
+	return nil
+}
+
+// AddMachine provisions the remote machine and adds it to the cluster. The clusterSecret must match the pre-shared
+// cluster secret if the cluster was initialised with one. If wgEndpoint is valid, it takes precedence over
+// the auto-discovered WireGuard endpoints of the machine.
+func (cli *CLI) AddMachine(
+	ctx context.Context,
+	remoteMachine RemoteMachine,
+	clusterName, machineName, role string,
+	subnet netip.Prefix,
+	clusterSecret string,
+	wgEndpoint netip.AddrPort,
+) error {
 	c, err := cli.ConnectCluster(ctx, clusterName)
 	if err != nil {
 		return fmt.Errorf("connect to cluster: %w", err)
@@ -214,6 +373,14 @@ func (cli *CLI) AddMachine(ctx context.Context, remoteMachine RemoteMachine, clu
 			Endpoints: endpoints,
 			PublicKey: token.PublicKey,
 		},
+		Role:          role,
+		ClusterSecret: clusterSecret,
+	}
+	if subnet.IsValid() {
+		addReq.Network.Subnet = pb.NewIPPrefix(subnet)
+	}
+	if wgEndpoint.IsValid() {
+		addReq.Network.ManualEndpoint = pb.NewIPPort(wgEndpoint)
 	}
 	addResp, err := c.AddMachine(ctx, addReq)
 	if err != nil {
@@ -236,6 +403,10 @@ func (cli *CLI) AddMachine(ctx context.Context, remoteMachine RemoteMachine, clu
 	joinReq := &pb.JoinClusterRequest{
 		Machine:       addResp.Machine,
 		OtherMachines: otherMachines,
+		// The machine verifies the cluster knows the secret before joining it.
+		ClusterSecret: clusterSecret,
+		SecretSalt:    addResp.SecretSalt,
+		JoinProof:     addResp.JoinProof,
 	}
 	if _, err = machineClient.JoinCluster(ctx, joinReq); err != nil {
 		return fmt.Errorf("join cluster: %w", err)
@@ -245,7 +416,8 @@ func (cli *CLI) AddMachine(ctx context.Context, remoteMachine RemoteMachine, clu
 
 	// Save the machine's SSH connection details in the cluster config.
 	connCfg := config.MachineConnection{
-		SSH: config.NewSSHDestination(remoteMachine.User, remoteMachine.Host, remoteMachine.Port),
+		SSH:       config.NewSSHDestination(remoteMachine.User, remoteMachine.Host, remoteMachine.Port),
+		PublicKey: addResp.Machine.Network.PublicKey,
 	}
 	if clusterName == "" {
 		clusterName = cli.config.CurrentCluster
@@ -258,6 +430,199 @@ func (cli *CLI) AddMachine(ctx context.Context, remoteMachine RemoteMachine, clu
 	return nil
 }
 
+// RemoveMachine removes the machine identified by its name or ID from the cluster and its connection from
+// the cluster config. If purge is true, it also uninstalls Uncloud from the machine over SSH leaving the host clean.
+func (cli *CLI) RemoveMachine(
+	ctx context.Context, clusterName, nameOrID string, drain, purge bool, sshKeyPath string,
+) error {
+	c, err := cli.ConnectCluster(ctx, clusterName)
+	if err != nil {
+		return fmt.Errorf("connect to cluster: %w", err)
+	}
+	defer c.Close()
+
+	machines, err := c.ListMachines(ctx)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+	var member *pb.MachineMember
+	for _, mm := range machines {
+		if mm.Machine.Id == nameOrID || mm.Machine.Name == nameOrID {
+			member = mm
+			break
+		}
+	}
+	if member == nil {
+		// The machine may have been removed by a previous run that failed midway. The remaining machines stop
+		// peering with it on their own once their cluster stores sync the removal.
+		fmt.Printf("Machine %q not found in the cluster, it may have already been removed.\n", nameOrID)
+		return nil
+	}
+	m := member.Machine
+
+	if clusterName == "" {
+		clusterName = cli.config.CurrentCluster
+	}
+	cfg := cli.config.Clusters[clusterName]
+	connIdx := machineConnectionIndex(cfg.Connections, m)
+	// Find the SSH destination before removing the machine to fail early if the machine can't be purged.
+	if purge && (connIdx == -1 || cfg.Connections[connIdx].SSH == "") {
+		return fmt.Errorf("SSH connection for machine %q not found in the cluster config, "+
+			"remove the machine without --purge and clean up the host manually", m.Name)
+	}
+
+	if drain {
+		if member.State == pb.MachineMember_DOWN {
+			fmt.Printf("WARNING: skipping draining machine %q which is down, its service containers are not "+
+				"moved to other machines.\n", m.Name)
+		} else if err = c.DrainMachine(ctx, m.Id); err != nil {
+			return fmt.Errorf("drain machine (use --no-drain to remove it without moving its containers): %w", err)
+		}
+	}
+
+	if _, err = c.ClusterClient.RemoveMachine(ctx, &pb.RemoveMachineRequest{Machine: m.Id}); err != nil {
+		return fmt.Errorf("remove machine from cluster: %w", err)
+	}
+	fmt.Printf("Machine %q removed from the cluster.\n", m.Name)
+	reconfigurePeers(ctx, c, slices.DeleteFunc(machines, func(mm *pb.MachineMember) bool {
+		return mm.Machine.Id == m.Id
+	}), m.Id)
+
+	var conn config.MachineConnection
+	if connIdx != -1 {
+		conn = cfg.Connections[connIdx]
+		cfg.Connections = slices.Delete(cfg.Connections, connIdx, connIdx+1)
+		if err = cli.config.Save(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+	}
+	if !purge {
+		// The removed machine would otherwise keep running its daemons and syncing its containers to the cluster
+		// store. Stopping them is best effort as the machine may be unreachable.
+		if err = stopRemovedMachine(ctx, conn, sshKeyPath); err != nil {
+			fmt.Printf("WARNING: failed to stop Uncloud on the removed machine %q: %v\n", m.Name, err)
+			fmt.Println("Run 'systemctl disable --now uncloud uncloud-corrosion' on the machine to stop it " +
+				"or remove the machine with --purge to uninstall Uncloud from it.")
+		}
+		return nil
+	}
+
+	user, host, port, err := conn.SSH.Parse()
+	if err != nil {
+		return fmt.Errorf("parse SSH connection %q: %w", conn.SSH, err)
+	}
+	sshClient, err := sshexec.Connect(user, host, port, sshKeyPath)
+	if err != nil {
+		return fmt.Errorf("SSH login to machine %s: %w", conn.SSH, err)
+	}
+	defer sshClient.Close()
+	if err = purgeMachine(ctx, sshexec.NewRemote(sshClient)); err != nil {
+		return fmt.Errorf("purge machine: %w", err)
+	}
+	return nil
+}
+
+// stopRemovedMachine stops the Uncloud daemons on the removed machine over SSH using its connection from the config.
+func stopRemovedMachine(ctx context.Context, conn config.MachineConnection, sshKeyPath string) error {
+	if conn.SSH == "" {
+		return errors.New("SSH connection for the machine not found in the cluster config")
+	}
+	user, host, port, err := conn.SSH.Parse()
+	if err != nil {
+		return fmt.Errorf("parse SSH connection %q: %w", conn.SSH, err)
+	}
+	sshClient, err := sshexec.Connect(user, host, port, sshKeyPath)
+	if err != nil {
+		return fmt.Errorf("SSH login to machine %s: %w", conn.SSH, err)
+	}
+	defer sshClient.Close()
+	if err = stopMachineDaemons(ctx, sshexec.NewRemote(sshClient)); err != nil {
+		return err
+	}
+	fmt.Println("Uncloud daemons stopped on the removed machine.")
+	return nil
+}
+
+// removedMachineSyncTimeout is the maximum time to wait for the cluster store on a machine to sync the removal of
+// another machine before reconfiguring its network peers.
+const removedMachineSyncTimeout = 30 * time.Second
+
+// reconfigurePeers reconfigures the WireGuard peers on the available machines to apply the removal of the machine
+// with the given ID without waiting for the change to be picked up by the store watch. A machine rebuilds the peers
+// from its own cluster store so it's only reconfigured once its store no longer lists the removed machine. Failures
+// are only reported as warnings as the machines reconfigure the peers on their own once the store is synced.
+func reconfigurePeers(ctx context.Context, c *client.Client, machines []*pb.MachineMember, removedID string) {
+	for _, mm := range machines {
+		if mm.State == pb.MachineMember_DOWN {
+			continue
+		}
+		machineIP, _ := mm.Machine.Network.ManagementIp.ToAddr()
+		machineCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("machines", machineIP.String()))
+		if err := waitMachineRemoved(machineCtx, c, removedID); err != nil {
+			fmt.Printf("WARNING: cluster store on machine %q hasn't synced the removal: %v. "+
+				"The machine will reconfigure its network peers once the store is synced.\n", mm.Machine.Name, err)
+			continue
+		}
+		if _, err := c.MachineClient.ReconfigureNetwork(machineCtx, &emptypb.Empty{}); err != nil {
+			fmt.Printf("WARNING: failed to reconfigure network peers on machine %q: %v\n", mm.Machine.Name, err)
+		}
+	}
+}
+
+// waitMachineRemoved waits until the cluster store on the machine the context is proxied to no longer lists
+// the machine with the given ID.
+func waitMachineRemoved(ctx context.Context, c *client.Client, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, removedMachineSyncTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		machines, err := c.ListMachines(ctx)
+		if err == nil && !slices.ContainsFunc(machines, func(mm *pb.MachineMember) bool {
+			return mm.Machine.Id == id
+		}) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("list machines: %w", err)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// machineConnectionIndex returns the index of the connection to the machine in the connections or -1 if not found.
+// Connections are matched by the machine's public key or, for connections saved without it, by the SSH host being
+// one of the machine's endpoint IPs.
+func machineConnectionIndex(connections []config.MachineConnection, m *pb.MachineInfo) int {
+	for i, conn := range connections {
+		if len(conn.PublicKey) > 0 && bytes.Equal(conn.PublicKey, m.Network.PublicKey) {
+			return i
+		}
+	}
+	for i, conn := range connections {
+		if conn.SSH == "" || len(conn.PublicKey) > 0 {
+			continue
+		}
+		_, host, _, err := conn.SSH.Parse()
+		if err != nil {
+			continue
+		}
+		for _, ep := range m.Network.Endpoints {
+			addrPort, _ := ep.ToAddrPort()
+			if addrPort.Addr().String() == host {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 // provisionRemoteMachine installs the Uncloud daemon and dependencies on the remote machine over SSH and returns
 // a machine API client to interact with the machine. The client should be closed after use by the caller.
 func (cli *CLI) provisionRemoteMachine(ctx context.Context, remoteMachine RemoteMachine) (*client.Client, error) {
@@ -271,7 +636,7 @@ func (cli *CLI) provisionRemoteMachine(ctx context.Context, remoteMachine Remote
 	}
 	exec := sshexec.NewRemote(sshClient)
 	// Install and run the Uncloud daemon and dependencies on the remote machine.
-	if err = provisionMachine(ctx, exec); err != nil {
+	if err = provisionMachine(ctx, exec, false); err != nil {
 		return nil, fmt.Errorf("provision machine: %w", err)
 	}
 
@@ -296,6 +661,30 @@ func (cli *CLI) provisionRemoteMachine(ctx context.Context, remoteMachine Remote
 	return machineClient, nil
 }
 
+// resumableMachine returns true if the initialised machine can have been initialised by the failed initialisation
+// of the cluster that is being resumed rather than belonging to a different cluster. Such a machine is the only
+// member of its cluster and, if the cluster has already been saved to the config with connections, is one of them.
+func (cli *CLI) resumableMachine(
+	ctx context.Context, machineClient *client.Client, minfo *pb.MachineInfo, clusterName string,
+) (bool, error) {
+	if cfg, ok := cli.config.Clusters[clusterName]; ok && len(cfg.Connections) > 0 {
+		if machineConnectionIndex(cfg.Connections, minfo) == -1 {
+			return false, nil
+		}
+	}
+
+	machines, err := machineClient.ListMachines(ctx)
+	if err != nil {
+		return false, fmt.Errorf("list machines: %w", err)
+	}
+	for _, m := range machines {
+		if m.Machine.Id != minfo.Id {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (cli *CLI) promptResetMachine() error {
 	var confirm bool
 	form := huh.NewForm(
@@ -2,8 +2,23 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	dockerclient "github.com/docker/docker/client"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"slices"
+	"strings"
+	"time"
+	"uncloud/internal/api"
 	"uncloud/internal/machine/api/pb"
+	machinedocker "uncloud/internal/machine/docker"
+	"uncloud/internal/secret"
 )
 
 func (cli *Client) ListMachines(ctx context.Context) ([]*pb.MachineMember, error) {
@@ -13,3 +28,330 @@ func (cli *Client) ListMachines(ctx context.Context) ([]*pb.MachineMember, error
 	}
 	return resp.Machines, nil
 }
+
+// machineStatusTimeout is the maximum time to wait for a response from a machine when collecting the cluster status.
+const machineStatusTimeout = 5 * time.Second
+
+// ClusterStatus is an overview of the health of the cluster.
+type ClusterStatus struct {
+	Machines []MachineStatus
+	// Services is the number of services in the cluster.
+	Services int
+}
+
+// MachineStatus is the status of a machine in the cluster.
+type MachineStatus struct {
+	Member *pb.MachineMember
+	// Reachable is true if the machine API responded through the cluster network.
+	Reachable bool
+	// Err is the error that made the machine unreachable.
+	Err error
+	// StoreLagging is true if the cluster store on the machine hasn't yet applied all the changes that have been
+	// applied by the stores on other machines.
+	StoreLagging bool
+	// Containers is the number of running service containers on the machine.
+	Containers int
+}
+
+// ClusterStatus collects the status of every machine in the cluster: its membership state, whether its API is
+// reachable, whether its cluster store is in sync, and the number of running service containers. A machine store
+// is lagging if it has applied a lower version of the changes from any site than the minimum version required to
+// catch up with the most up-to-date reachable machine.
+func (cli *Client) ClusterStatus(ctx context.Context) (*ClusterStatus, error) {
+	machines, err := cli.ListMachines(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list machines: %w", err)
+	}
+	services, err := cli.ListServices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list services: %w", err)
+	}
+
+	containers := make(map[string]int)
+	for _, svc := range services {
+		for _, mc := range svc.Containers {
+			if mc.Container.State == "running" {
+				containers[mc.MachineID]++
+			}
+		}
+	}
+
+	status := &ClusterStatus{
+		Machines: make([]MachineStatus, len(machines)),
+		Services: len(services),
+	}
+	siteVersions := make([]map[string]int64, len(machines))
+	// minVersions are the versions of the changes from each site that every store must apply to be in sync.
+	minVersions := make(map[string]int64)
+	for i, m := range machines {
+		ms := MachineStatus{Member: m, Containers: containers[m.Machine.Id]}
+		if m.State == pb.MachineMember_DOWN {
+			ms.Err = errors.New("machine is down")
+			status.Machines[i] = ms
+			continue
+		}
+
+		machineCtx, cancel := context.WithTimeout(
+			metadata.NewOutgoingContext(ctx, machineCtxMetadata(m.Machine)), machineStatusTimeout)
+		resp, err := cli.ClusterClient.StoreVersion(machineCtx, &emptypb.Empty{})
+		cancel()
+		if err != nil {
+			ms.Err = err
+			status.Machines[i] = ms
+			continue
+		}
+
+		ms.Reachable = true
+		siteVersions[i] = resp.SiteVersions
+		for site, v := range resp.SiteVersions {
+			minVersions[site] = max(minVersions[site], v)
+		}
+		status.Machines[i] = ms
+	}
+
+	for i, versions := range siteVersions {
+		if versions == nil {
+			continue
+		}
+		for site, v := range minVersions {
+			if versions[site] < v {
+				status.Machines[i].StoreLagging = true
+				break
+			}
+		}
+	}
+	return status, nil
+}
+
+// DrainMachine cordons the machine identified by its name or ID and moves its service containers off it.
+// Containers of replicated services are spread across the available machines and then removed. Containers
+// of global services are removed as the services already run on the other machines. Data in the volumes of
+// the moved containers is not copied.
+func (cli *Client) DrainMachine(ctx context.Context, nameOrID string) error {
+	machines, err := cli.ListMachines(ctx)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+	var m *pb.MachineInfo
+	var others []*pb.MachineMember
+	for _, mm := range machines {
+		if m == nil && (mm.Machine.Id == nameOrID || mm.Machine.Name == nameOrID) {
+			m = mm.Machine
+			continue
+		}
+		others = append(others, mm)
+	}
+	if m == nil {
+		return fmt.Errorf("machine %q not found", nameOrID)
+	}
+
+	machineCtx := metadata.NewOutgoingContext(ctx, machineCtxMetadata(m))
+	machineContainers, err := cli.ListContainers(machineCtx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", api.LabelServiceID)),
+	})
+	if err != nil {
+		return fmt.Errorf("list containers on machine '%s': %w", m.Name, err)
+	}
+	var containers []types.Container
+	for _, mc := range machineContainers {
+		containers = append(containers, mc.Containers...)
+	}
+
+	// Find the containers already moved by a previous drain that failed to remove the originals to not create
+	// duplicate replicas when re-running it.
+	moved, err := cli.movedContainers(ctx, others)
+	if err != nil {
+		return err
+	}
+	// Check there are machines to move the replicated service containers to before making any changes.
+	targets, err := drainTargets(containers, others, moved)
+	if err != nil {
+		return fmt.Errorf("drain machine '%s': %w", m.Name, err)
+	}
+
+	cordoned := true
+	if _, err = cli.UpdateMachine(ctx, &pb.UpdateMachineRequest{Machine: m.Id, Cordoned: &cordoned}); err != nil {
+		return fmt.Errorf("cordon machine: %w", err)
+	}
+
+	var globalServices []string
+	err = progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		var errs error
+		for _, c := range containers {
+			if c.Labels[api.LabelServiceMode] == api.ServiceModeGlobal {
+				if !slices.Contains(globalServices, c.Labels[api.LabelServiceName]) {
+					globalServices = append(globalServices, c.Labels[api.LabelServiceName])
+				}
+			} else if dst, ok := targets[c.ID]; ok {
+				if err := cli.moveContainer(ctx, m, c.ID, dst); err != nil {
+					errs = errors.Join(errs, fmt.Errorf("move container '%s': %w", c.ID, err))
+					continue
+				}
+			}
+
+			pw := progress.ContextWriter(ctx)
+			eventID := fmt.Sprintf("Container %s on %s", containerName(c), m.Name)
+			pw.Event(progress.RemovingEvent(eventID))
+			// TODO: gracefully stop the container before removing it without force.
+			err := cli.RemoveContainer(metadata.NewOutgoingContext(ctx, machineCtxMetadata(m)), c.ID,
+				container.RemoveOptions{Force: true})
+			if err != nil && !dockerclient.IsErrNotFound(err) {
+				pw.Event(progress.ErrorEvent(eventID))
+				errs = errors.Join(errs, fmt.Errorf("remove container '%s': %w", c.ID, err))
+				continue
+			}
+			pw.Event(progress.RemovedEvent(eventID))
+		}
+		return errs
+	}, cli.progressOut(), "Draining machine "+m.Name)
+
+	for _, s := range globalServices {
+		fmt.Printf("WARNING: removed the container of global service '%s' on machine '%s' without a replacement, "+
+			"it keeps running on the other machines.\n", s, m.Name)
+	}
+	return err
+}
+
+// drainTargets returns the machines to move the replicated service containers to, keyed by container ID.
+// Containers that have already been moved are skipped. The containers are spread across the UP machines that can
+// run service containers in a round-robin fashion, or across the SUSPECT ones if there is no UP machine. Machine
+// resources are not taken into account.
+func drainTargets(
+	containers []types.Container, machines []*pb.MachineMember, moved map[string]struct{},
+) (map[string]*pb.MachineInfo, error) {
+	var available []*pb.MachineInfo
+	for _, state := range []pb.MachineMember_MembershipState{pb.MachineMember_UP, pb.MachineMember_SUSPECT} {
+		for _, mm := range machines {
+			if mm.State == state && mm.Machine.RunsWorkloads() {
+				available = append(available, mm.Machine)
+			}
+		}
+		if len(available) > 0 {
+			break
+		}
+	}
+
+	targets := make(map[string]*pb.MachineInfo)
+	for _, c := range containers {
+		if c.Labels[api.LabelServiceMode] == api.ServiceModeGlobal {
+			continue
+		}
+		if _, ok := moved[c.ID]; ok {
+			continue
+		}
+		if len(available) == 0 {
+			return nil, fmt.Errorf("no available machine to move replicated service container '%s' to, "+
+				"add a machine or uncordon one", containerName(c))
+		}
+		targets[c.ID] = available[len(targets)%len(available)]
+	}
+	return targets, nil
+}
+
+// movedContainers returns the IDs of the containers that have been moved to the available machines and replaced
+// by containers labeled with LabelMovedFrom.
+func (cli *Client) movedContainers(ctx context.Context, machines []*pb.MachineMember) (map[string]struct{}, error) {
+	md := metadata.New(nil)
+	for _, mm := range machines {
+		if mm.State == pb.MachineMember_UP || mm.State == pb.MachineMember_SUSPECT {
+			machineIP, _ := mm.Machine.Network.ManagementIp.ToAddr()
+			md.Append("machines", machineIP.String())
+		}
+	}
+	moved := make(map[string]struct{})
+	if len(md.Get("machines")) == 0 {
+		return moved, nil
+	}
+
+	machineContainers, err := cli.ListContainers(metadata.NewOutgoingContext(ctx, md), container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", api.LabelMovedFrom)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list moved containers: %w", err)
+	}
+	for _, mc := range machineContainers {
+		if mc.Metadata != nil && mc.Metadata.Error != "" {
+			return nil, fmt.Errorf("list moved containers on machine '%s': %s",
+				mc.Metadata.Machine, mc.Metadata.Error)
+		}
+		for _, c := range mc.Containers {
+			moved[c.Labels[api.LabelMovedFrom]] = struct{}{}
+		}
+	}
+	return moved, nil
+}
+
+// moveContainer recreates the container from the src machine on the dst machine with the same configuration.
+// The new container is started if the original one is running. The original container is not removed.
+func (cli *Client) moveContainer(ctx context.Context, src *pb.MachineInfo, id string, dst *pb.MachineInfo) error {
+	ctr, err := cli.InspectContainer(metadata.NewOutgoingContext(ctx, machineCtxMetadata(src)), id)
+	if err != nil {
+		return fmt.Errorf("inspect container: %w", err)
+	}
+
+	suffix, err := secret.RandomAlphaNumeric(4)
+	if err != nil {
+		return fmt.Errorf("generate random suffix: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s", ctr.Config.Labels[api.LabelServiceName], suffix)
+	config := ctr.Config
+	// Docker sets the hostname to the container ID by default, let it set a new one for the new container.
+	config.Hostname = ""
+	config.Labels[api.LabelMovedFrom] = ctr.ID
+	// Keep the original desired state so that the machine doesn't start the moved container on its own
+	// if the original one is stopped.
+	config.Labels[api.LabelDesiredState] = api.DesiredStateCreated
+	if ctr.State != nil && ctr.State.Running {
+		config.Labels[api.LabelDesiredState] = api.DesiredStateRunning
+	}
+	netConfig := &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			machinedocker.NetworkName: {},
+		},
+	}
+
+	dstCtx := metadata.NewOutgoingContext(ctx, machineCtxMetadata(dst))
+	pw := progress.ContextWriter(ctx)
+	eventID := fmt.Sprintf("Container %s on %s", name, dst.Name)
+
+	pw.Event(progress.CreatingEvent(eventID))
+	resp, err := cli.CreateContainer(dstCtx, config, ctr.HostConfig, netConfig, nil, name)
+	if err != nil {
+		if !dockerclient.IsErrNotFound(err) {
+			return fmt.Errorf("create container: %w", err)
+		}
+		// Pull the missing image and create the container again.
+		if err = cli.pullImageWithProgress(dstCtx, config.Image, dst.Name, eventID); err != nil {
+			return fmt.Errorf("pull image: %w", err)
+		}
+		if resp, err = cli.CreateContainer(dstCtx, config, ctr.HostConfig, netConfig, nil, name); err != nil {
+			return fmt.Errorf("create container: %w", err)
+		}
+	}
+	pw.Event(progress.CreatedEvent(eventID))
+
+	if ctr.State != nil && ctr.State.Running {
+		pw.Event(progress.StartingEvent(eventID))
+		if err = cli.StartContainer(dstCtx, resp.ID, container.StartOptions{}); err != nil {
+			return fmt.Errorf("start container: %w", err)
+		}
+		pw.Event(progress.StartedEvent(eventID))
+	}
+	return nil
+}
+
+// machineCtxMetadata returns the gRPC metadata that proxies the requests to the machine.
+func machineCtxMetadata(m *pb.MachineInfo) metadata.MD {
+	machineIP, _ := m.Network.ManagementIp.ToAddr()
+	return metadata.Pairs("machines", machineIP.String())
+}
+
+// containerName returns the container name without the leading slash or the short ID if it has no name.
+func containerName(c types.Container) string {
+	if len(c.Names) > 0 {
+		return strings.TrimPrefix(c.Names[0], "/")
+	}
+	return c.ID[:12]
+}
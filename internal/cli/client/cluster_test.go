@@ -0,0 +1,109 @@
+package client
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"uncloud/internal/api"
+	"uncloud/internal/machine/api/pb"
+)
+
+func TestDrainTargets(t *testing.T) {
+	t.Parallel()
+
+	replicated := func(id string) types.Container {
+		return types.Container{ID: id, Names: []string{"/" + id}, Labels: map[string]string{
+			api.LabelServiceMode: api.ServiceModeReplicated,
+		}}
+	}
+	global := func(id string) types.Container {
+		return types.Container{ID: id, Names: []string{"/" + id}, Labels: map[string]string{
+			api.LabelServiceMode: api.ServiceModeGlobal,
+		}}
+	}
+	machine := func(id string, state pb.MachineMember_MembershipState, cordoned bool) *pb.MachineMember {
+		return &pb.MachineMember{Machine: &pb.MachineInfo{Id: id, Cordoned: cordoned}, State: state}
+	}
+
+	tests := []struct {
+		name       string
+		containers []types.Container
+		machines   []*pb.MachineMember
+		moved      map[string]struct{}
+		want       map[string]string
+		wantErr    string
+	}{
+		{
+			name:       "spread across UP machines",
+			containers: []types.Container{replicated("c1"), replicated("c2"), replicated("c3")},
+			machines: []*pb.MachineMember{
+				machine("m1", pb.MachineMember_UP, false),
+				machine("m2", pb.MachineMember_SUSPECT, false),
+				machine("m3", pb.MachineMember_UP, false),
+			},
+			want: map[string]string{"c1": "m1", "c2": "m3", "c3": "m1"},
+		},
+		{
+			name:       "skip cordoned and down machines",
+			containers: []types.Container{replicated("c1"), replicated("c2")},
+			machines: []*pb.MachineMember{
+				machine("m1", pb.MachineMember_UP, true),
+				machine("m2", pb.MachineMember_DOWN, false),
+				machine("m3", pb.MachineMember_UP, false),
+			},
+			want: map[string]string{"c1": "m3", "c2": "m3"},
+		},
+		{
+			name:       "SUSPECT machines if no UP machine",
+			containers: []types.Container{replicated("c1"), replicated("c2")},
+			machines: []*pb.MachineMember{
+				machine("m1", pb.MachineMember_SUSPECT, false),
+				machine("m2", pb.MachineMember_DOWN, false),
+				machine("m3", pb.MachineMember_SUSPECT, false),
+			},
+			want: map[string]string{"c1": "m1", "c2": "m3"},
+		},
+		{
+			name:       "skip global and moved containers",
+			containers: []types.Container{global("c1"), replicated("c2"), replicated("c3")},
+			machines:   []*pb.MachineMember{machine("m1", pb.MachineMember_UP, false)},
+			moved:      map[string]struct{}{"c2": {}},
+			want:       map[string]string{"c3": "m1"},
+		},
+		{
+			name:       "only global containers without available machines",
+			containers: []types.Container{global("c1")},
+			machines:   []*pb.MachineMember{machine("m1", pb.MachineMember_DOWN, false)},
+			want:       map[string]string{},
+		},
+		{
+			name:       "no available machine",
+			containers: []types.Container{global("c1"), replicated("c2")},
+			machines: []*pb.MachineMember{
+				machine("m1", pb.MachineMember_UP, true),
+				machine("m2", pb.MachineMember_DOWN, false),
+			},
+			wantErr: "no available machine to move replicated service container 'c2' to",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			targets, err := drainTargets(tt.containers, tt.machines, tt.moved)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			got := make(map[string]string)
+			for id, m := range targets {
+				got[id] = m.Id
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package connector
+
+import (
+	"google.golang.org/grpc"
+	"uncloud/internal/machine/api/pb"
+)
+
+// maxMessageSizeOption returns the dial option that limits the size of gRPC messages the client can send or receive
+// to maxMsgSize bytes. pb.DefaultMaxMessageSize is used if maxMsgSize is not positive. It must not exceed the limit
+// configured on the machine daemon with --grpc-max-message-size.
+func maxMessageSizeOption(maxMsgSize int) grpc.DialOption {
+	if maxMsgSize <= 0 {
+		maxMsgSize = pb.DefaultMaxMessageSize
+	}
+	return grpc.WithDefaultCallOptions(
+		grpc.MaxCallRecvMsgSize(maxMsgSize),
+		grpc.MaxCallSendMsgSize(maxMsgSize),
+	)
+}
@@ -19,6 +19,9 @@ type SSHConnectorConfig struct {
 	KeyPath string
 
 	SockPath string
+	// MaxMessageSize is the maximum size in bytes of a gRPC message the client can send or receive.
+	// Default is pb.DefaultMaxMessageSize.
+	MaxMessageSize int
 }
 
 // SSHConnector establishes a connection to the machine API through an SSH tunnel to the machine.
@@ -71,6 +74,7 @@ func (c *SSHConnector) Connect(ctx context.Context) (*grpc.ClientConn, error) {
 				return conn, nil
 			},
 		),
+		maxMessageSizeOption(c.config.MaxMessageSize),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("create machine API client: %w", err)
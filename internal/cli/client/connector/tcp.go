@@ -10,17 +10,21 @@ import (
 
 // TCPConnector establishes a connection to the machine API through a direct TCP connection to an API endpoint.
 type TCPConnector struct {
-	apiAddr netip.AddrPort
+	apiAddr    netip.AddrPort
+	maxMsgSize int
 }
 
-func NewTCPConnector(apiAddr netip.AddrPort) *TCPConnector {
-	return &TCPConnector{apiAddr: apiAddr}
+// NewTCPConnector creates a connector to the machine API endpoint. maxMsgSize limits the size of gRPC messages
+// in bytes, pb.DefaultMaxMessageSize is used if zero.
+func NewTCPConnector(apiAddr netip.AddrPort, maxMsgSize int) *TCPConnector {
+	return &TCPConnector{apiAddr: apiAddr, maxMsgSize: maxMsgSize}
 }
 
 func (c *TCPConnector) Connect(_ context.Context) (*grpc.ClientConn, error) {
 	conn, err := grpc.NewClient(
 		c.apiAddr.String(),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		maxMessageSizeOption(c.maxMsgSize),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("create machine API client: %w", err)
@@ -20,13 +20,18 @@ import (
 type WireGuardConnector struct {
 	user     *client.User
 	machines []config.MachineConnection
-	tun      *tunnel.Tunnel
+	// maxMsgSize is the maximum size in bytes of a gRPC message the client can send or receive.
+	maxMsgSize int
+	tun        *tunnel.Tunnel
 }
 
-func NewWireGuardConnector(user *client.User, machines []config.MachineConnection) *WireGuardConnector {
+func NewWireGuardConnector(
+	user *client.User, machines []config.MachineConnection, maxMsgSize int,
+) *WireGuardConnector {
 	return &WireGuardConnector{
-		user:     user,
-		machines: machines,
+		user:       user,
+		machines:   machines,
+		maxMsgSize: maxMsgSize,
 	}
 }
 
@@ -67,6 +72,7 @@ func (c *WireGuardConnector) Connect(ctx context.Context) (*grpc.ClientConn, err
 		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
 			return c.tun.DialContext(ctx, "tcp", addr)
 		}),
+		maxMessageSizeOption(c.maxMsgSize),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("connect to machine API through WireGuard tunnel: %w", err)
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/distribution/reference"
@@ -15,12 +16,16 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"io"
 	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 	"uncloud/internal/api"
 	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/caddyfile"
 	machinedocker "uncloud/internal/machine/docker"
 	"uncloud/internal/secret"
 )
@@ -36,16 +41,74 @@ type MachineContainerID struct {
 	ContainerID string
 }
 
+// RunService creates and starts containers for a new service on the cluster machines according to the spec.
 func (cli *Client) RunService(ctx context.Context, spec api.ServiceSpec) (RunServiceResponse, error) {
-	var resp RunServiceResponse
+	serviceID, spec, err := cli.prepareNewService(ctx, spec)
+	if err != nil {
+		return RunServiceResponse{}, err
+	}
+	return cli.deployService(ctx, serviceID, spec)
+}
+
+// CreateService stores the desired spec of a new service in the cluster without creating any containers.
+// The created service can be reviewed and then started with StartService. The returned response has no containers.
+func (cli *Client) CreateService(ctx context.Context, spec api.ServiceSpec) (RunServiceResponse, error) {
+	serviceID, spec, err := cli.prepareNewService(ctx, spec)
+	if err != nil {
+		return RunServiceResponse{}, err
+	}
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return RunServiceResponse{}, fmt.Errorf("marshal service spec: %w", err)
+	}
+	req := &pb.ServiceSpecRecord{Id: serviceID, Name: spec.Name, Spec: specJSON}
+	if _, err = cli.ClusterClient.CreateServiceSpec(ctx, req); err != nil {
+		if s, ok := status.FromError(err); ok && s.Code() == codes.AlreadyExists {
+			return RunServiceResponse{}, fmt.Errorf("service with name '%s' already exists", spec.Name)
+		}
+		return RunServiceResponse{}, fmt.Errorf("create service spec: %w", err)
+	}
 
+	return RunServiceResponse{ID: serviceID, Name: spec.Name}, nil
+}
+
+// checkIngressPorts returns an error if the cluster ingress can't route traffic for any of the ports published
+// in ingress mode.
+func (cli *Client) checkIngressPorts(ctx context.Context, ports []api.PortSpec) error {
+	// Only query the cluster ingress if there are ports that may not be supported by it.
+	if !slices.ContainsFunc(ports, func(p api.PortSpec) bool {
+		return caddyfile.ValidateIngressPort(caddyfile.IngressCaddy, p) != nil
+	}) {
+		return nil
+	}
+
+	resp, err := cli.ClusterClient.Ingress(ctx, &emptypb.Empty{})
+	if err != nil {
+		return fmt.Errorf("get cluster ingress: %w", err)
+	}
+	for _, p := range ports {
+		if err = caddyfile.ValidateIngressPort(resp.Ingress, p); err != nil {
+			return fmt.Errorf("invalid port %d/%s: %w", p.ContainerPort, p.Protocol, err)
+		}
+	}
+	return nil
+}
+
+// prepareNewService validates the spec of a new service, generates its name if not specified, and hashes
+// the basic auth passwords. It returns a new service ID and the prepared spec.
+func (cli *Client) prepareNewService(ctx context.Context, spec api.ServiceSpec) (string, api.ServiceSpec, error) {
 	if err := spec.Validate(); err != nil {
-		return resp, fmt.Errorf("invalid service spec: %w", err)
+		return "", spec, fmt.Errorf("invalid service spec: %w", err)
 	}
 
 	img, err := reference.ParseDockerRef(spec.Container.Image)
 	if err != nil {
-		return resp, fmt.Errorf("invalid image: %w", err)
+		return "", spec, fmt.Errorf("invalid image: %w", err)
+	}
+
+	if err = cli.checkIngressPorts(ctx, spec.Ports); err != nil {
+		return "", spec, err
 	}
 
 	if spec.Name == "" {
@@ -59,26 +122,53 @@ func (cli *Client) RunService(ctx context.Context, spec api.ServiceSpec) (RunSer
 		// Append a random suffix to the image name to generate an optimistically unique service name.
 		suffix, err := secret.RandomAlphaNumeric(4)
 		if err != nil {
-			return resp, fmt.Errorf("generate random suffix: %w", err)
+			return "", spec, fmt.Errorf("generate random suffix: %w", err)
 		}
 		spec.Name = fmt.Sprintf("%s-%s", imageName, suffix)
 	} else {
-		// Optimistically check if a service with the specified name already exists.
+		// Optimistically check if a service or a service spec with the specified name already exists.
 		_, err := cli.InspectService(ctx, spec.Name)
 		if err == nil {
-			return resp, fmt.Errorf("service with name '%s' already exists", spec.Name)
+			return "", spec, fmt.Errorf("service with name '%s' already exists", spec.Name)
 		}
 		if !errors.Is(err, ErrNotFound) {
-			return resp, fmt.Errorf("inspect service: %w", err)
+			return "", spec, fmt.Errorf("inspect service: %w", err)
+		}
+		_, err = cli.ClusterClient.GetServiceSpec(ctx, &pb.GetServiceSpecRequest{Service: spec.Name})
+		if err == nil {
+			return "", spec, fmt.Errorf("service with name '%s' already exists", spec.Name)
+		}
+		if s, ok := status.FromError(err); !ok || s.Code() != codes.NotFound {
+			return "", spec, fmt.Errorf("get service spec: %w", err)
 		}
 	}
 
 	serviceID, err := secret.NewID()
 	if err != nil {
-		return resp, fmt.Errorf("generate service ID: %w", err)
+		return "", spec, fmt.Errorf("generate service ID: %w", err)
 	}
 
-	err = progress.RunWithTitle(ctx, func(ctx context.Context) error {
+	// Hash basic auth passwords once for all containers. Copy the users to not modify the caller's spec.
+	basicAuth := make([]api.BasicAuthUser, len(spec.BasicAuth))
+	for i, u := range spec.BasicAuth {
+		if err = u.HashPassword(); err != nil {
+			return "", spec, err
+		}
+		basicAuth[i] = u
+	}
+	spec.BasicAuth = basicAuth
+
+	return serviceID, spec, nil
+}
+
+// deployService creates and starts containers for the service with the given ID on the cluster machines according
+// to the spec.
+func (cli *Client) deployService(
+	ctx context.Context, serviceID string, spec api.ServiceSpec,
+) (RunServiceResponse, error) {
+	var resp RunServiceResponse
+	err := progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		var err error
 		switch spec.Mode {
 		case "", api.ServiceModeReplicated:
 			resp, err = cli.runReplicatedService(ctx, serviceID, spec)
@@ -94,7 +184,9 @@ func (cli *Client) RunService(ctx context.Context, spec api.ServiceSpec) (RunSer
 	return resp, err
 }
 
-func (cli *Client) runReplicatedService(ctx context.Context, id string, spec api.ServiceSpec) (RunServiceResponse, error) {
+func (cli *Client) runReplicatedService(
+	ctx context.Context, id string, spec api.ServiceSpec,
+) (RunServiceResponse, error) {
 	resp := RunServiceResponse{
 		ID:   id,
 		Name: spec.Name,
@@ -121,11 +213,27 @@ func (cli *Client) runReplicatedService(ctx context.Context, id string, spec api
 	//	}
 	//}
 
+	if spec.Container.Runtime != "" {
+		if machines, err = cli.filterMachinesWithRuntime(ctx, machines, spec.Container.Runtime); err != nil {
+			return resp, err
+		}
+	}
+
 	m := firstAvailableMachine(machines)
 	if m == nil {
+		if spec.Container.Runtime != "" {
+			return resp, fmt.Errorf("no available machine with runtime '%s' to run the service",
+				spec.Container.Runtime)
+		}
 		return resp, errors.New("no available machine to run the service")
 	}
 
+	if spec.Container.PullPolicy == api.PullPolicyAlways {
+		if err = cli.pullImageOnMachines(ctx, spec.Container.Image, []*pb.MachineInfo{m.Machine}); err != nil {
+			return resp, err
+		}
+	}
+
 	runResp, err := cli.runContainer(ctx, id, spec, m.Machine)
 	if err != nil {
 		return resp, fmt.Errorf("run container: %w", err)
@@ -139,16 +247,18 @@ func (cli *Client) runReplicatedService(ctx context.Context, id string, spec api
 	return resp, nil
 }
 
+// firstAvailableMachine returns the first UP or, if there is none, SUSPECT machine that can run service containers.
+// Control-plane machines are skipped.
 func firstAvailableMachine(machines []*pb.MachineMember) *pb.MachineMember {
 	// Find the first UP machine.
 	for _, m := range machines {
-		if m.State == pb.MachineMember_UP {
+		if m.State == pb.MachineMember_UP && m.Machine.RunsWorkloads() {
 			return m
 		}
 	}
 	// There is no UP machine, try to find the first SUSPECT machine.
 	for _, m := range machines {
-		if m.State == pb.MachineMember_SUSPECT {
+		if m.State == pb.MachineMember_SUSPECT && m.Machine.RunsWorkloads() {
 			return m
 		}
 	}
@@ -156,7 +266,41 @@ func firstAvailableMachine(machines []*pb.MachineMember) *pb.MachineMember {
 	return nil
 }
 
-func (cli *Client) runGlobalService(ctx context.Context, id string, spec api.ServiceSpec) (RunServiceResponse, error) {
+// runsGlobalService returns true if a container of the global service can be placed on the machine. Control-plane
+// machines only run the service if it explicitly opts in. Cordoned machines never accept new containers.
+func runsGlobalService(m *pb.MachineInfo, spec api.ServiceSpec) bool {
+	if spec.ControlPlane && m.MachineRole() == pb.MachineRoleControlPlane {
+		return !m.Cordoned
+	}
+	return m.RunsWorkloads()
+}
+
+// filterMachinesWithRuntime returns the machines which Docker daemon has the given OCI runtime configured.
+func (cli *Client) filterMachinesWithRuntime(
+	ctx context.Context, machines []*pb.MachineMember, runtime string,
+) ([]*pb.MachineMember, error) {
+	var filtered []*pb.MachineMember
+	for _, m := range machines {
+		if m.State == pb.MachineMember_DOWN {
+			continue
+		}
+		machineIP, _ := m.Machine.Network.ManagementIp.ToAddr()
+		infoCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("machines", machineIP.String()))
+
+		info, err := cli.DockerClient.Info(infoCtx)
+		if err != nil {
+			return nil, fmt.Errorf("get Docker info on machine '%s': %w", m.Machine.Name, err)
+		}
+		if _, ok := info.Runtimes[runtime]; ok {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered, nil
+}
+
+func (cli *Client) runGlobalService(
+	ctx context.Context, id string, spec api.ServiceSpec,
+) (RunServiceResponse, error) {
 	resp := RunServiceResponse{
 		ID:   id,
 		Name: spec.Name,
@@ -167,31 +311,71 @@ func (cli *Client) runGlobalService(ctx context.Context, id string, spec api.Ser
 		return resp, fmt.Errorf("list machines: %w", err)
 	}
 
-	wg := sync.WaitGroup{}
-	errCh := make(chan error)
-	mu := sync.Mutex{}
+	if spec.Container.Runtime != "" {
+		// Fail before creating any containers if some of the machines can't run the service.
+		var available []*pb.MachineMember
+		for _, m := range machines {
+			if runsGlobalService(m.Machine, spec) &&
+				(m.State == pb.MachineMember_UP || m.State == pb.MachineMember_SUSPECT) {
+				available = append(available, m)
+			}
+		}
+		withRuntime, err := cli.filterMachinesWithRuntime(ctx, available, spec.Container.Runtime)
+		if err != nil {
+			return resp, err
+		}
+		if len(withRuntime) != len(available) {
+			var missing []string
+			for _, m := range available {
+				if !slices.Contains(withRuntime, m) {
+					missing = append(missing, m.Machine.Name)
+				}
+			}
+			return resp, fmt.Errorf("runtime '%s' is not available on machines: %s",
+				spec.Container.Runtime, strings.Join(missing, ", "))
+		}
+	}
 
-	// Run a service container on each available machine.
+	// Run a service container on each available machine except control-plane machines unless the service opts in.
+	var targets []*pb.MachineInfo
 	for _, m := range machines {
+		if !runsGlobalService(m.Machine, spec) {
+			continue
+		}
 		if m.State != pb.MachineMember_UP && m.State != pb.MachineMember_SUSPECT {
 			// TODO: return failed machines in the response.
 			fmt.Printf("WARNING: failed to run a service container on machine '%s' which is Down.\n", m.Machine.Name)
 			continue
 		}
+		targets = append(targets, m.Machine)
+	}
+
+	if spec.Container.PullPolicy == api.PullPolicyAlways {
+		// Pull the image on all machines before creating any containers so that the containers are started
+		// at about the same time rather than waiting for slow pulls one by one.
+		if err = cli.pullImageOnMachines(ctx, spec.Container.Image, targets); err != nil {
+			return resp, err
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	errCh := make(chan error)
+	mu := sync.Mutex{}
 
+	for _, m := range targets {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 
-			runResp, err := cli.runContainer(ctx, id, spec, m.Machine)
+			runResp, err := cli.runContainer(ctx, id, spec, m)
 			if err != nil {
-				errCh <- fmt.Errorf("run container on machine '%s': %w", m.Machine.Name, err)
+				errCh <- fmt.Errorf("run container on machine '%s': %w", m.Name, err)
 				return
 			}
 
 			mu.Lock()
 			resp.Containers = append(resp.Containers, MachineContainerID{
-				MachineID:   m.Machine.Id,
+				MachineID:   m.Id,
 				ContainerID: runResp.ID,
 			})
 			mu.Unlock()
@@ -228,17 +412,66 @@ func (cli *Client) runContainer(
 	containerName := fmt.Sprintf("%s-%s", spec.Name, suffix)
 
 	config := &container.Config{
-		Cmd:   spec.Container.Command,
-		Image: spec.Container.Image,
-		Labels: map[string]string{
-			api.LabelServiceID:   serviceID,
-			api.LabelServiceName: spec.Name,
-			api.LabelManaged:     "",
-		},
+		Cmd:    spec.Container.Command,
+		Env:    spec.Container.Env.ToSlice(),
+		Image:  spec.Container.Image,
+		Labels: make(map[string]string, len(spec.Container.Labels)+3),
+	}
+	// Custom labels can't override the reserved Uncloud labels set below as validated by the spec.
+	for k, v := range spec.Container.Labels {
+		config.Labels[k] = v
 	}
+	config.Labels[api.LabelServiceID] = serviceID
+	config.Labels[api.LabelServiceName] = spec.Name
+	config.Labels[api.LabelManaged] = ""
+	config.Labels[api.LabelDesiredState] = api.DesiredStateRunning
 	if spec.Mode == api.ServiceModeGlobal {
 		config.Labels[api.LabelServiceMode] = api.ServiceModeGlobal
 	}
+	if spec.StickySessions {
+		config.Labels[api.LabelServiceStickySessions] = ""
+	}
+	if spec.HTTPSRedirect {
+		config.Labels[api.LabelServiceHTTPSRedirect] = ""
+	}
+	if spec.IngressHealthCheck != nil {
+		config.Labels[api.LabelServiceIngressHealthPath] = spec.IngressHealthCheck.Path
+		if spec.IngressHealthCheck.Interval != 0 {
+			config.Labels[api.LabelServiceIngressHealthInterval] = spec.IngressHealthCheck.Interval.String()
+		}
+	}
+	if len(spec.BasicAuth) > 0 {
+		users := make([]string, len(spec.BasicAuth))
+		for i, u := range spec.BasicAuth {
+			users[i] = u.Username + ":" + u.PasswordHash
+		}
+		config.Labels[api.LabelServiceBasicAuth] = strings.Join(users, ",")
+	}
+	if hc := spec.Container.Healthcheck; hc != nil {
+		if hc.Disable {
+			// Override the healthcheck defined in the image.
+			config.Healthcheck = &container.HealthConfig{Test: []string{"NONE"}}
+		} else {
+			config.Healthcheck = &container.HealthConfig{
+				Interval:    hc.Interval,
+				Timeout:     hc.Timeout,
+				Retries:     hc.Retries,
+				StartPeriod: hc.StartPeriod,
+			}
+			// An empty test inherits the test command defined in the image.
+			if hc.Test != "" {
+				config.Healthcheck.Test = []string{"CMD-SHELL", hc.Test}
+			}
+		}
+	}
+	if spec.Container.StopGracePeriod != nil {
+		// Round up to whole seconds to never shorten the requested grace period.
+		stopTimeout := int((*spec.Container.StopGracePeriod + time.Second - 1) / time.Second)
+		config.StopTimeout = &stopTimeout
+	}
+	if spec.Container.StopSignal != "" {
+		config.StopSignal = spec.Container.StopSignal
+	}
 
 	if len(spec.Ports) > 0 {
 		encodedPorts := make([]string, len(spec.Ports))
@@ -257,20 +490,38 @@ func (cli *Client) runContainer(
 		if p.Mode != api.PortModeHost {
 			continue
 		}
-		port := nat.Port(fmt.Sprintf("%d/%s", p.ContainerPort, p.Protocol))
-		portBindings[port] = []nat.PortBinding{
-			{
-				HostPort: strconv.Itoa(int(p.PublishedPort)),
-			},
-		}
-		if p.HostIP.IsValid() {
-			portBindings[port][0].HostIP = p.HostIP.String()
+		// Expand port ranges into a binding for each port.
+		for i := 0; i < p.Len(); i++ {
+			port := nat.Port(fmt.Sprintf("%d/%s", int(p.ContainerPort)+i, p.Protocol))
+			portBindings[port] = []nat.PortBinding{
+				{
+					HostPort: strconv.Itoa(int(p.PublishedPort) + i),
+				},
+			}
+			if p.HostIP.IsValid() {
+				portBindings[port][0].HostIP = p.HostIP.String()
+			}
 		}
 	}
 	hostConfig := &container.HostConfig{
-		Binds:        spec.Container.Volumes,
-		Init:         spec.Container.Init,
-		PortBindings: portBindings,
+		Binds:          spec.Container.Volumes,
+		CapAdd:         spec.Container.CapAdd,
+		CapDrop:        spec.Container.CapDrop,
+		ExtraHosts:     spec.Container.ExtraHosts,
+		Init:           spec.Container.Init,
+		PortBindings:   portBindings,
+		ReadonlyRootfs: spec.Container.ReadOnlyRootfs,
+		Runtime:        spec.Container.Runtime,
+		Sysctls:        spec.Container.Sysctls,
+		Resources: container.Resources{
+			PidsLimit: spec.Container.PidsLimit,
+		},
+	}
+	for _, u := range spec.Container.Ulimits {
+		hostConfig.Ulimits = append(hostConfig.Ulimits, u.DockerUlimit())
+	}
+	if spec.Container.RestartPolicy != nil {
+		hostConfig.RestartPolicy = spec.Container.RestartPolicy.DockerRestartPolicy()
 	}
 	netConfig := &network.NetworkingConfig{
 		EndpointsConfig: map[string]*network.EndpointSettings{
@@ -287,6 +538,10 @@ func (cli *Client) runContainer(
 		if !dockerclient.IsErrNotFound(err) {
 			return resp, fmt.Errorf("create container: %w", err)
 		}
+		if spec.Container.PullPolicy == api.PullPolicyNever {
+			return resp, fmt.Errorf("image '%s' not found on machine '%s' and pull policy is '%s': %w",
+				config.Image, machine.Name, api.PullPolicyNever, err)
+		}
 
 		// Pull the missing image and create the container again.
 		if err = cli.pullImageWithProgress(ctx, config.Image, machine.Name, eventID); err != nil {
@@ -307,6 +562,34 @@ func (cli *Client) runContainer(
 	return resp, nil
 }
 
+// pullImageOnMachines pulls the image on the machines concurrently showing the pull progress for each machine.
+// Docker only downloads the layers missing on a machine so the pull is quick if the machine has the image already.
+func (cli *Client) pullImageOnMachines(ctx context.Context, image string, machines []*pb.MachineInfo) error {
+	wg := sync.WaitGroup{}
+	errCh := make(chan error, len(machines))
+
+	for _, m := range machines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			machineIP, _ := m.Network.ManagementIp.ToAddr()
+			pullCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("machines", machineIP.String()))
+			if err := cli.pullImageWithProgress(pullCtx, image, m.Name, ""); err != nil {
+				errCh <- fmt.Errorf("pull image on machine '%s': %w", m.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+
+	var err error
+	for e := range errCh {
+		err = errors.Join(err, e)
+	}
+	return err
+}
+
 func (cli *Client) pullImageWithProgress(ctx context.Context, image, machineName, parentEventID string) error {
 	pw := progress.ContextWriter(ctx)
 	eventID := fmt.Sprintf("Image %s on %s", image, machineName)
@@ -554,12 +837,76 @@ func (cli *Client) InspectServiceFromStore(ctx context.Context, id string) (api.
 	return svc, nil
 }
 
-// RemoveService removes all containers on all machines that belong to the specified service.
-// The id parameter can be either a service ID or name.
+type WatchServiceMessage struct {
+	// Service is the current state of the service. It's empty if the service has been removed.
+	Service api.Service
+	Err     error
+}
+
+// WatchService returns a channel that receives the state of the service from the cluster store every time it
+// changes. The first message contains the current state. The channel is closed after the service is removed,
+// the stream fails, or the context is canceled. The id parameter can be either a service ID or name.
+func (cli *Client) WatchService(ctx context.Context, id string) (<-chan WatchServiceMessage, error) {
+	stream, err := cli.MachineClient.WatchService(ctx, &pb.InspectServiceRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	// Receive the current state before returning to report a missing service as ErrNotFound.
+	resp, err := stream.Recv()
+	if err != nil {
+		if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	ch := make(chan WatchServiceMessage)
+	go func() {
+		defer close(ch)
+
+		for {
+			var msg WatchServiceMessage
+			if resp.Service != nil {
+				msg.Service, msg.Err = api.ServiceFromProto(resp.Service)
+				if msg.Err != nil {
+					msg.Err = fmt.Errorf("from proto: %w", msg.Err)
+				}
+			}
+			select {
+			case ch <- msg:
+			case <-ctx.Done():
+				return
+			}
+			if msg.Err != nil {
+				return
+			}
+
+			if resp, err = stream.Recv(); err != nil {
+				if errors.Is(err, io.EOF) {
+					return
+				}
+				select {
+				case ch <- WatchServiceMessage{Err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// RemoveService removes all containers on all machines that belong to the specified service and its desired spec
+// stored in the cluster if any. The id parameter can be either a service ID or name.
 func (cli *Client) RemoveService(ctx context.Context, id string) error {
 	svc, err := cli.InspectService(ctx, id)
 	if err != nil {
-		return err
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		// The service may have been created but not started yet so it only has a spec.
+		return cli.removeServiceSpec(ctx, id)
 	}
 
 	machines, err := cli.ListMachines(ctx)
@@ -607,7 +954,70 @@ func (cli *Client) RemoveService(ctx context.Context, id string) error {
 	for e := range errCh {
 		err = errors.Join(err, e)
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	if err = cli.removeServiceSpec(ctx, svc.ID); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// removeServiceSpec removes the desired spec of the service from the cluster. It returns ErrNotFound if the service
+// has no spec stored. The id parameter can be either a service ID or name.
+func (cli *Client) removeServiceSpec(ctx context.Context, id string) error {
+	_, err := cli.ClusterClient.DeleteServiceSpec(ctx, &pb.GetServiceSpecRequest{Service: id})
+	if err != nil {
+		if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+			return ErrNotFound
+		}
+		return fmt.Errorf("delete service spec: %w", err)
+	}
+	return nil
+}
+
+// StartService creates and starts containers for the service created with CreateService according to its desired
+// spec stored in the cluster. The spec is kept in the cluster after the service is started. It returns an error if
+// the service already has containers. The id parameter can be either a service ID or name.
+func (cli *Client) StartService(ctx context.Context, id string) (RunServiceResponse, error) {
+	record, err := cli.ClusterClient.GetServiceSpec(ctx, &pb.GetServiceSpecRequest{Service: id})
+	if err != nil {
+		if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+			return RunServiceResponse{}, ErrNotFound
+		}
+		return RunServiceResponse{}, fmt.Errorf("get service spec: %w", err)
+	}
+	var spec api.ServiceSpec
+	if err = json.Unmarshal(record.Spec, &spec); err != nil {
+		return RunServiceResponse{}, fmt.Errorf("unmarshal service spec: %w", err)
+	}
+
+	// Reconcile the desired spec with the actual containers only if the service hasn't been started yet as
+	// the existing containers may have been changed since then, e.g. scaled.
+	if _, err = cli.InspectService(ctx, record.Id); err == nil {
+		return RunServiceResponse{}, fmt.Errorf("service '%s' has already been started", record.Name)
+	} else if !errors.Is(err, ErrNotFound) {
+		return RunServiceResponse{}, fmt.Errorf("inspect service: %w", err)
+	}
+
+	return cli.deployService(ctx, record.Id, spec)
+}
+
+// ListServiceSpecs returns the desired specs of the services stored in the cluster, e.g. created but not started.
+func (cli *Client) ListServiceSpecs(ctx context.Context) ([]api.ServiceSpecRecord, error) {
+	resp, err := cli.ClusterClient.ListServiceSpecs(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	records := make([]api.ServiceSpecRecord, len(resp.Specs))
+	for i, r := range resp.Specs {
+		records[i] = api.ServiceSpecRecord{ID: r.Id}
+		if err = json.Unmarshal(r.Spec, &records[i].Spec); err != nil {
+			return nil, fmt.Errorf("unmarshal spec of service '%s': %w", r.Name, err)
+		}
+	}
+	return records, nil
 }
 
 // ListServices returns a list of all services and their containers.
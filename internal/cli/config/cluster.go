@@ -3,4 +3,8 @@ package config
 type Cluster struct {
 	Name        string              `toml:"-"`
 	Connections []MachineConnection `toml:"connections"`
+	// GRPCMaxMessageSize is the maximum size in bytes of a gRPC message the CLI can send to or receive from
+	// the cluster machines. It should match the --grpc-max-message-size of the machine daemons if raised.
+	// Default is pb.DefaultMaxMessageSize (64 MiB).
+	GRPCMaxMessageSize int `toml:"grpc_max_message_size,omitempty"`
 }
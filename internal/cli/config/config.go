@@ -1,10 +1,14 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/BurntSushi/toml"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"syscall"
 )
 
 type Config struct {
@@ -34,6 +38,11 @@ func NewFromFile(path string) (*Config, error) {
 	return c, nil
 }
 
+// Path returns the file path the config is read from and saved to.
+func (c *Config) Path() string {
+	return c.path
+}
+
 func (c *Config) Read() error {
 	_, err := toml.DecodeFile(c.path, c)
 	if err != nil {
@@ -42,22 +51,140 @@ func (c *Config) Read() error {
 	return nil
 }
 
+// Save writes the config to the file it was read from. If the file is a symlink, the symlink is preserved and
+// its target file is overwritten. The config is written to a temporary file first and then atomically renamed
+// to not leave a partially written config behind. The mode and owner of an existing file are preserved.
+// If the file can't be replaced, e.g. it's a single-file bind mount, it's overwritten in place instead.
 func (c *Config) Save() error {
-	dir, _ := filepath.Split(c.path)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("create config directory %q: %w", dir, err)
+	path, err := c.resolvePath()
+	if err != nil {
+		return err
 	}
 
-	f, err := os.OpenFile(c.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return fmt.Errorf("write config file %q: %w", c.path, err)
+	dir := filepath.Dir(path)
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("create config directory %q: %w", dir, readOnlyHint(err))
 	}
 
-	encoder := toml.NewEncoder(f)
+	var buf bytes.Buffer
+	encoder := toml.NewEncoder(&buf)
 	encoder.Indent = ""
 	if err = encoder.Encode(c); err != nil {
+		return fmt.Errorf("encode config file %q: %w", path, err)
+	}
+
+	existing, err := os.Stat(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("check config file %q: %w", path, err)
+		}
+	}
+	if existing != nil {
+		// Replacing the file only requires write access to the directory. Fail like an in-place write would
+		// to not silently replace a read-only file.
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("write config file %q: %w", path, readOnlyHint(err))
+		}
 		_ = f.Close()
-		return fmt.Errorf("encode config file %q: %w", c.path, err)
+	}
+
+	err = replaceFile(path, buf.Bytes(), existing)
+	if errors.Is(err, errKeepOwner) || errors.Is(err, syscall.EXDEV) || errors.Is(err, syscall.EBUSY) {
+		err = writeFileInPlace(path, buf.Bytes())
+	}
+	if err != nil {
+		return fmt.Errorf("write config file %q: %w", path, readOnlyHint(err))
+	}
+	return nil
+}
+
+// errKeepOwner is returned by replaceFile when the owner of the existing file can't be preserved.
+var errKeepOwner = errors.New("can't preserve file owner")
+
+// replaceFile atomically replaces the file at path with the data by writing it to a temporary file in the same
+// directory and renaming it. The mode and owner of the existing file are preserved if it's not nil.
+func replaceFile(path string, data []byte, existing fs.FileInfo) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Clean up the temporary file if it hasn't been renamed.
+		_ = os.Remove(f.Name())
+	}()
+
+	mode := fs.FileMode(0600)
+	if existing != nil {
+		mode = existing.Mode().Perm()
+		if st, ok := existing.Sys().(*syscall.Stat_t); ok && (int(st.Uid) != os.Geteuid() ||
+			int(st.Gid) != os.Getegid()) {
+			if err = f.Chown(int(st.Uid), int(st.Gid)); err != nil {
+				_ = f.Close()
+				return fmt.Errorf("%w: %w", errKeepOwner, err)
+			}
+		}
+	}
+	if err = f.Chmod(mode); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// writeFileInPlace truncates and overwrites the file at path with the data keeping its mode and owner.
+func writeFileInPlace(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return err
 	}
 	return f.Close()
 }
+
+// resolvePath returns the path to write the config to. If the config path is a symlink, the path of its target
+// is returned. A dangling symlink is rejected to not create a file in an unexpected location.
+func (c *Config) resolvePath() (string, error) {
+	fi, err := os.Lstat(c.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return c.path, nil
+		}
+		return "", fmt.Errorf("check config file %q: %w", c.path, err)
+	}
+	if fi.Mode()&fs.ModeSymlink == 0 {
+		return c.path, nil
+	}
+
+	path, err := filepath.EvalSymlinks(c.path)
+	if err != nil {
+		target, _ := os.Readlink(c.path)
+		return "", fmt.Errorf("config file %q is a symlink to %q which can't be resolved: %w", c.path, target, err)
+	}
+	fi, err = os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("check config file %q: %w", path, err)
+	}
+	if !fi.Mode().IsRegular() {
+		return "", fmt.Errorf("config file %q is a symlink to %q which is not a regular file", c.path, path)
+	}
+	return path, nil
+}
+
+// readOnlyHint returns an error with a hint on how to fix it if err is caused by a read-only filesystem.
+func readOnlyHint(err error) error {
+	if errors.Is(err, syscall.EROFS) {
+		return fmt.Errorf("%w (use --uncloud-config to specify a config path on a writable filesystem)", err)
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigSave(t *testing.T) {
+	t.Parallel()
+
+	newConfig := func(path string) *Config {
+		return &Config{
+			Clusters:       map[string]*Cluster{"default": {}},
+			CurrentCluster: "default",
+			path:           path,
+		}
+	}
+
+	t.Run("new file", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "uncloud", "config.toml")
+
+		require.NoError(t, newConfig(path).Save())
+
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+		c, err := NewFromFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "default", c.CurrentCluster)
+	})
+
+	t.Run("existing file mode is preserved", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "config.toml")
+		require.NoError(t, os.WriteFile(path, nil, 0640))
+
+		require.NoError(t, newConfig(path).Save())
+
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0640), fi.Mode().Perm())
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		target := filepath.Join(dir, "target.toml")
+		path := filepath.Join(dir, "config.toml")
+		require.NoError(t, os.WriteFile(target, nil, 0600))
+		require.NoError(t, os.Symlink(target, path))
+
+		require.NoError(t, newConfig(path).Save())
+
+		fi, err := os.Lstat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.ModeSymlink, fi.Mode()&os.ModeSymlink, "symlink must be preserved")
+		c, err := NewFromFile(target)
+		require.NoError(t, err)
+		assert.Equal(t, "default", c.CurrentCluster)
+	})
+
+	t.Run("dangling symlink", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		target := filepath.Join(dir, "missing.toml")
+		path := filepath.Join(dir, "config.toml")
+		require.NoError(t, os.Symlink(target, path))
+
+		err := newConfig(path).Save()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "can't be resolved")
+		assert.NoFileExists(t, target)
+	})
+
+	t.Run("read-only file", func(t *testing.T) {
+		t.Parallel()
+		if os.Geteuid() == 0 {
+			t.Skip("root can write read-only files")
+		}
+		path := filepath.Join(t.TempDir(), "config.toml")
+		require.NoError(t, os.WriteFile(path, []byte("current_cluster = \"old\"\n"), 0400))
+
+		require.Error(t, newConfig(path).Save())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "current_cluster = \"old\"\n", string(data), "read-only file must not be replaced")
+	})
+}
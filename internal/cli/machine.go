@@ -4,11 +4,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"uncloud/internal/sshexec"
 )
 
 // TODO: support pinning the script version to the CLI version.
-const installScriptURL = "https://raw.githubusercontent.com/psviderski/uncloud/refs/heads/main/scripts/install.sh"
+const (
+	installScriptURL   = "https://raw.githubusercontent.com/psviderski/uncloud/refs/heads/main/scripts/install.sh"
+	uninstallScriptURL = "https://raw.githubusercontent.com/psviderski/uncloud/refs/heads/main/scripts/uninstall.sh"
+	// installScriptDryRunMarker is the line in the install script indicating that it supports the dry-run mode.
+	// Older or cached scripts without the marker ignore UNCLOUD_DRY_RUN and would perform a real installation.
+	installScriptDryRunMarker = "# capability: dry-run"
+)
 
 type RemoteMachine struct {
 	User    string
@@ -18,25 +25,76 @@ type RemoteMachine struct {
 }
 
 // provisionMachine provisions the remote machine by downloading the Uncloud install script from GitHub and running it.
-func provisionMachine(ctx context.Context, exec sshexec.Executor) error {
+// If dryRun is true, the script only runs the preflight checks and reports what it would install or change.
+func provisionMachine(ctx context.Context, exec sshexec.Executor, dryRun bool) error {
 	user, err := exec.Run(ctx, "whoami")
 	if err != nil {
 		return fmt.Errorf("run whoami: %w", err)
 	}
-	sudoPrefix, env := "", ""
+	var bashCmd []string
 	if user != "root" {
-		sudoPrefix = "sudo"
+		bashCmd = append(bashCmd, "sudo")
 		// Add the SSH user (non-root) to the uncloud group to allow access to the Uncloud daemon unix socket.
-		env = "UNCLOUD_GROUP_ADD_USER=" + user
+		bashCmd = append(bashCmd, sshexec.Quote("UNCLOUD_GROUP_ADD_USER="+user))
+	}
+	if dryRun {
+		bashCmd = append(bashCmd, "UNCLOUD_DRY_RUN=true")
 	}
+	bashCmd = append(bashCmd, "bash")
 
 	fmt.Println("Downloading Uncloud install script:", installScriptURL)
-	curlBashCmd := fmt.Sprintf(
-		"curl -fsSL %s | %s %s bash", sshexec.Quote(installScriptURL), sudoPrefix, sshexec.Quote(env),
-	)
+	curlBashCmd := fmt.Sprintf("curl -fsSL %s | %s", sshexec.Quote(installScriptURL), strings.Join(bashCmd, " "))
+	if dryRun {
+		// Download the script first to make sure it supports the dry-run mode before running it.
+		curlBashCmd = fmt.Sprintf(
+			"script=$(curl -fsSL %s) && "+
+				"{ grep -qxF %s <<< \"$script\" || "+
+				"{ echo 'The install script does not support the dry-run mode.' >&2; exit 1; }; } && "+
+				"printf '%%s\\n' \"$script\" | %s",
+			sshexec.Quote(installScriptURL), sshexec.Quote(installScriptDryRunMarker), strings.Join(bashCmd, " "),
+		)
+	}
 	cmd := sshexec.QuoteCommand("bash", "-c", "set -o pipefail; "+curlBashCmd)
 	if err = exec.Stream(ctx, cmd, os.Stdout, os.Stderr); err != nil {
 		return fmt.Errorf("download and run install script: %w", err)
 	}
 	return nil
 }
+
+// purgeMachine uninstalls Uncloud from the remote machine by downloading the Uncloud uninstall script from GitHub and
+// running it. The script stops and removes the Uncloud daemons, service containers, network configuration, and data.
+func purgeMachine(ctx context.Context, exec sshexec.Executor) error {
+	user, err := exec.Run(ctx, "whoami")
+	if err != nil {
+		return fmt.Errorf("run whoami: %w", err)
+	}
+	bash := "bash"
+	if user != "root" {
+		bash = "sudo bash"
+	}
+
+	fmt.Println("Downloading Uncloud uninstall script:", uninstallScriptURL)
+	curlBashCmd := fmt.Sprintf("curl -fsSL %s | %s", sshexec.Quote(uninstallScriptURL), bash)
+	cmd := sshexec.QuoteCommand("bash", "-c", "set -o pipefail; "+curlBashCmd)
+	if err = exec.Stream(ctx, cmd, os.Stdout, os.Stderr); err != nil {
+		return fmt.Errorf("download and run uninstall script: %w", err)
+	}
+	return nil
+}
+
+// stopMachineDaemons stops and disables the Uncloud daemons on the remote machine so that the removed machine doesn't
+// keep syncing its containers to the cluster store. The service containers, network configuration, and data are kept.
+func stopMachineDaemons(ctx context.Context, exec sshexec.Executor) error {
+	user, err := exec.Run(ctx, "whoami")
+	if err != nil {
+		return fmt.Errorf("run whoami: %w", err)
+	}
+	cmd := sshexec.QuoteCommand("systemctl", "disable", "--now", "uncloud.service", "uncloud-corrosion.service")
+	if user != "root" {
+		cmd = "sudo " + cmd
+	}
+	if _, err = exec.Run(ctx, cmd); err != nil {
+		return fmt.Errorf("stop Uncloud systemd services: %w", err)
+	}
+	return nil
+}
@@ -12,10 +12,7 @@ type Daemon struct {
 	machine *machine.Machine
 }
 
-func New(dataDir string) (*Daemon, error) {
-	config := &machine.Config{
-		DataDir: dataDir,
-	}
+func New(config *machine.Config) (*Daemon, error) {
 	mach, err := machine.NewMachine(config)
 	if err != nil {
 		return nil, fmt.Errorf("init machine: %w", err)
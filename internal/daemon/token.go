@@ -1,41 +1,32 @@
 package daemon
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"net/netip"
-	"os"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
 	"uncloud/internal/machine"
-	"uncloud/internal/machine/network"
+	"uncloud/internal/machine/api/pb"
 )
 
 // MachineToken returns the local machine's token that can be used for adding the machine to a cluster.
-// TODO: ideally, this should be an RPC call to the daemon API to ensure the config is created and up-to-date.
-func MachineToken(dataDir string) (machine.Token, error) {
-	state, err := machine.ParseState(machine.StatePath(dataDir))
+// The token is requested from the daemon listening on the machine API unix socket so that its endpoints
+// are resolved in the same way as for the machine joining a cluster, taking the daemon config into account.
+func MachineToken(ctx context.Context, sockPath string) (machine.Token, error) {
+	conn, err := grpc.NewClient("unix://"+sockPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return machine.Token{}, fmt.Errorf("load machine config (is uncloudd daemon running?): %w", err)
-		}
-		return machine.Token{}, fmt.Errorf("load machine config: %w", err)
-	}
-	if len(state.Network.PublicKey) == 0 {
-		return machine.Token{}, errors.New("public key is not set in machine config")
+		return machine.Token{}, fmt.Errorf("connect to machine API: %w", err)
 	}
+	defer conn.Close()
 
-	ips, err := network.ListRoutableIPs()
+	resp, err := pb.NewMachineClient(conn).Token(ctx, &emptypb.Empty{})
 	if err != nil {
-		return machine.Token{}, fmt.Errorf("list routable addresses: %w", err)
-	}
-	publicIP, err := network.GetPublicIP()
-	// Ignore the error if failed to get the public IP using API services.
-	if err == nil {
-		ips = append(ips, publicIP)
+		return machine.Token{}, fmt.Errorf("request token from machine API (is uncloudd daemon running?): %w", err)
 	}
-
-	endpoints := make([]netip.AddrPort, len(ips))
-	for i, ip := range ips {
-		endpoints[i] = netip.AddrPortFrom(ip, network.WireGuardPort)
+	token, err := machine.ParseToken(resp.Token)
+	if err != nil {
+		return machine.Token{}, fmt.Errorf("parse token: %w", err)
 	}
-	return machine.NewToken(state.Network.PublicKey, endpoints), nil
+	return token, nil
 }
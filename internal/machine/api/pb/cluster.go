@@ -3,11 +3,29 @@ package pb
 import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // KeyLen is the expected key length for a WireGuard public or private key.
 const KeyLen = 32
 
+// SetManualEndpoint sets the WireGuard endpoint specified by the operator and moves it to the front of the endpoints
+// so that it takes precedence over the auto-discovered ones. A nil endpoint removes the current manual endpoint.
+func (c *NetworkConfig) SetManualEndpoint(ep *IPPort) {
+	endpoints := make([]*IPPort, 0, len(c.Endpoints)+1)
+	if ep != nil {
+		endpoints = append(endpoints, ep)
+	}
+	for _, e := range c.Endpoints {
+		if proto.Equal(e, ep) || proto.Equal(e, c.ManualEndpoint) {
+			continue
+		}
+		endpoints = append(endpoints, e)
+	}
+	c.Endpoints = endpoints
+	c.ManualEndpoint = ep
+}
+
 func (c *NetworkConfig) Validate() error {
 	if c.Subnet != nil {
 		_, err := c.Subnet.ToPrefix()
@@ -30,6 +48,11 @@ func (c *NetworkConfig) Validate() error {
 			return status.Errorf(codes.InvalidArgument, "invalid endpoint: %v", err)
 		}
 	}
+	if c.ManualEndpoint != nil {
+		if _, err := c.ManualEndpoint.ToAddrPort(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid manual endpoint: %v", err)
+		}
+	}
 	if c.PublicKey == nil {
 		return status.Error(codes.InvalidArgument, "public key not set")
 	}
@@ -20,8 +20,18 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Cluster_AddMachine_FullMethodName   = "/api.Cluster/AddMachine"
-	Cluster_ListMachines_FullMethodName = "/api.Cluster/ListMachines"
+	Cluster_AddMachine_FullMethodName          = "/api.Cluster/AddMachine"
+	Cluster_ListMachines_FullMethodName        = "/api.Cluster/ListMachines"
+	Cluster_UpdateMachine_FullMethodName       = "/api.Cluster/UpdateMachine"
+	Cluster_UpdateMachineLabels_FullMethodName = "/api.Cluster/UpdateMachineLabels"
+	Cluster_RemoveMachine_FullMethodName       = "/api.Cluster/RemoveMachine"
+	Cluster_StoreVersion_FullMethodName        = "/api.Cluster/StoreVersion"
+	Cluster_Ingress_FullMethodName             = "/api.Cluster/Ingress"
+	Cluster_Events_FullMethodName              = "/api.Cluster/Events"
+	Cluster_CreateServiceSpec_FullMethodName   = "/api.Cluster/CreateServiceSpec"
+	Cluster_GetServiceSpec_FullMethodName      = "/api.Cluster/GetServiceSpec"
+	Cluster_ListServiceSpecs_FullMethodName    = "/api.Cluster/ListServiceSpecs"
+	Cluster_DeleteServiceSpec_FullMethodName   = "/api.Cluster/DeleteServiceSpec"
 )
 
 // ClusterClient is the client API for Cluster service.
@@ -30,6 +40,20 @@ const (
 type ClusterClient interface {
 	AddMachine(ctx context.Context, in *AddMachineRequest, opts ...grpc.CallOption) (*AddMachineResponse, error)
 	ListMachines(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListMachinesResponse, error)
+	UpdateMachine(ctx context.Context, in *UpdateMachineRequest, opts ...grpc.CallOption) (*UpdateMachineResponse, error)
+	UpdateMachineLabels(ctx context.Context, in *UpdateMachineLabelsRequest, opts ...grpc.CallOption) (*UpdateMachineResponse, error)
+	RemoveMachine(ctx context.Context, in *RemoveMachineRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	StoreVersion(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StoreVersionResponse, error)
+	// Ingress returns the ingress controller used in the cluster.
+	Ingress(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*IngressResponse, error)
+	// Events streams the container, service, and machine events in the cluster as they are observed
+	// in the cluster store on the machine.
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// Service specs are the desired specs of services stored in the cluster independently of their containers.
+	CreateServiceSpec(ctx context.Context, in *ServiceSpecRecord, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetServiceSpec(ctx context.Context, in *GetServiceSpecRequest, opts ...grpc.CallOption) (*ServiceSpecRecord, error)
+	ListServiceSpecs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListServiceSpecsResponse, error)
+	DeleteServiceSpec(ctx context.Context, in *GetServiceSpecRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type clusterClient struct {
@@ -60,12 +84,135 @@ func (c *clusterClient) ListMachines(ctx context.Context, in *emptypb.Empty, opt
 	return out, nil
 }
 
+func (c *clusterClient) UpdateMachine(ctx context.Context, in *UpdateMachineRequest, opts ...grpc.CallOption) (*UpdateMachineResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateMachineResponse)
+	err := c.cc.Invoke(ctx, Cluster_UpdateMachine_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) UpdateMachineLabels(ctx context.Context, in *UpdateMachineLabelsRequest, opts ...grpc.CallOption) (*UpdateMachineResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateMachineResponse)
+	err := c.cc.Invoke(ctx, Cluster_UpdateMachineLabels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) RemoveMachine(ctx context.Context, in *RemoveMachineRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Cluster_RemoveMachine_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) StoreVersion(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StoreVersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StoreVersionResponse)
+	err := c.cc.Invoke(ctx, Cluster_StoreVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) Ingress(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*IngressResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IngressResponse)
+	err := c.cc.Invoke(ctx, Cluster_Ingress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Cluster_ServiceDesc.Streams[0], Cluster_Events_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Cluster_EventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *clusterClient) CreateServiceSpec(ctx context.Context, in *ServiceSpecRecord, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Cluster_CreateServiceSpec_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) GetServiceSpec(ctx context.Context, in *GetServiceSpecRequest, opts ...grpc.CallOption) (*ServiceSpecRecord, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ServiceSpecRecord)
+	err := c.cc.Invoke(ctx, Cluster_GetServiceSpec_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) ListServiceSpecs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListServiceSpecsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListServiceSpecsResponse)
+	err := c.cc.Invoke(ctx, Cluster_ListServiceSpecs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) DeleteServiceSpec(ctx context.Context, in *GetServiceSpecRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Cluster_DeleteServiceSpec_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ClusterServer is the server API for Cluster service.
 // All implementations must embed UnimplementedClusterServer
 // for forward compatibility.
 type ClusterServer interface {
 	AddMachine(context.Context, *AddMachineRequest) (*AddMachineResponse, error)
 	ListMachines(context.Context, *emptypb.Empty) (*ListMachinesResponse, error)
+	UpdateMachine(context.Context, *UpdateMachineRequest) (*UpdateMachineResponse, error)
+	UpdateMachineLabels(context.Context, *UpdateMachineLabelsRequest) (*UpdateMachineResponse, error)
+	RemoveMachine(context.Context, *RemoveMachineRequest) (*emptypb.Empty, error)
+	StoreVersion(context.Context, *emptypb.Empty) (*StoreVersionResponse, error)
+	// Ingress returns the ingress controller used in the cluster.
+	Ingress(context.Context, *emptypb.Empty) (*IngressResponse, error)
+	// Events streams the container, service, and machine events in the cluster as they are observed
+	// in the cluster store on the machine.
+	Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error
+	// Service specs are the desired specs of services stored in the cluster independently of their containers.
+	CreateServiceSpec(context.Context, *ServiceSpecRecord) (*emptypb.Empty, error)
+	GetServiceSpec(context.Context, *GetServiceSpecRequest) (*ServiceSpecRecord, error)
+	ListServiceSpecs(context.Context, *emptypb.Empty) (*ListServiceSpecsResponse, error)
+	DeleteServiceSpec(context.Context, *GetServiceSpecRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedClusterServer()
 }
 
@@ -82,6 +229,36 @@ func (UnimplementedClusterServer) AddMachine(context.Context, *AddMachineRequest
 func (UnimplementedClusterServer) ListMachines(context.Context, *emptypb.Empty) (*ListMachinesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListMachines not implemented")
 }
+func (UnimplementedClusterServer) UpdateMachine(context.Context, *UpdateMachineRequest) (*UpdateMachineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateMachine not implemented")
+}
+func (UnimplementedClusterServer) UpdateMachineLabels(context.Context, *UpdateMachineLabelsRequest) (*UpdateMachineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateMachineLabels not implemented")
+}
+func (UnimplementedClusterServer) RemoveMachine(context.Context, *RemoveMachineRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveMachine not implemented")
+}
+func (UnimplementedClusterServer) StoreVersion(context.Context, *emptypb.Empty) (*StoreVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StoreVersion not implemented")
+}
+func (UnimplementedClusterServer) Ingress(context.Context, *emptypb.Empty) (*IngressResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ingress not implemented")
+}
+func (UnimplementedClusterServer) Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedClusterServer) CreateServiceSpec(context.Context, *ServiceSpecRecord) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateServiceSpec not implemented")
+}
+func (UnimplementedClusterServer) GetServiceSpec(context.Context, *GetServiceSpecRequest) (*ServiceSpecRecord, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServiceSpec not implemented")
+}
+func (UnimplementedClusterServer) ListServiceSpecs(context.Context, *emptypb.Empty) (*ListServiceSpecsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListServiceSpecs not implemented")
+}
+func (UnimplementedClusterServer) DeleteServiceSpec(context.Context, *GetServiceSpecRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteServiceSpec not implemented")
+}
 func (UnimplementedClusterServer) mustEmbedUnimplementedClusterServer() {}
 func (UnimplementedClusterServer) testEmbeddedByValue()                 {}
 
@@ -139,6 +316,179 @@ func _Cluster_ListMachines_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Cluster_UpdateMachine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateMachineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).UpdateMachine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_UpdateMachine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).UpdateMachine(ctx, req.(*UpdateMachineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_UpdateMachineLabels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateMachineLabelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).UpdateMachineLabels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_UpdateMachineLabels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).UpdateMachineLabels(ctx, req.(*UpdateMachineLabelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_RemoveMachine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveMachineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).RemoveMachine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_RemoveMachine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).RemoveMachine(ctx, req.(*RemoveMachineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_StoreVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).StoreVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_StoreVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).StoreVersion(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_Ingress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).Ingress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_Ingress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).Ingress(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ClusterServer).Events(m, &grpc.GenericServerStream[EventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Cluster_EventsServer = grpc.ServerStreamingServer[Event]
+
+func _Cluster_CreateServiceSpec_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ServiceSpecRecord)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).CreateServiceSpec(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_CreateServiceSpec_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).CreateServiceSpec(ctx, req.(*ServiceSpecRecord))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_GetServiceSpec_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServiceSpecRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).GetServiceSpec(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_GetServiceSpec_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).GetServiceSpec(ctx, req.(*GetServiceSpecRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_ListServiceSpecs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).ListServiceSpecs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_ListServiceSpecs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).ListServiceSpecs(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_DeleteServiceSpec_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServiceSpecRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).DeleteServiceSpec(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Cluster_DeleteServiceSpec_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).DeleteServiceSpec(ctx, req.(*GetServiceSpecRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Cluster_ServiceDesc is the grpc.ServiceDesc for Cluster service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -154,7 +504,49 @@ var Cluster_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListMachines",
 			Handler:    _Cluster_ListMachines_Handler,
 		},
+		{
+			MethodName: "UpdateMachine",
+			Handler:    _Cluster_UpdateMachine_Handler,
+		},
+		{
+			MethodName: "UpdateMachineLabels",
+			Handler:    _Cluster_UpdateMachineLabels_Handler,
+		},
+		{
+			MethodName: "RemoveMachine",
+			Handler:    _Cluster_RemoveMachine_Handler,
+		},
+		{
+			MethodName: "StoreVersion",
+			Handler:    _Cluster_StoreVersion_Handler,
+		},
+		{
+			MethodName: "Ingress",
+			Handler:    _Cluster_Ingress_Handler,
+		},
+		{
+			MethodName: "CreateServiceSpec",
+			Handler:    _Cluster_CreateServiceSpec_Handler,
+		},
+		{
+			MethodName: "GetServiceSpec",
+			Handler:    _Cluster_GetServiceSpec_Handler,
+		},
+		{
+			MethodName: "ListServiceSpecs",
+			Handler:    _Cluster_ListServiceSpecs_Handler,
+		},
+		{
+			MethodName: "DeleteServiceSpec",
+			Handler:    _Cluster_DeleteServiceSpec_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _Cluster_Events_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "internal/machine/api/pb/cluster.proto",
 }
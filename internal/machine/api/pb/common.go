@@ -6,6 +6,10 @@ import (
 	"net/netip"
 )
 
+// DefaultMaxMessageSize is the default maximum size in bytes of a gRPC message the machine API servers and clients
+// can send or receive. It's larger than the gRPC default of 4MB to allow responses with many containers.
+const DefaultMaxMessageSize = 64 << 20
+
 func NewIP(addr netip.Addr) *IP {
 	// MarshalBinary always returns a nil error.
 	ip, _ := addr.MarshalBinary()
@@ -20,11 +20,20 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Docker_CreateContainer_FullMethodName = "/api.Docker/CreateContainer"
-	Docker_StartContainer_FullMethodName  = "/api.Docker/StartContainer"
-	Docker_ListContainers_FullMethodName  = "/api.Docker/ListContainers"
-	Docker_RemoveContainer_FullMethodName = "/api.Docker/RemoveContainer"
-	Docker_PullImage_FullMethodName       = "/api.Docker/PullImage"
+	Docker_CreateContainer_FullMethodName   = "/api.Docker/CreateContainer"
+	Docker_StartContainer_FullMethodName    = "/api.Docker/StartContainer"
+	Docker_InspectContainer_FullMethodName  = "/api.Docker/InspectContainer"
+	Docker_ListContainers_FullMethodName    = "/api.Docker/ListContainers"
+	Docker_RemoveContainer_FullMethodName   = "/api.Docker/RemoveContainer"
+	Docker_PullImage_FullMethodName         = "/api.Docker/PullImage"
+	Docker_Info_FullMethodName              = "/api.Docker/Info"
+	Docker_CopyToContainer_FullMethodName   = "/api.Docker/CopyToContainer"
+	Docker_CopyFromContainer_FullMethodName = "/api.Docker/CopyFromContainer"
+	Docker_ContainerStats_FullMethodName    = "/api.Docker/ContainerStats"
+	Docker_PruneImages_FullMethodName       = "/api.Docker/PruneImages"
+	Docker_ListVolumes_FullMethodName       = "/api.Docker/ListVolumes"
+	Docker_BackupVolume_FullMethodName      = "/api.Docker/BackupVolume"
+	Docker_RestoreVolume_FullMethodName     = "/api.Docker/RestoreVolume"
 )
 
 // DockerClient is the client API for Docker service.
@@ -33,9 +42,25 @@ const (
 type DockerClient interface {
 	CreateContainer(ctx context.Context, in *CreateContainerRequest, opts ...grpc.CallOption) (*CreateContainerResponse, error)
 	StartContainer(ctx context.Context, in *StartContainerRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	InspectContainer(ctx context.Context, in *InspectContainerRequest, opts ...grpc.CallOption) (*InspectContainerResponse, error)
 	ListContainers(ctx context.Context, in *ListContainersRequest, opts ...grpc.CallOption) (*ListContainersResponse, error)
 	RemoveContainer(ctx context.Context, in *RemoveContainerRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	PullImage(ctx context.Context, in *PullImageRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[JSONMessage], error)
+	Info(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*InfoResponse, error)
+	// CopyToContainer extracts a tar archive streamed by the client into a container.
+	CopyToContainer(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[CopyToContainerRequest, emptypb.Empty], error)
+	// CopyFromContainer streams a tar archive of a file or directory in a container.
+	CopyFromContainer(ctx context.Context, in *CopyFromContainerRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CopyFromContainerResponse], error)
+	// ContainerStats streams resource usage statistics of a container.
+	ContainerStats(ctx context.Context, in *ContainerStatsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ContainerStatsResponse], error)
+	// PruneImages removes unused images. Images used by any container are never removed.
+	PruneImages(ctx context.Context, in *PruneImagesRequest, opts ...grpc.CallOption) (*PruneImagesResponse, error)
+	// ListVolumes returns the volumes on the machine, optionally with their disk usage.
+	ListVolumes(ctx context.Context, in *ListVolumesRequest, opts ...grpc.CallOption) (*ListVolumesResponse, error)
+	// BackupVolume streams a tar archive of the contents of a volume.
+	BackupVolume(ctx context.Context, in *BackupVolumeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupVolumeResponse], error)
+	// RestoreVolume extracts a tar archive streamed by the client into a volume. The volume is created if missing.
+	RestoreVolume(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreVolumeRequest, emptypb.Empty], error)
 }
 
 type dockerClient struct {
@@ -66,6 +91,16 @@ func (c *dockerClient) StartContainer(ctx context.Context, in *StartContainerReq
 	return out, nil
 }
 
+func (c *dockerClient) InspectContainer(ctx context.Context, in *InspectContainerRequest, opts ...grpc.CallOption) (*InspectContainerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InspectContainerResponse)
+	err := c.cc.Invoke(ctx, Docker_InspectContainer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *dockerClient) ListContainers(ctx context.Context, in *ListContainersRequest, opts ...grpc.CallOption) (*ListContainersResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListContainersResponse)
@@ -105,15 +140,144 @@ func (c *dockerClient) PullImage(ctx context.Context, in *PullImageRequest, opts
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Docker_PullImageClient = grpc.ServerStreamingClient[JSONMessage]
 
+func (c *dockerClient) Info(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*InfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, Docker_Info_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dockerClient) CopyToContainer(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[CopyToContainerRequest, emptypb.Empty], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Docker_ServiceDesc.Streams[1], Docker_CopyToContainer_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CopyToContainerRequest, emptypb.Empty]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Docker_CopyToContainerClient = grpc.ClientStreamingClient[CopyToContainerRequest, emptypb.Empty]
+
+func (c *dockerClient) CopyFromContainer(ctx context.Context, in *CopyFromContainerRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CopyFromContainerResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Docker_ServiceDesc.Streams[2], Docker_CopyFromContainer_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CopyFromContainerRequest, CopyFromContainerResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Docker_CopyFromContainerClient = grpc.ServerStreamingClient[CopyFromContainerResponse]
+
+func (c *dockerClient) ContainerStats(ctx context.Context, in *ContainerStatsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ContainerStatsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Docker_ServiceDesc.Streams[3], Docker_ContainerStats_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ContainerStatsRequest, ContainerStatsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Docker_ContainerStatsClient = grpc.ServerStreamingClient[ContainerStatsResponse]
+
+func (c *dockerClient) PruneImages(ctx context.Context, in *PruneImagesRequest, opts ...grpc.CallOption) (*PruneImagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PruneImagesResponse)
+	err := c.cc.Invoke(ctx, Docker_PruneImages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dockerClient) ListVolumes(ctx context.Context, in *ListVolumesRequest, opts ...grpc.CallOption) (*ListVolumesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListVolumesResponse)
+	err := c.cc.Invoke(ctx, Docker_ListVolumes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dockerClient) BackupVolume(ctx context.Context, in *BackupVolumeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BackupVolumeResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Docker_ServiceDesc.Streams[4], Docker_BackupVolume_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BackupVolumeRequest, BackupVolumeResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Docker_BackupVolumeClient = grpc.ServerStreamingClient[BackupVolumeResponse]
+
+func (c *dockerClient) RestoreVolume(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreVolumeRequest, emptypb.Empty], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Docker_ServiceDesc.Streams[5], Docker_RestoreVolume_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RestoreVolumeRequest, emptypb.Empty]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Docker_RestoreVolumeClient = grpc.ClientStreamingClient[RestoreVolumeRequest, emptypb.Empty]
+
 // DockerServer is the server API for Docker service.
 // All implementations must embed UnimplementedDockerServer
 // for forward compatibility.
 type DockerServer interface {
 	CreateContainer(context.Context, *CreateContainerRequest) (*CreateContainerResponse, error)
 	StartContainer(context.Context, *StartContainerRequest) (*emptypb.Empty, error)
+	InspectContainer(context.Context, *InspectContainerRequest) (*InspectContainerResponse, error)
 	ListContainers(context.Context, *ListContainersRequest) (*ListContainersResponse, error)
 	RemoveContainer(context.Context, *RemoveContainerRequest) (*emptypb.Empty, error)
 	PullImage(*PullImageRequest, grpc.ServerStreamingServer[JSONMessage]) error
+	Info(context.Context, *emptypb.Empty) (*InfoResponse, error)
+	// CopyToContainer extracts a tar archive streamed by the client into a container.
+	CopyToContainer(grpc.ClientStreamingServer[CopyToContainerRequest, emptypb.Empty]) error
+	// CopyFromContainer streams a tar archive of a file or directory in a container.
+	CopyFromContainer(*CopyFromContainerRequest, grpc.ServerStreamingServer[CopyFromContainerResponse]) error
+	// ContainerStats streams resource usage statistics of a container.
+	ContainerStats(*ContainerStatsRequest, grpc.ServerStreamingServer[ContainerStatsResponse]) error
+	// PruneImages removes unused images. Images used by any container are never removed.
+	PruneImages(context.Context, *PruneImagesRequest) (*PruneImagesResponse, error)
+	// ListVolumes returns the volumes on the machine, optionally with their disk usage.
+	ListVolumes(context.Context, *ListVolumesRequest) (*ListVolumesResponse, error)
+	// BackupVolume streams a tar archive of the contents of a volume.
+	BackupVolume(*BackupVolumeRequest, grpc.ServerStreamingServer[BackupVolumeResponse]) error
+	// RestoreVolume extracts a tar archive streamed by the client into a volume. The volume is created if missing.
+	RestoreVolume(grpc.ClientStreamingServer[RestoreVolumeRequest, emptypb.Empty]) error
 	mustEmbedUnimplementedDockerServer()
 }
 
@@ -130,6 +294,9 @@ func (UnimplementedDockerServer) CreateContainer(context.Context, *CreateContain
 func (UnimplementedDockerServer) StartContainer(context.Context, *StartContainerRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StartContainer not implemented")
 }
+func (UnimplementedDockerServer) InspectContainer(context.Context, *InspectContainerRequest) (*InspectContainerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InspectContainer not implemented")
+}
 func (UnimplementedDockerServer) ListContainers(context.Context, *ListContainersRequest) (*ListContainersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListContainers not implemented")
 }
@@ -139,6 +306,30 @@ func (UnimplementedDockerServer) RemoveContainer(context.Context, *RemoveContain
 func (UnimplementedDockerServer) PullImage(*PullImageRequest, grpc.ServerStreamingServer[JSONMessage]) error {
 	return status.Errorf(codes.Unimplemented, "method PullImage not implemented")
 }
+func (UnimplementedDockerServer) Info(context.Context, *emptypb.Empty) (*InfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Info not implemented")
+}
+func (UnimplementedDockerServer) CopyToContainer(grpc.ClientStreamingServer[CopyToContainerRequest, emptypb.Empty]) error {
+	return status.Errorf(codes.Unimplemented, "method CopyToContainer not implemented")
+}
+func (UnimplementedDockerServer) CopyFromContainer(*CopyFromContainerRequest, grpc.ServerStreamingServer[CopyFromContainerResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method CopyFromContainer not implemented")
+}
+func (UnimplementedDockerServer) ContainerStats(*ContainerStatsRequest, grpc.ServerStreamingServer[ContainerStatsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ContainerStats not implemented")
+}
+func (UnimplementedDockerServer) PruneImages(context.Context, *PruneImagesRequest) (*PruneImagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PruneImages not implemented")
+}
+func (UnimplementedDockerServer) ListVolumes(context.Context, *ListVolumesRequest) (*ListVolumesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVolumes not implemented")
+}
+func (UnimplementedDockerServer) BackupVolume(*BackupVolumeRequest, grpc.ServerStreamingServer[BackupVolumeResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method BackupVolume not implemented")
+}
+func (UnimplementedDockerServer) RestoreVolume(grpc.ClientStreamingServer[RestoreVolumeRequest, emptypb.Empty]) error {
+	return status.Errorf(codes.Unimplemented, "method RestoreVolume not implemented")
+}
 func (UnimplementedDockerServer) mustEmbedUnimplementedDockerServer() {}
 func (UnimplementedDockerServer) testEmbeddedByValue()                {}
 
@@ -196,6 +387,24 @@ func _Docker_StartContainer_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Docker_InspectContainer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InspectContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DockerServer).InspectContainer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Docker_InspectContainer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DockerServer).InspectContainer(ctx, req.(*InspectContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Docker_ListContainers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListContainersRequest)
 	if err := dec(in); err != nil {
@@ -243,6 +452,107 @@ func _Docker_PullImage_Handler(srv interface{}, stream grpc.ServerStream) error
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type Docker_PullImageServer = grpc.ServerStreamingServer[JSONMessage]
 
+func _Docker_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DockerServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Docker_Info_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DockerServer).Info(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Docker_CopyToContainer_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DockerServer).CopyToContainer(&grpc.GenericServerStream[CopyToContainerRequest, emptypb.Empty]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Docker_CopyToContainerServer = grpc.ClientStreamingServer[CopyToContainerRequest, emptypb.Empty]
+
+func _Docker_CopyFromContainer_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CopyFromContainerRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DockerServer).CopyFromContainer(m, &grpc.GenericServerStream[CopyFromContainerRequest, CopyFromContainerResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Docker_CopyFromContainerServer = grpc.ServerStreamingServer[CopyFromContainerResponse]
+
+func _Docker_ContainerStats_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ContainerStatsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DockerServer).ContainerStats(m, &grpc.GenericServerStream[ContainerStatsRequest, ContainerStatsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Docker_ContainerStatsServer = grpc.ServerStreamingServer[ContainerStatsResponse]
+
+func _Docker_PruneImages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PruneImagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DockerServer).PruneImages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Docker_PruneImages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DockerServer).PruneImages(ctx, req.(*PruneImagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Docker_ListVolumes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVolumesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DockerServer).ListVolumes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Docker_ListVolumes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DockerServer).ListVolumes(ctx, req.(*ListVolumesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Docker_BackupVolume_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackupVolumeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DockerServer).BackupVolume(m, &grpc.GenericServerStream[BackupVolumeRequest, BackupVolumeResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Docker_BackupVolumeServer = grpc.ServerStreamingServer[BackupVolumeResponse]
+
+func _Docker_RestoreVolume_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DockerServer).RestoreVolume(&grpc.GenericServerStream[RestoreVolumeRequest, emptypb.Empty]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Docker_RestoreVolumeServer = grpc.ClientStreamingServer[RestoreVolumeRequest, emptypb.Empty]
+
 // Docker_ServiceDesc is the grpc.ServiceDesc for Docker service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -258,6 +568,10 @@ var Docker_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "StartContainer",
 			Handler:    _Docker_StartContainer_Handler,
 		},
+		{
+			MethodName: "InspectContainer",
+			Handler:    _Docker_InspectContainer_Handler,
+		},
 		{
 			MethodName: "ListContainers",
 			Handler:    _Docker_ListContainers_Handler,
@@ -266,6 +580,18 @@ var Docker_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RemoveContainer",
 			Handler:    _Docker_RemoveContainer_Handler,
 		},
+		{
+			MethodName: "Info",
+			Handler:    _Docker_Info_Handler,
+		},
+		{
+			MethodName: "PruneImages",
+			Handler:    _Docker_PruneImages_Handler,
+		},
+		{
+			MethodName: "ListVolumes",
+			Handler:    _Docker_ListVolumes_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -273,6 +599,31 @@ var Docker_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Docker_PullImage_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "CopyToContainer",
+			Handler:       _Docker_CopyToContainer_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "CopyFromContainer",
+			Handler:       _Docker_CopyFromContainer_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ContainerStats",
+			Handler:       _Docker_ContainerStats_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "BackupVolume",
+			Handler:       _Docker_BackupVolume_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RestoreVolume",
+			Handler:       _Docker_RestoreVolume_Handler,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "internal/machine/api/pb/docker.proto",
 }
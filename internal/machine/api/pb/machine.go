@@ -0,0 +1,53 @@
+package pb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MachineRoleWorker is the default role of a machine that runs service containers.
+	MachineRoleWorker = "worker"
+	// MachineRoleControlPlane is the role of a machine that only runs the cluster components and is excluded
+	// from service container placement.
+	MachineRoleControlPlane = "control-plane"
+)
+
+// ValidateMachineRole returns an error if the role is not a valid machine role. An empty role is valid
+// and means MachineRoleWorker.
+func ValidateMachineRole(role string) error {
+	switch role {
+	case "", MachineRoleWorker, MachineRoleControlPlane:
+		return nil
+	default:
+		return fmt.Errorf("invalid machine role: %q, must be either %q or %q",
+			role, MachineRoleWorker, MachineRoleControlPlane)
+	}
+}
+
+// ValidateMachineLabel returns an error if the machine label key is invalid. Similar to Docker labels, the key
+// must not be empty or contain whitespace.
+func ValidateMachineLabel(key string) error {
+	if key == "" {
+		return errors.New("invalid label: key must not be empty")
+	}
+	if strings.ContainsAny(key, " \t\n\r\v\f") {
+		return fmt.Errorf("invalid label %q: key must not contain whitespace", key)
+	}
+	return nil
+}
+
+// MachineRole returns the role of the machine defaulting to MachineRoleWorker if not set.
+func (m *MachineInfo) MachineRole() string {
+	if m.Role == "" {
+		return MachineRoleWorker
+	}
+	return m.Role
+}
+
+// RunsWorkloads returns true if new service containers can be placed on the machine. Control-plane and cordoned
+// machines don't accept new service containers.
+func (m *MachineInfo) RunsWorkloads() bool {
+	return m.MachineRole() != MachineRoleControlPlane && !m.Cordoned
+}
@@ -13,16 +13,20 @@ import (
 type Director struct {
 	localBackend   *LocalBackend
 	remotePort     uint16
+	maxMsgSize     int
 	remoteBackends sync.Map
 	// mu synchronizes access to localAddress.
 	mu           sync.RWMutex
 	localAddress string
 }
 
-func NewDirector(localSockPath string, remotePort uint16) *Director {
+// NewDirector returns a new Director. maxMsgSize is the maximum size in bytes of a message the backend
+// connections can send or receive.
+func NewDirector(localSockPath string, remotePort uint16, maxMsgSize int) *Director {
 	return &Director{
-		localBackend: NewLocalBackend(localSockPath, ""),
+		localBackend: NewLocalBackend(localSockPath, "", maxMsgSize),
 		remotePort:   remotePort,
+		maxMsgSize:   maxMsgSize,
 	}
 }
 
@@ -34,7 +38,7 @@ func (d *Director) UpdateLocalAddress(addr string) {
 
 	d.localAddress = addr
 	// Replace the local backend with the one that has local address set.
-	d.localBackend = NewLocalBackend(d.localBackend.sockPath, addr)
+	d.localBackend = NewLocalBackend(d.localBackend.sockPath, addr, d.maxMsgSize)
 }
 
 // Director implements proxy.StreamDirector for grpc-proxy, routing requests to local or remote backends based
@@ -90,7 +94,7 @@ func (d *Director) remoteBackend(addr string) (*RemoteBackend, error) {
 		return b.(*RemoteBackend), nil
 	}
 
-	backend, err := NewRemoteBackend(addr, d.remotePort)
+	backend, err := NewRemoteBackend(addr, d.remotePort, d.maxMsgSize)
 	if err != nil {
 		return nil, err
 	}
@@ -12,7 +12,8 @@ import (
 // LocalBackend is a proxy.One2ManyResponder implementation that proxies to a local gRPC server listening on a Unix socket.
 type LocalBackend struct {
 	One2ManyResponder
-	sockPath string
+	sockPath   string
+	maxMsgSize int
 
 	mu   sync.RWMutex
 	conn *grpc.ClientConn
@@ -22,13 +23,15 @@ var _ proxy.Backend = (*LocalBackend)(nil)
 
 // NewLocalBackend returns a new LocalBackend for the given Unix socket path. The addr parameter is the local address
 // of the current machine which could be empty if it's not known. The address is used to populate response metadata
-// in one2many mode.
-func NewLocalBackend(sockPath, addr string) *LocalBackend {
+// in one2many mode. The maxMsgSize parameter is the maximum size in bytes of a message the connection can send
+// or receive.
+func NewLocalBackend(sockPath, addr string, maxMsgSize int) *LocalBackend {
 	return &LocalBackend{
 		One2ManyResponder: One2ManyResponder{
 			machine: addr,
 		},
-		sockPath: sockPath,
+		sockPath:   sockPath,
+		maxMsgSize: maxMsgSize,
 	}
 }
 
@@ -57,6 +60,8 @@ func (b *LocalBackend) GetConnection(ctx context.Context, _ string) (context.Con
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithDefaultCallOptions(
 			grpc.ForceCodecV2(proxy.Codec()),
+			grpc.MaxCallRecvMsgSize(b.maxMsgSize),
+			grpc.MaxCallSendMsgSize(b.maxMsgSize),
 		),
 	)
 
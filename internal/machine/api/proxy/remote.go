@@ -20,7 +20,8 @@ import (
 // https://github.com/siderolabs/talos/blob/59a78da42cdea8fbccc35d0851f9b0eef928261b/internal/app/apid/pkg/backend/apid.go
 type RemoteBackend struct {
 	One2ManyResponder
-	target string
+	target     string
+	maxMsgSize int
 
 	mu   sync.RWMutex
 	conn *grpc.ClientConn
@@ -28,8 +29,9 @@ type RemoteBackend struct {
 
 var _ proxy.Backend = (*RemoteBackend)(nil)
 
-// NewRemoteBackend creates a new instance of RemoteBackend for the given IPv6 address and port.
-func NewRemoteBackend(addr string, port uint16) (*RemoteBackend, error) {
+// NewRemoteBackend creates a new instance of RemoteBackend for the given IPv6 address and port. The maxMsgSize
+// parameter is the maximum size in bytes of a message the connection can send or receive.
+func NewRemoteBackend(addr string, port uint16, maxMsgSize int) (*RemoteBackend, error) {
 	ip, err := netip.ParseAddr(addr)
 	if err != nil || !ip.Is6() {
 		return nil, fmt.Errorf("address must be a valid IPv6 address: %s", addr)
@@ -39,7 +41,8 @@ func NewRemoteBackend(addr string, port uint16) (*RemoteBackend, error) {
 		One2ManyResponder: One2ManyResponder{
 			machine: addr,
 		},
-		target: netip.AddrPortFrom(ip, port).String(),
+		target:     netip.AddrPortFrom(ip, port).String(),
+		maxMsgSize: maxMsgSize,
 	}, nil
 }
 
@@ -91,6 +94,8 @@ func (b *RemoteBackend) GetConnection(ctx context.Context, _ string) (context.Co
 		}),
 		grpc.WithDefaultCallOptions(
 			grpc.ForceCodecV2(proxy.Codec()),
+			grpc.MaxCallRecvMsgSize(b.maxMsgSize),
+			grpc.MaxCallSendMsgSize(b.maxMsgSize),
 		),
 	)
 
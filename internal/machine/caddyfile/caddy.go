@@ -0,0 +1,179 @@
+package caddyfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp/caddyauth"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp/reverseproxy"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"uncloud/internal/api"
+)
+
+// CaddyGenerator generates a Caddy JSON configuration.
+type CaddyGenerator struct {
+	// TLS defines how Caddy obtains certificates for HTTPS hostnames.
+	TLS TLSConfig
+}
+
+func (g *CaddyGenerator) Generate(containers []*api.Container) ([]byte, error) {
+	hu := containersHostUpstreams(containers)
+	if len(hu.tcp) > 0 || len(hu.udp) > 0 {
+		// The standard Caddy build doesn't include the layer4 app required to proxy raw TCP and UDP traffic.
+		// Such services are rejected on deployment but may have been deployed before the check was added.
+		slog.Warn("TCP and UDP ingress ports are not supported by the Caddy ingress, "+
+			"use the Traefik ingress instead.", "tcp_ports", len(hu.tcp), "udp_ports", len(hu.udp))
+	}
+
+	var warnings []caddyconfig.Warning
+	// Redirect routes replace the plain HTTP routes for the same hostnames.
+	redirectHosts := hu.httpsRedirectHosts()
+	httpUpstreams := make(map[string][]string, len(hu.http))
+	for hostname, upstreams := range hu.http {
+		if !slices.Contains(redirectHosts, hostname) {
+			httpUpstreams[hostname] = upstreams
+		}
+	}
+	httpRoutes := httpsRedirectRoutes(redirectHosts, &warnings)
+	httpRoutes = append(httpRoutes, hostUpstreamsToRoutes(httpUpstreams, hu.hosts, &warnings)...)
+
+	servers := make(map[string]*caddyhttp.Server)
+	servers["http"] = &caddyhttp.Server{
+		Listen: []string{fmt.Sprintf(":%d", caddyhttp.DefaultHTTPPort)},
+		Routes: httpRoutes,
+	}
+	servers["https"] = &caddyhttp.Server{
+		Listen: []string{fmt.Sprintf(":%d", caddyhttp.DefaultHTTPSPort)},
+		Routes: hostUpstreamsToRoutes(hu.https, hu.hosts, &warnings),
+	}
+
+	httpApp := caddyhttp.App{
+		Servers: servers,
+	}
+	config := &caddy.Config{
+		AppsRaw: caddy.ModuleMap{
+			"http": caddyconfig.JSON(httpApp, &warnings),
+		},
+	}
+	if tlsApp := g.TLS.caddyTLSApp(&warnings); tlsApp != nil {
+		config.AppsRaw["tls"] = caddyconfig.JSON(tlsApp, &warnings)
+	}
+
+	var err error
+	if len(warnings) > 0 {
+		// warnings only contains errors from JSON marshaling, which are highly unlikely with correct code.
+		for _, w := range warnings {
+			err = errors.Join(err, errors.New(w.Message))
+		}
+		return nil, fmt.Errorf("marshal Caddy configuration: %w", err)
+	}
+
+	configBytes, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal Caddy configuration: %w", err)
+	}
+	return configBytes, nil
+}
+
+// hostUpstreamsToRoutes converts a map of hostnames to upstreams to a list of Caddy routes. The reverse proxy
+// handler of each route is configured according to the hostname options.
+func hostUpstreamsToRoutes(
+	hostUpstreams map[string][]string, hosts map[string]hostOptions, warnings *[]caddyconfig.Warning,
+) []caddyhttp.Route {
+	routes := make([]caddyhttp.Route, 0, len(hostUpstreams))
+	for hostname, upstreams := range hostUpstreams {
+		upstreamPool := make([]*reverseproxy.Upstream, len(upstreams))
+		for i, upstream := range upstreams {
+			upstreamPool[i] = &reverseproxy.Upstream{
+				Dial: upstream,
+			}
+		}
+		handler := &reverseproxy.Handler{
+			Upstreams: upstreamPool,
+		}
+		opts := hosts[hostname]
+		if opts.sticky {
+			// Equivalent to 'lb_policy cookie' in the Caddyfile.
+			handler.LoadBalancing = &reverseproxy.LoadBalancing{
+				SelectionPolicyRaw: caddyconfig.JSONModuleObject(
+					reverseproxy.CookieHashSelection{}, "policy", "cookie", warnings,
+				),
+			}
+		}
+		if opts.healthCheck != nil {
+			// Equivalent to 'health_uri' and 'health_interval' in the Caddyfile.
+			handler.HealthChecks = &reverseproxy.HealthChecks{
+				Active: &reverseproxy.ActiveHealthChecks{
+					URI:      opts.healthCheck.Path,
+					Interval: caddy.Duration(opts.healthCheck.Interval),
+				},
+			}
+		}
+
+		var handlers []json.RawMessage
+		if len(opts.basicAuth) > 0 {
+			// Equivalent to the 'basic_auth' directive in the Caddyfile. It must precede the reverse proxy handler.
+			handlers = append(handlers, basicAuthHandler(opts.basicAuth, warnings))
+		}
+		handlers = append(handlers, caddyconfig.JSONModuleObject(handler, "handler", "reverse_proxy", warnings))
+
+		routes = append(routes, caddyhttp.Route{
+			MatcherSetsRaw: caddyhttp.RawMatcherSets{
+				{
+					"host": caddyconfig.JSON(caddyhttp.MatchHost{hostname}, warnings),
+				},
+			},
+			HandlersRaw: handlers,
+		})
+	}
+	return routes
+}
+
+// basicAuthHandler returns a Caddy authentication handler that only allows the users with valid credentials.
+func basicAuthHandler(users []api.BasicAuthUser, warnings *[]caddyconfig.Warning) json.RawMessage {
+	accounts := make([]caddyauth.Account, len(users))
+	for i, u := range users {
+		accounts[i] = caddyauth.Account{Username: u.Username, Password: u.PasswordHash}
+	}
+	basicAuth := caddyauth.HTTPBasicAuth{
+		HashRaw:     caddyconfig.JSONModuleObject(caddyauth.BcryptHash{}, "algorithm", "bcrypt", warnings),
+		AccountList: accounts,
+	}
+	auth := caddyauth.Authentication{
+		ProvidersRaw: caddy.ModuleMap{
+			"http_basic": caddyconfig.JSON(basicAuth, warnings),
+		},
+	}
+	return caddyconfig.JSONModuleObject(auth, "handler", "authentication", warnings)
+}
+
+// httpsRedirectRoutes returns Caddy routes that permanently redirect requests to the hostnames to HTTPS.
+// It's equivalent to 'redir https://{host}{uri} permanent' in the Caddyfile.
+func httpsRedirectRoutes(hostnames []string, warnings *[]caddyconfig.Warning) []caddyhttp.Route {
+	routes := make([]caddyhttp.Route, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		redirect := caddyhttp.StaticResponse{
+			StatusCode: caddyhttp.WeakString(strconv.Itoa(http.StatusPermanentRedirect)),
+			Headers: http.Header{
+				"Location": []string{"https://{http.request.host}{http.request.uri}"},
+			},
+		}
+		routes = append(routes, caddyhttp.Route{
+			MatcherSetsRaw: caddyhttp.RawMatcherSets{
+				{
+					"host": caddyconfig.JSON(caddyhttp.MatchHost{hostname}, warnings),
+				},
+			},
+			HandlersRaw: []json.RawMessage{
+				caddyconfig.JSONModuleObject(redirect, "handler", "static_response", warnings),
+			},
+		})
+	}
+	return routes
+}
@@ -2,18 +2,17 @@ package caddyfile
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/caddyserver/caddy/v2"
-	"github.com/caddyserver/caddy/v2/caddyconfig"
-	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
-	"github.com/caddyserver/caddy/v2/modules/caddyhttp/reverseproxy"
+	dnetwork "github.com/docker/docker/api/types/network"
 	"log/slog"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 	"uncloud/internal/api"
 	"uncloud/internal/fs"
 	"uncloud/internal/machine/docker"
@@ -22,51 +21,142 @@ import (
 
 const CaddyGroup = "uncloud"
 
-// Controller monitors container changes in the cluster store and generates a configuration file for Caddy reverse
-// proxy. The generated Caddyfile allows Caddy to route external traffic to service containers across the internal
-// network.
+const (
+	// IngressCaddy is the default ingress controller that uses the Caddy reverse proxy.
+	IngressCaddy = "caddy"
+	// IngressTraefik is the ingress controller that uses the Traefik reverse proxy with the file provider.
+	IngressTraefik = "traefik"
+	// IngressStoreKey is the key in the cluster store that holds the ingress controller used in the cluster.
+	IngressStoreKey = "ingress"
+
+	// ingressCheckInterval is how often the controller checks if the ingress controller configured for the cluster
+	// has changed, e.g. after it has been synced to a freshly joined machine.
+	ingressCheckInterval = 30 * time.Second
+)
+
+// ValidateIngress returns an error if the ingress controller is not supported. An empty value means IngressCaddy.
+func ValidateIngress(ingress string) error {
+	switch ingress {
+	case "", IngressCaddy, IngressTraefik:
+		return nil
+	default:
+		return fmt.Errorf("unsupported ingress controller: %q, must be either %q or %q",
+			ingress, IngressCaddy, IngressTraefik)
+	}
+}
+
+// ConfigGenerator generates a configuration for an ingress reverse proxy that routes external traffic to service
+// containers across the internal network.
+type ConfigGenerator interface {
+	// Generate returns the reverse proxy configuration for the given containers.
+	Generate(containers []*api.Container) ([]byte, error)
+}
+
+// Controller monitors container changes in the cluster store and generates a configuration file for the ingress
+// reverse proxy configured for the cluster, Caddy by default. The generated configuration allows the reverse proxy
+// to route external traffic to service containers across the internal network.
 type Controller struct {
 	store *store.Store
-	path  string
+	// caddyTLS defines how the Caddy ingress obtains certificates for HTTPS hostnames.
+	caddyTLS TLSConfig
+	// paths maps the ingress controller names to the paths of their generated configuration files.
+	paths map[string]string
 }
 
-func NewController(store *store.Store, path string) (*Controller, error) {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0750); err != nil {
-		return nil, fmt.Errorf("create parent directory for Caddy configuration '%s': %w", dir, err)
+// NewController creates a new controller that generates the Caddy configuration at caddyPath or the Traefik
+// dynamic configuration at traefikPath depending on the ingress controller configured for the cluster.
+// The Caddy configuration obtains certificates as defined by caddyTLS.
+func NewController(store *store.Store, caddyPath, traefikPath string, caddyTLS TLSConfig) (*Controller, error) {
+	if err := caddyTLS.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid Caddy TLS configuration: %w", err)
 	}
-	if err := fs.Chown(dir, "", CaddyGroup); err != nil {
-		return nil, fmt.Errorf("change owner of parent directory for Caddy configuration '%s': %w", dir, err)
-	}
-
 	return &Controller{
-		store: store,
-		path:  path,
+		store:    store,
+		caddyTLS: caddyTLS,
+		paths: map[string]string{
+			IngressCaddy:   caddyPath,
+			IngressTraefik: traefikPath,
+		},
 	}, nil
 }
 
+// ClusterIngress returns the ingress controller configured for the cluster in the store defaulting to IngressCaddy.
+func ClusterIngress(ctx context.Context, st *store.Store) (string, error) {
+	var ingress string
+	if err := st.Get(ctx, IngressStoreKey, &ingress); err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return IngressCaddy, nil
+		}
+		return "", err
+	}
+	if ingress == "" {
+		return IngressCaddy, nil
+	}
+	if err := ValidateIngress(ingress); err != nil {
+		return "", err
+	}
+	return ingress, nil
+}
+
+// ValidateIngressPort returns an error if the ingress controller can't route traffic for the port published
+// in ingress mode. Caddy only routes HTTP(S) traffic as the standard build doesn't include the layer4 app.
+func ValidateIngressPort(ingress string, port api.PortSpec) error {
+	if port.Mode != api.PortModeIngress || ingress == IngressTraefik {
+		return nil
+	}
+	if port.Protocol == api.ProtocolTCP || port.Protocol == api.ProtocolUDP {
+		return fmt.Errorf("%s ports in %s mode are only supported by the %q ingress but the cluster uses %q, "+
+			"publish the port in %s mode instead", strings.ToUpper(port.Protocol), api.PortModeIngress,
+			IngressTraefik, ingress, api.PortModeHost)
+	}
+	return nil
+}
+
+// Run generates the ingress configuration every time the containers in the cluster change. The ingress controller
+// configured for the cluster is re-resolved on every change and periodically because a freshly joined machine may not
+// have synced it from the cluster store yet. In that case, the Caddy configuration is generated until the configured
+// ingress is synced.
 func (c *Controller) Run(ctx context.Context) error {
 	containerRecords, changes, err := c.store.SubscribeContainers(ctx)
 	if err != nil {
 		return fmt.Errorf("subscribe to container changes: %w", err)
 	}
-	slog.Info("Subscribed to container changes in the cluster to generate Caddy configuration.")
+	slog.Info("Subscribed to container changes in the cluster to generate ingress configuration.")
 
 	containers, err := c.filterAvailableContainers(containerRecords)
 	if err != nil {
 		return fmt.Errorf("filter available containers: %w", err)
 	}
-	if err = c.generateConfig(containers); err != nil {
-		return fmt.Errorf("generate Caddy configuration: %w", err)
-	}
 
+	ticker := time.NewTicker(ingressCheckInterval)
+	defer ticker.Stop()
+	// generated is the ingress controller the configuration has been last generated for.
+	generated := ""
+	// outdated indicates that the containers have changed since the configuration has been last generated.
+	outdated := true
 	for {
+		ingress, err := ClusterIngress(ctx, c.store)
+		if err != nil {
+			slog.Error("Failed to get ingress controller for cluster.", "err", err)
+		} else if outdated || ingress != generated {
+			if err = c.generate(ingress, containers, ingress != generated); err != nil {
+				slog.Error("Failed to generate ingress configuration.", "ingress", ingress, "err", err)
+			} else {
+				if ingress != generated {
+					slog.Info("Generated ingress configuration.", "ingress", ingress, "path", c.paths[ingress])
+				} else {
+					slog.Debug("Updated ingress configuration.", "ingress", ingress, "path", c.paths[ingress])
+				}
+				generated, outdated = ingress, false
+			}
+		}
+
 		select {
 		case _, ok := <-changes:
 			if !ok {
 				return fmt.Errorf("containers subscription failed")
 			}
-			slog.Debug("Cluster containers changed, updating Caddy configuration.")
+			slog.Debug("Cluster containers changed, updating ingress configuration.")
 
 			containerRecords, err = c.store.ListContainers(ctx, store.ListOptions{})
 			if err != nil {
@@ -78,17 +168,35 @@ func (c *Controller) Run(ctx context.Context) error {
 				slog.Error("Failed to filter available containers.", "err", err)
 				continue
 			}
-			if err = c.generateConfig(containers); err != nil {
-				slog.Error("Failed to generate Caddy configuration.", "err", err)
-			}
-
-			slog.Debug("Updated Caddy configuration.", "path", c.path)
+			outdated = true
+		case <-ticker.C:
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
+// generate generates the configuration for the ingress controller and writes it to the configured path.
+// If prepareDir is true, the parent directory of the configuration file is created if it doesn't exist.
+func (c *Controller) generate(ingress string, containers []*api.Container, prepareDir bool) error {
+	var generator ConfigGenerator = &CaddyGenerator{TLS: c.caddyTLS}
+	if ingress == IngressTraefik {
+		generator = &TraefikGenerator{}
+	}
+	path := c.paths[ingress]
+
+	if prepareDir {
+		dir := filepath.Dir(path)
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("create parent directory for %s configuration '%s': %w", ingress, dir, err)
+		}
+		if err := fs.Chown(dir, "", CaddyGroup); err != nil {
+			return fmt.Errorf("change owner of parent directory for %s configuration '%s': %w", ingress, dir, err)
+		}
+	}
+	return c.writeConfig(generator, containers, path)
+}
+
 // filterAvailableContainers filters out containers that are likely unavailable from this machine. The availability
 // is determined by the cluster membership state of the machine that the container is running on.
 // TODO: implement machine membership check using Corrossion Admin client.
@@ -100,10 +208,70 @@ func (c *Controller) filterAvailableContainers(containerRecords []*store.Contain
 	return containers, nil
 }
 
-func (c *Controller) generateConfig(containers []*api.Container) error {
-	// Maps hostnames to lists of upstreams (container IP:port pairs).
-	httpHostUpstreams := make(map[string][]string)
-	httpsHostUpstreams := make(map[string][]string)
+// writeConfig generates the ingress configuration for the containers and writes it to the file at path.
+func (c *Controller) writeConfig(generator ConfigGenerator, containers []*api.Container, path string) error {
+	config, err := generator.Generate(containers)
+	if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(path, config, 0640); err != nil {
+		return fmt.Errorf("write configuration to file '%s': %w", path, err)
+	}
+	if err = fs.Chown(path, "", CaddyGroup); err != nil {
+		return fmt.Errorf("change owner of configuration file '%s': %w", path, err)
+	}
+
+	return nil
+}
+
+// hostUpstreams maps hostnames to lists of upstreams (container IP:port pairs) for HTTP and HTTPS ports
+// published by the containers, and load balancer ports to lists of upstreams for TCP and UDP ports published
+// in ingress mode.
+type hostUpstreams struct {
+	http  map[string][]string
+	https map[string][]string
+	tcp   map[uint16][]string
+	udp   map[uint16][]string
+	// hosts maps HTTP(S) hostnames to the options of routing requests to their upstreams.
+	hosts map[string]hostOptions
+}
+
+// hostOptions are the options of routing HTTP(S) requests for a hostname defined by the services
+// of the upstream containers.
+type hostOptions struct {
+	// sticky means requests from the same client should be routed to the same upstream.
+	sticky bool
+	// healthCheck is the active health check to perform on the upstreams. If nil, only passive health
+	// checking is used.
+	healthCheck *api.IngressHealthCheckSpec
+	// httpsRedirect means plain HTTP requests to the hostname should be redirected to HTTPS if the hostname
+	// has HTTPS upstreams.
+	httpsRedirect bool
+	// basicAuth is the list of users with password hashes allowed to access the hostname. If empty, access
+	// is not restricted.
+	basicAuth []api.BasicAuthUser
+}
+
+// containerIP returns the IP address of the container in the machine subnet that is reachable from other machines.
+// The uncloud Docker network only has IPv6 enabled in IPv6 cluster networks. The containers then also get IPv4
+// addresses from the Docker default address pools that are not routed across the machines.
+func containerIP(network *dnetwork.EndpointSettings) string {
+	if network.GlobalIPv6Address != "" {
+		return network.GlobalIPv6Address
+	}
+	return network.IPAddress
+}
+
+// containersHostUpstreams groups the upstreams of containers' published HTTP and HTTPS ports by hostnames.
+func containersHostUpstreams(containers []*api.Container) hostUpstreams {
+	hu := hostUpstreams{
+		http:  make(map[string][]string),
+		https: make(map[string][]string),
+		tcp:   make(map[uint16][]string),
+		udp:   make(map[uint16][]string),
+		hosts: make(map[string]hostOptions),
+	}
 	for _, ctr := range containers {
 		logger := slog.With("container", ctr.ID)
 		network, ok := ctr.NetworkSettings.Networks[docker.NetworkName]
@@ -111,8 +279,9 @@ func (c *Controller) generateConfig(containers []*api.Container) error {
 			// Container is not connected to the uncloud Docker network (could be host network).
 			continue
 		}
-		if network.IPAddress == "" {
-			logger.Error("Container has no IPv4 address.")
+		ip := containerIP(network)
+		if ip == "" {
+			logger.Error("Container has no IP address.")
 			continue
 		}
 
@@ -122,92 +291,70 @@ func (c *Controller) generateConfig(containers []*api.Container) error {
 			continue
 		}
 
+		opts := hostOptions{sticky: ctr.StickySessions(), httpsRedirect: ctr.HTTPSRedirect()}
+		if opts.healthCheck, err = ctr.IngressHealthCheck(); err != nil {
+			logger.Error("Failed to parse ingress health check for container.", "err", err)
+		}
+		if opts.basicAuth, err = ctr.BasicAuth(); err != nil {
+			// Skip the container to not expose it without authentication.
+			logger.Error("Failed to parse basic auth users for container.", "err", err)
+			continue
+		}
+
 		for _, port := range ports {
 			switch port.Protocol {
 			case api.ProtocolHTTP:
-				upstream := net.JoinHostPort(network.IPAddress, strconv.Itoa(int(port.ContainerPort)))
-				httpHostUpstreams[port.Hostname] = append(httpHostUpstreams[port.Hostname], upstream)
+				upstream := net.JoinHostPort(ip, strconv.Itoa(int(port.ContainerPort)))
+				hu.http[port.Hostname] = append(hu.http[port.Hostname], upstream)
+				hu.addHostOptions(port.Hostname, opts)
 			case api.ProtocolHTTPS:
-				upstream := net.JoinHostPort(network.IPAddress, strconv.Itoa(int(port.ContainerPort)))
-				httpsHostUpstreams[port.Hostname] = append(httpHostUpstreams[port.Hostname], upstream)
-			default:
-				if port.Mode == api.PortModeIngress {
-					// TODO: implement L4 ingress routing for TCP and UDP.
-					logger.Error("Unsupported protocol for ingress port.", "port", port)
+				upstream := net.JoinHostPort(ip, strconv.Itoa(int(port.ContainerPort)))
+				hu.https[port.Hostname] = append(hu.https[port.Hostname], upstream)
+				hu.addHostOptions(port.Hostname, opts)
+			case api.ProtocolTCP, api.ProtocolUDP:
+				if port.Mode != api.PortModeIngress {
 					continue
 				}
+				// The load balancer port defaults to the container port if not specified.
+				lbPort := port.PublishedPort
+				if lbPort == 0 {
+					lbPort = port.ContainerPort
+				}
+				upstream := net.JoinHostPort(ip, strconv.Itoa(int(port.ContainerPort)))
+				if port.Protocol == api.ProtocolTCP {
+					hu.tcp[lbPort] = append(hu.tcp[lbPort], upstream)
+				} else {
+					hu.udp[lbPort] = append(hu.udp[lbPort], upstream)
+				}
 			}
 		}
 	}
+	return hu
+}
 
-	var warnings []caddyconfig.Warning
-	servers := make(map[string]*caddyhttp.Server)
-	servers["http"] = &caddyhttp.Server{
-		Listen: []string{fmt.Sprintf(":%d", caddyhttp.DefaultHTTPPort)},
-		Routes: hostUpstreamsToRoutes(httpHostUpstreams, &warnings),
-	}
-	servers["https"] = &caddyhttp.Server{
-		Listen: []string{fmt.Sprintf(":%d", caddyhttp.DefaultHTTPSPort)},
-		Routes: hostUpstreamsToRoutes(httpsHostUpstreams, &warnings),
-	}
-
-	httpApp := caddyhttp.App{
-		Servers: servers,
-	}
-	config := &caddy.Config{
-		AppsRaw: caddy.ModuleMap{
-			"http": caddyconfig.JSON(httpApp, &warnings),
-		},
-	}
-
-	var err error
-	if len(warnings) > 0 {
-		// warnings only contains errors from JSON marshaling, which are highly unlikely with correct code.
-		for _, w := range warnings {
-			err = errors.Join(err, errors.New(w.Message))
-		}
-		return fmt.Errorf("marshal Caddy configuration: %w", err)
-	}
-
-	configBytes, err := json.MarshalIndent(config, "", "    ")
-	if err != nil {
-		return fmt.Errorf("marshal Caddy configuration: %w", err)
-	}
-
-	if err = os.WriteFile(c.path, configBytes, 0640); err != nil {
-		return fmt.Errorf("write Caddy configuration to file '%s': %w", c.path, err)
+// addHostOptions merges the routing options defined by a container's service into the options for the hostname.
+// Normally, all upstreams of a hostname belong to the same service so the options are the same.
+func (hu *hostUpstreams) addHostOptions(hostname string, ctrOpts hostOptions) {
+	opts := hu.hosts[hostname]
+	opts.sticky = opts.sticky || ctrOpts.sticky
+	opts.httpsRedirect = opts.httpsRedirect || ctrOpts.httpsRedirect
+	if opts.healthCheck == nil {
+		opts.healthCheck = ctrOpts.healthCheck
 	}
-	if err = fs.Chown(c.path, "", CaddyGroup); err != nil {
-		return fmt.Errorf("change owner of Caddy configuration file '%s': %w", c.path, err)
+	if opts.basicAuth == nil {
+		opts.basicAuth = ctrOpts.basicAuth
 	}
-
-	return nil
+	hu.hosts[hostname] = opts
 }
 
-// hostUpstreamsToRoutes converts a map of hostnames to upstreams to a list of Caddy routes.
-func hostUpstreamsToRoutes(hostUpstreams map[string][]string, warnings *[]caddyconfig.Warning) []caddyhttp.Route {
-	routes := make([]caddyhttp.Route, 0, len(hostUpstreams))
-	for hostname, upstreams := range hostUpstreams {
-		upstreamPool := make([]*reverseproxy.Upstream, len(upstreams))
-		for i, upstream := range upstreams {
-			upstreamPool[i] = &reverseproxy.Upstream{
-				Dial: upstream,
-			}
-		}
-		handler := &reverseproxy.Handler{
-			Upstreams: upstreamPool,
+// httpsRedirectHosts returns the HTTPS hostnames that should redirect plain HTTP requests to HTTPS.
+func (hu *hostUpstreams) httpsRedirectHosts() []string {
+	var hosts []string
+	for hostname := range hu.https {
+		if hu.hosts[hostname].httpsRedirect {
+			hosts = append(hosts, hostname)
 		}
-
-		routes = append(routes, caddyhttp.Route{
-			MatcherSetsRaw: caddyhttp.RawMatcherSets{
-				{
-					"host": caddyconfig.JSON(caddyhttp.MatchHost{hostname}, warnings),
-				},
-			},
-			HandlersRaw: []json.RawMessage{
-				caddyconfig.JSONModuleObject(handler, "handler", "reverse_proxy", warnings),
-			},
-		})
-	}
-	return routes
+	}
+	slices.Sort(hosts)
+	return hosts
 }
@@ -0,0 +1,323 @@
+package caddyfile
+
+import (
+	"encoding/json"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"uncloud/internal/api"
+	"uncloud/internal/machine/docker"
+)
+
+func newContainer(id, ip, ports string) *api.Container {
+	return &api.Container{Container: types.Container{
+		ID: id,
+		Labels: map[string]string{
+			api.LabelServiceID:    "service-id",
+			api.LabelServiceName:  "service",
+			api.LabelServicePorts: ports,
+		},
+		NetworkSettings: &types.SummaryNetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				docker.NetworkName: {IPAddress: ip},
+			},
+		},
+	}}
+}
+
+// generateCaddyConfig generates the Caddy configuration for the containers and returns it decoded as generic JSON.
+func generateCaddyConfig(t *testing.T, g *CaddyGenerator, containers ...*api.Container) map[string]any {
+	configBytes, err := g.Generate(containers)
+	require.NoError(t, err)
+
+	var config map[string]any
+	require.NoError(t, json.Unmarshal(configBytes, &config))
+	return config
+}
+
+// jsonValue returns the value at the path of object keys or array indices in the decoded JSON value.
+func jsonValue(t *testing.T, v any, path ...any) any {
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			obj, ok := v.(map[string]any)
+			require.Truef(t, ok, "expected object at %q", key)
+			require.Containsf(t, obj, key, "missing key %q", key)
+			v = obj[key]
+		case int:
+			arr, ok := v.([]any)
+			require.Truef(t, ok, "expected array at index %d", key)
+			require.Lessf(t, key, len(arr), "missing index %d", key)
+			v = arr[key]
+		}
+	}
+	return v
+}
+
+func TestContainersHostUpstreams_HTTPS(t *testing.T) {
+	t.Parallel()
+
+	// HTTPS upstreams used to be appended to the upstreams of the same hostname in the HTTP map.
+	containers := []*api.Container{
+		newContainer("c1", "10.210.0.2", "app.example.com:8080/http,app.example.com:8443/https"),
+		newContainer("c2", "10.210.0.3", "app.example.com:8443/https"),
+	}
+
+	hu := containersHostUpstreams(containers)
+
+	assert.Equal(t, map[string][]string{"app.example.com": {"10.210.0.2:8080"}}, hu.http)
+	assert.Equal(t, map[string][]string{"app.example.com": {"10.210.0.2:8443", "10.210.0.3:8443"}}, hu.https)
+}
+
+func TestContainersHostUpstreams_IPv6(t *testing.T) {
+	t.Parallel()
+
+	// Containers in IPv6 cluster networks also have IPv4 addresses that are not routed across the machines.
+	ctr := newContainer("c1", "172.18.0.2", "app.example.com:8080/http")
+	ctr.NetworkSettings.Networks[docker.NetworkName].GlobalIPv6Address = "fd10:210:0:1::2"
+
+	hu := containersHostUpstreams([]*api.Container{ctr})
+
+	assert.Equal(t, map[string][]string{"app.example.com": {"[fd10:210:0:1::2]:8080"}}, hu.http)
+}
+
+func TestValidateIngressPort(t *testing.T) {
+	t.Parallel()
+
+	for _, protocol := range []string{api.ProtocolTCP, api.ProtocolUDP} {
+		port := api.PortSpec{ContainerPort: 5432, Protocol: protocol, Mode: api.PortModeIngress}
+		assert.Error(t, ValidateIngressPort(IngressCaddy, port), protocol)
+		assert.NoError(t, ValidateIngressPort(IngressTraefik, port), protocol)
+
+		port.Mode = api.PortModeHost
+		assert.NoError(t, ValidateIngressPort(IngressCaddy, port), protocol)
+	}
+
+	port := api.PortSpec{Hostname: "app.example.com", ContainerPort: 8080, Protocol: api.ProtocolHTTPS,
+		Mode: api.PortModeIngress}
+	assert.NoError(t, ValidateIngressPort(IngressCaddy, port))
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  TLSConfig
+		wantErr string
+	}{
+		{name: "default"},
+		{name: "internal", config: TLSConfig{Internal: true}},
+		{name: "cert files", config: TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}},
+		{
+			name:    "cert without key",
+			config:  TLSConfig{CertFile: "cert.pem"},
+			wantErr: "must be specified together",
+		},
+		{
+			name:    "key without cert",
+			config:  TLSConfig{KeyFile: "key.pem"},
+			wantErr: "must be specified together",
+		},
+		{
+			name:    "internal and cert files",
+			config:  TLSConfig{Internal: true, CertFile: "cert.pem", KeyFile: "key.pem"},
+			wantErr: "mutually exclusive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCaddyGenerator_TLS(t *testing.T) {
+	t.Parallel()
+
+	ctr := newContainer("c1", "10.210.0.2", "app.example.com:8080/https")
+
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+
+		// Caddy obtains public certificates with its default ACME issuers if there is no tls app.
+		config := generateCaddyConfig(t, &CaddyGenerator{}, ctr)
+		assert.NotContains(t, jsonValue(t, config, "apps"), "tls")
+	})
+
+	t.Run("internal", func(t *testing.T) {
+		t.Parallel()
+
+		config := generateCaddyConfig(t, &CaddyGenerator{TLS: TLSConfig{Internal: true}}, ctr)
+		policies := jsonValue(t, config, "apps", "tls", "automation", "policies").([]any)
+		require.Len(t, policies, 1)
+		// The policy without subjects applies to all hostnames.
+		assert.Equal(t, map[string]any{"issuers": []any{map[string]any{"module": "internal"}}}, policies[0])
+	})
+
+	t.Run("cert files", func(t *testing.T) {
+		t.Parallel()
+
+		tlsConfig := TLSConfig{CertFile: "/etc/uncloud/cert.pem", KeyFile: "/etc/uncloud/key.pem"}
+		config := generateCaddyConfig(t, &CaddyGenerator{TLS: tlsConfig}, ctr)
+		tlsApp := jsonValue(t, config, "apps", "tls")
+		assert.Equal(t, []any{map[string]any{
+			"certificate": "/etc/uncloud/cert.pem",
+			"key":         "/etc/uncloud/key.pem",
+		}}, jsonValue(t, tlsApp, "certificates", "load_files"))
+		assert.NotContains(t, tlsApp, "automation")
+	})
+}
+
+func TestCaddyGenerator_StickySessions(t *testing.T) {
+	t.Parallel()
+
+	sticky := newContainer("c1", "10.210.0.2", "sticky.example.com:8080/https")
+	sticky.Labels[api.LabelServiceStickySessions] = "true"
+	plain := newContainer("c2", "10.210.0.3", "plain.example.com:8080/https")
+
+	config := generateCaddyConfig(t, &CaddyGenerator{}, sticky, plain)
+	routes := jsonValue(t, config, "apps", "http", "servers", "https", "routes").([]any)
+	require.Len(t, routes, 2)
+
+	for _, route := range routes {
+		host := jsonValue(t, route, "match", 0, "host", 0)
+		handler := jsonValue(t, route, "handle", 0)
+		assert.Equal(t, "reverse_proxy", jsonValue(t, handler, "handler"))
+
+		if host == "sticky.example.com" {
+			assert.Equal(t, map[string]any{"policy": "cookie"},
+				jsonValue(t, handler, "load_balancing", "selection_policy"))
+		} else {
+			assert.NotContains(t, handler, "load_balancing")
+		}
+	}
+}
+
+func TestCaddyGenerator_IngressHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   map[string]any
+	}{
+		{
+			name: "no health check",
+		},
+		{
+			name:   "default interval",
+			labels: map[string]string{api.LabelServiceIngressHealthPath: "/healthz"},
+			want:   map[string]any{"uri": "/healthz"},
+		},
+		{
+			name: "interval",
+			labels: map[string]string{
+				api.LabelServiceIngressHealthPath:     "/healthz",
+				api.LabelServiceIngressHealthInterval: "10s",
+			},
+			// Caddy durations are encoded as nanoseconds.
+			want: map[string]any{"uri": "/healthz", "interval": float64(10 * time.Second)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctr := newContainer("c1", "10.210.0.2", "app.example.com:8080/https")
+			for k, v := range tt.labels {
+				ctr.Labels[k] = v
+			}
+
+			config := generateCaddyConfig(t, &CaddyGenerator{}, ctr)
+			handler := jsonValue(t, config, "apps", "http", "servers", "https", "routes", 0, "handle", 0)
+			if tt.want == nil {
+				assert.NotContains(t, handler, "health_checks")
+				return
+			}
+			assert.Equal(t, tt.want, jsonValue(t, handler, "health_checks", "active"))
+		})
+	}
+}
+
+func TestCaddyGenerator_BasicAuth(t *testing.T) {
+	t.Parallel()
+
+	ctr := newContainer("c1", "10.210.0.2", "app.example.com:8080/http,app.example.com:8443/https")
+	ctr.Labels[api.LabelServiceBasicAuth] = "alice:$2a$14$hash1,bob:$2a$14$hash2"
+
+	config := generateCaddyConfig(t, &CaddyGenerator{}, ctr)
+	for _, server := range []string{"http", "https"} {
+		handlers := jsonValue(t, config, "apps", "http", "servers", server, "routes", 0, "handle").([]any)
+		require.Len(t, handlers, 2, server)
+
+		// The authentication handler must precede the reverse proxy to not expose the upstreams.
+		assert.Equal(t, map[string]any{
+			"handler": "authentication",
+			"providers": map[string]any{
+				"http_basic": map[string]any{
+					"hash": map[string]any{"algorithm": "bcrypt"},
+					"accounts": []any{
+						map[string]any{"username": "alice", "password": "$2a$14$hash1"},
+						map[string]any{"username": "bob", "password": "$2a$14$hash2"},
+					},
+				},
+			},
+		}, handlers[0], server)
+		assert.Equal(t, "reverse_proxy", jsonValue(t, handlers[1], "handler"), server)
+	}
+
+	t.Run("invalid users", func(t *testing.T) {
+		t.Parallel()
+
+		// A container with invalid users is skipped to not expose it without authentication.
+		invalid := newContainer("c2", "10.210.0.3", "app.example.com:8443/https")
+		invalid.Labels[api.LabelServiceBasicAuth] = "alice"
+
+		config := generateCaddyConfig(t, &CaddyGenerator{}, invalid)
+		assert.NotContains(t, jsonValue(t, config, "apps", "http", "servers", "https"), "routes")
+	})
+}
+
+func TestCaddyGenerator_HTTPSRedirect(t *testing.T) {
+	t.Parallel()
+
+	redirect := newContainer("c1", "10.210.0.2", "app.example.com:8080/http,app.example.com:8443/https")
+	redirect.Labels[api.LabelServiceHTTPSRedirect] = "true"
+	// The redirect only applies to the hostnames published over HTTPS.
+	httpOnly := newContainer("c2", "10.210.0.3", "http.example.com:8080/http")
+	httpOnly.Labels[api.LabelServiceHTTPSRedirect] = "true"
+
+	config := generateCaddyConfig(t, &CaddyGenerator{}, redirect, httpOnly)
+	routes := jsonValue(t, config, "apps", "http", "servers", "http", "routes").([]any)
+	// The redirect route replaces the reverse proxy route for the same hostname.
+	require.Len(t, routes, 2)
+
+	assert.Equal(t, map[string]any{
+		"match": []any{map[string]any{"host": []any{"app.example.com"}}},
+		"handle": []any{map[string]any{
+			"handler":     "static_response",
+			"status_code": float64(308),
+			"headers": map[string]any{
+				"Location": []any{"https://{http.request.host}{http.request.uri}"},
+			},
+		}},
+	}, routes[0])
+	assert.Equal(t, "http.example.com", jsonValue(t, routes[1], "match", 0, "host", 0))
+	assert.Equal(t, "reverse_proxy", jsonValue(t, routes[1], "handle", 0, "handler"))
+
+	assert.Equal(t, "reverse_proxy",
+		jsonValue(t, config, "apps", "http", "servers", "https", "routes", 0, "handle", 0, "handler"))
+}
@@ -0,0 +1,63 @@
+package caddyfile
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/modules/caddytls"
+)
+
+// TLSConfig defines how the Caddy ingress obtains certificates for HTTPS hostnames. The zero value means Caddy
+// obtains public certificates with the ACME HTTP-01 and TLS-ALPN-01 challenges that require the machine
+// to be reachable from the internet.
+type TLSConfig struct {
+	// Internal makes Caddy issue certificates from its own locally-trusted CA, e.g. for air-gapped clusters.
+	Internal bool
+	// CertFile and KeyFile are the paths to a PEM-encoded certificate and key provided by the operator to use
+	// for the hostnames the certificate is valid for. The files must be readable by Caddy at these paths.
+	CertFile string
+	KeyFile  string
+}
+
+func (c TLSConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("TLS certificate and key files must be specified together")
+	}
+	if c.Internal && c.CertFile != "" {
+		return errors.New("internal CA and certificate files are mutually exclusive")
+	}
+	return nil
+}
+
+// caddyTLSApp returns a Caddy TLS app config for the TLS configuration or nil if the Caddy defaults should be used.
+func (c TLSConfig) caddyTLSApp(warnings *[]caddyconfig.Warning) *caddytls.TLS {
+	switch {
+	case c.Internal:
+		// Equivalent to the 'tls internal' directive in the Caddyfile.
+		return automationTLSApp(
+			caddyconfig.JSONModuleObject(caddytls.InternalIssuer{}, "module", "internal", warnings),
+		)
+	case c.CertFile != "":
+		// Equivalent to the 'tls <cert_file> <key_file>' directive in the Caddyfile. Caddy doesn't manage
+		// certificates for the hostnames covered by the loaded certificate.
+		files := []caddytls.CertKeyFilePair{{Certificate: c.CertFile, Key: c.KeyFile}}
+		return &caddytls.TLS{
+			CertificatesRaw: caddy.ModuleMap{
+				"load_files": caddyconfig.JSON(files, warnings),
+			},
+		}
+	}
+	return nil
+}
+
+// automationTLSApp returns a Caddy TLS app config that obtains certificates for all hostnames using the issuer.
+func automationTLSApp(issuerRaw json.RawMessage) *caddytls.TLS {
+	return &caddytls.TLS{
+		Automation: &caddytls.AutomationConfig{
+			Policies: []*caddytls.AutomationPolicy{
+				{IssuersRaw: []json.RawMessage{issuerRaw}},
+			},
+		},
+	}
+}
@@ -0,0 +1,271 @@
+package caddyfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"uncloud/internal/api"
+)
+
+const (
+	// TraefikHTTPEntryPoint is the name of the Traefik entry point for HTTP traffic that must be defined
+	// in the Traefik static configuration.
+	TraefikHTTPEntryPoint = "web"
+	// TraefikHTTPSEntryPoint is the name of the Traefik entry point for HTTPS traffic that must be defined
+	// in the Traefik static configuration.
+	TraefikHTTPSEntryPoint = "websecure"
+
+	// traefikHTTPSRedirectMiddleware is the name of the middleware that permanently redirects requests to HTTPS.
+	traefikHTTPSRedirectMiddleware = "https-redirect"
+)
+
+// TraefikTCPEntryPoint returns the name of the Traefik entry point for TCP traffic on the given load balancer port,
+// e.g. "tcp-5432". The entry point must be defined in the Traefik static configuration to route the traffic.
+func TraefikTCPEntryPoint(port uint16) string {
+	return fmt.Sprintf("tcp-%d", port)
+}
+
+// TraefikUDPEntryPoint returns the name of the Traefik entry point for UDP traffic on the given load balancer port,
+// e.g. "udp-27015". The entry point must be defined in the Traefik static configuration to route the traffic.
+//
+// UDP is connectionless, so Traefik tracks sessions by the client address: the first datagram from a client picks
+// an upstream container in a round-robin fashion and subsequent datagrams from the same client go to the same
+// container until the session is idle for the entry point's udp.timeout (3 seconds by default).
+func TraefikUDPEntryPoint(port uint16) string {
+	return fmt.Sprintf("udp-%d", port)
+}
+
+// TraefikGenerator generates a Traefik dynamic configuration for the file provider. The configuration is encoded
+// as JSON which is a valid YAML so Traefik can load it from a file with the .yml extension.
+type TraefikGenerator struct{}
+
+type traefikConfig struct {
+	HTTP traefikHTTP `json:"http"`
+	TCP  *traefikTCP `json:"tcp,omitempty"`
+	UDP  *traefikUDP `json:"udp,omitempty"`
+}
+
+type traefikUDP struct {
+	Routers  map[string]traefikUDPRouter  `json:"routers"`
+	Services map[string]traefikTCPService `json:"services"`
+}
+
+type traefikUDPRouter struct {
+	Service     string   `json:"service"`
+	EntryPoints []string `json:"entryPoints"`
+}
+
+type traefikTCP struct {
+	Routers  map[string]traefikTCPRouter  `json:"routers"`
+	Services map[string]traefikTCPService `json:"services"`
+}
+
+type traefikTCPRouter struct {
+	Rule        string   `json:"rule"`
+	Service     string   `json:"service"`
+	EntryPoints []string `json:"entryPoints"`
+}
+
+// traefikTCPService is a TCP or UDP service. Both have the same load balancer configuration.
+type traefikTCPService struct {
+	LoadBalancer traefikTCPLoadBalancer `json:"loadBalancer"`
+}
+
+type traefikTCPLoadBalancer struct {
+	Servers []traefikTCPServer `json:"servers"`
+}
+
+type traefikTCPServer struct {
+	Address string `json:"address"`
+}
+
+type traefikHTTP struct {
+	Routers     map[string]traefikRouter     `json:"routers,omitempty"`
+	Services    map[string]traefikService    `json:"services,omitempty"`
+	Middlewares map[string]traefikMiddleware `json:"middlewares,omitempty"`
+}
+
+type traefikRouter struct {
+	Rule        string      `json:"rule"`
+	Service     string      `json:"service"`
+	EntryPoints []string    `json:"entryPoints"`
+	Middlewares []string    `json:"middlewares,omitempty"`
+	TLS         *traefikTLS `json:"tls,omitempty"`
+}
+
+type traefikMiddleware struct {
+	BasicAuth      *traefikBasicAuth      `json:"basicAuth,omitempty"`
+	RedirectScheme *traefikRedirectScheme `json:"redirectScheme,omitempty"`
+}
+
+type traefikRedirectScheme struct {
+	Scheme    string `json:"scheme"`
+	Permanent bool   `json:"permanent"`
+}
+
+type traefikBasicAuth struct {
+	// Users is a list of username:password_hash pairs.
+	Users []string `json:"users"`
+}
+
+type traefikTLS struct{}
+
+type traefikService struct {
+	LoadBalancer traefikLoadBalancer `json:"loadBalancer"`
+}
+
+type traefikLoadBalancer struct {
+	Servers []traefikServer `json:"servers"`
+	Sticky  *traefikSticky  `json:"sticky,omitempty"`
+	// HealthCheck enables active health checking of the servers.
+	HealthCheck *traefikHealthCheck `json:"healthCheck,omitempty"`
+}
+
+type traefikHealthCheck struct {
+	Path     string `json:"path"`
+	Interval string `json:"interval,omitempty"`
+}
+
+type traefikSticky struct {
+	Cookie traefikStickyCookie `json:"cookie"`
+}
+
+type traefikStickyCookie struct{}
+
+type traefikServer struct {
+	URL string `json:"url"`
+}
+
+func (g *TraefikGenerator) Generate(containers []*api.Container) ([]byte, error) {
+	hu := containersHostUpstreams(containers)
+
+	config := traefikConfig{
+		HTTP: traefikHTTP{
+			Routers:     make(map[string]traefikRouter),
+			Services:    make(map[string]traefikService),
+			Middlewares: make(map[string]traefikMiddleware),
+		},
+	}
+	redirectHosts := hu.httpsRedirectHosts()
+	addRoutes := func(hostUpstreams map[string][]string, entryPoint string, tls *traefikTLS) {
+		for hostname, upstreams := range hostUpstreams {
+			if tls == nil && slices.Contains(redirectHosts, hostname) {
+				// Plain HTTP requests are redirected to HTTPS instead.
+				continue
+			}
+			name := traefikName(entryPoint, hostname)
+			opts := hu.hosts[hostname]
+			router := traefikRouter{
+				Rule:        fmt.Sprintf("Host(`%s`)", hostname),
+				Service:     name,
+				EntryPoints: []string{entryPoint},
+				TLS:         tls,
+			}
+			if len(opts.basicAuth) > 0 {
+				users := make([]string, len(opts.basicAuth))
+				for i, u := range opts.basicAuth {
+					users[i] = u.Username + ":" + u.PasswordHash
+				}
+				middleware := name + "-auth"
+				config.HTTP.Middlewares[middleware] = traefikMiddleware{
+					BasicAuth: &traefikBasicAuth{Users: users},
+				}
+				router.Middlewares = append(router.Middlewares, middleware)
+			}
+			config.HTTP.Routers[name] = router
+
+			servers := make([]traefikServer, len(upstreams))
+			for i, upstream := range upstreams {
+				// Upstream containers always serve plain HTTP, TLS is terminated by Traefik.
+				servers[i] = traefikServer{URL: "http://" + upstream}
+			}
+			lb := traefikLoadBalancer{Servers: servers}
+			if opts.sticky {
+				lb.Sticky = &traefikSticky{}
+			}
+			if opts.healthCheck != nil {
+				lb.HealthCheck = &traefikHealthCheck{Path: opts.healthCheck.Path}
+				if opts.healthCheck.Interval != 0 {
+					lb.HealthCheck.Interval = opts.healthCheck.Interval.String()
+				}
+			}
+			config.HTTP.Services[name] = traefikService{LoadBalancer: lb}
+		}
+	}
+	addRoutes(hu.http, TraefikHTTPEntryPoint, nil)
+	addRoutes(hu.https, TraefikHTTPSEntryPoint, &traefikTLS{})
+
+	if len(redirectHosts) > 0 {
+		config.HTTP.Middlewares[traefikHTTPSRedirectMiddleware] = traefikMiddleware{
+			RedirectScheme: &traefikRedirectScheme{Scheme: "https", Permanent: true},
+		}
+		for _, hostname := range redirectHosts {
+			config.HTTP.Routers[traefikName(TraefikHTTPEntryPoint, hostname)] = traefikRouter{
+				Rule: fmt.Sprintf("Host(`%s`)", hostname),
+				// The service is never reached because the middleware responds with a redirect but a router
+				// must have one.
+				Service:     traefikName(TraefikHTTPSEntryPoint, hostname),
+				EntryPoints: []string{TraefikHTTPEntryPoint},
+				Middlewares: []string{traefikHTTPSRedirectMiddleware},
+			}
+		}
+	}
+
+	if len(hu.tcp) > 0 {
+		config.TCP = &traefikTCP{
+			Routers:  make(map[string]traefikTCPRouter),
+			Services: make(map[string]traefikTCPService),
+		}
+		for port, upstreams := range hu.tcp {
+			entryPoint := TraefikTCPEntryPoint(port)
+			config.TCP.Routers[entryPoint] = traefikTCPRouter{
+				// Route all non-TLS connections on the entry point to the service.
+				Rule:        "HostSNI(`*`)",
+				Service:     entryPoint,
+				EntryPoints: []string{entryPoint},
+			}
+
+			servers := make([]traefikTCPServer, len(upstreams))
+			for i, upstream := range upstreams {
+				servers[i] = traefikTCPServer{Address: upstream}
+			}
+			config.TCP.Services[entryPoint] = traefikTCPService{
+				LoadBalancer: traefikTCPLoadBalancer{Servers: servers},
+			}
+		}
+	}
+
+	if len(hu.udp) > 0 {
+		config.UDP = &traefikUDP{
+			Routers:  make(map[string]traefikUDPRouter),
+			Services: make(map[string]traefikTCPService),
+		}
+		for port, upstreams := range hu.udp {
+			entryPoint := TraefikUDPEntryPoint(port)
+			config.UDP.Routers[entryPoint] = traefikUDPRouter{
+				Service:     entryPoint,
+				EntryPoints: []string{entryPoint},
+			}
+
+			servers := make([]traefikTCPServer, len(upstreams))
+			for i, upstream := range upstreams {
+				servers[i] = traefikTCPServer{Address: upstream}
+			}
+			config.UDP.Services[entryPoint] = traefikTCPService{
+				LoadBalancer: traefikTCPLoadBalancer{Servers: servers},
+			}
+		}
+	}
+
+	configBytes, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal Traefik configuration: %w", err)
+	}
+	return configBytes, nil
+}
+
+// traefikName returns a name for a Traefik router or service that is unique for the entry point and hostname.
+func traefikName(entryPoint, hostname string) string {
+	return entryPoint + "-" + strings.ReplaceAll(hostname, ".", "-")
+}
@@ -0,0 +1,75 @@
+package caddyfile
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"uncloud/internal/api"
+)
+
+func TestTraefikGenerator_TCP(t *testing.T) {
+	t.Parallel()
+
+	containers := []*api.Container{
+		newContainer("c1", "10.210.0.2", "15432:5432/tcp"),
+		newContainer("c2", "10.210.0.3", "15432:5432/tcp"),
+		// Host mode ports are not routed by the ingress.
+		newContainer("c3", "10.210.0.4", "6379:6379/tcp@host"),
+	}
+
+	g := &TraefikGenerator{}
+	configBytes, err := g.Generate(containers)
+	require.NoError(t, err)
+
+	var config traefikConfig
+	require.NoError(t, json.Unmarshal(configBytes, &config))
+
+	require.NotNil(t, config.TCP)
+	assert.Nil(t, config.UDP)
+	assert.Equal(t, map[string]traefikTCPRouter{
+		"tcp-15432": {
+			Rule:        "HostSNI(`*`)",
+			Service:     "tcp-15432",
+			EntryPoints: []string{"tcp-15432"},
+		},
+	}, config.TCP.Routers)
+	assert.Equal(t, map[string]traefikTCPService{
+		"tcp-15432": {LoadBalancer: traefikTCPLoadBalancer{Servers: []traefikTCPServer{
+			{Address: "10.210.0.2:5432"},
+			{Address: "10.210.0.3:5432"},
+		}}},
+	}, config.TCP.Services)
+}
+
+func TestTraefikGenerator_UDP(t *testing.T) {
+	t.Parallel()
+
+	containers := []*api.Container{
+		newContainer("c1", "10.210.0.2", "27015/udp"),
+		newContainer("c2", "10.210.0.3", "27015/udp,5353:53/udp"),
+	}
+
+	g := &TraefikGenerator{}
+	configBytes, err := g.Generate(containers)
+	require.NoError(t, err)
+
+	var config traefikConfig
+	require.NoError(t, json.Unmarshal(configBytes, &config))
+
+	require.NotNil(t, config.UDP)
+	assert.Nil(t, config.TCP)
+	assert.Equal(t, map[string]traefikUDPRouter{
+		"udp-27015": {Service: "udp-27015", EntryPoints: []string{"udp-27015"}},
+		"udp-5353":  {Service: "udp-5353", EntryPoints: []string{"udp-5353"}},
+	}, config.UDP.Routers)
+	assert.Equal(t, map[string]traefikTCPService{
+		"udp-27015": {LoadBalancer: traefikTCPLoadBalancer{Servers: []traefikTCPServer{
+			{Address: "10.210.0.2:27015"},
+			{Address: "10.210.0.3:27015"},
+		}}},
+		"udp-5353": {LoadBalancer: traefikTCPLoadBalancer{Servers: []traefikTCPServer{
+			{Address: "10.210.0.3:53"},
+		}}},
+	}, config.UDP.Services)
+}
@@ -3,16 +3,19 @@ package cluster
 import (
 	"bytes"
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"log/slog"
 	"net/netip"
 	"time"
 	"uncloud/internal/corrosion"
 	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/caddyfile"
 	"uncloud/internal/machine/network"
 	"uncloud/internal/machine/store"
 	"uncloud/internal/secret"
@@ -39,7 +42,9 @@ func (c *Cluster) UpdateMachineID(mid string) {
 	c.machineID = mid
 }
 
-func (c *Cluster) Init(ctx context.Context, network netip.Prefix) error {
+// Init initialises the cluster state in the store. If clusterSecret is not empty, machines must present
+// the same secret to be added to the cluster.
+func (c *Cluster) Init(ctx context.Context, network netip.Prefix, ingress, clusterSecret string) error {
 	initialised, err := c.Initialised(ctx)
 	if err != nil {
 		return err
@@ -51,12 +56,69 @@ func (c *Cluster) Init(ctx context.Context, network netip.Prefix) error {
 	if err = c.store.Put(ctx, "network", network.String()); err != nil {
 		return fmt.Errorf("put network to store: %w", err)
 	}
+	if ingress == "" {
+		ingress = caddyfile.IngressCaddy
+	}
+	if err = c.store.Put(ctx, caddyfile.IngressStoreKey, ingress); err != nil {
+		return fmt.Errorf("put ingress to store: %w", err)
+	}
+	if clusterSecret != "" {
+		if err = c.initSecret(ctx, clusterSecret); err != nil {
+			return err
+		}
+	}
+	// A new cluster store is created with the latest schema so there is nothing to migrate.
+	if err = c.store.Put(ctx, store.SchemaVersionKey, store.SchemaVersion()); err != nil {
+		return fmt.Errorf("put schema version to store: %w", err)
+	}
 	if err = c.store.Put(ctx, "created_at", time.Now().UTC().Format(time.RFC3339)); err != nil {
 		return fmt.Errorf("put created_at to store: %w", err)
 	}
 	return nil
 }
 
+// CheckInitConfig returns an error if the cluster has been initialised with a different network, ingress,
+// or cluster secret than the given ones. It's used to make sure a resumed initialisation doesn't silently adopt
+// the cluster state left by an initialisation with a different configuration.
+func (c *Cluster) CheckInitConfig(ctx context.Context, network netip.Prefix, ingress, clusterSecret string) error {
+	storedNetwork, err := c.Network(ctx)
+	if err != nil {
+		return err
+	}
+	if storedNetwork.Masked() != network.Masked() {
+		return status.Errorf(codes.FailedPrecondition,
+			"cluster is already initialised with network %s, not %s", storedNetwork, network)
+	}
+
+	if ingress == "" {
+		ingress = caddyfile.IngressCaddy
+	}
+	storedIngress, err := caddyfile.ClusterIngress(ctx, c.store)
+	if err != nil {
+		return status.Errorf(codes.Internal, "get cluster ingress: %v", err)
+	}
+	if storedIngress != ingress {
+		return status.Errorf(codes.FailedPrecondition,
+			"cluster is already initialised with ingress %q, not %q", storedIngress, ingress)
+	}
+
+	salt, key, err := c.secretKey(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case key == nil && clusterSecret != "":
+		return status.Error(codes.FailedPrecondition, "cluster is already initialised without a cluster secret")
+	case key != nil && clusterSecret == "":
+		return status.Error(codes.FailedPrecondition,
+			"cluster is already initialised with a cluster secret, provide the same secret to resume")
+	case key != nil && subtle.ConstantTimeCompare(deriveSecretKey(clusterSecret, salt), key) != 1:
+		return status.Error(codes.FailedPrecondition,
+			"cluster is already initialised with a different cluster secret")
+	}
+	return nil
+}
+
 func (c *Cluster) Initialised(ctx context.Context) (bool, error) {
 	var createdAt string
 	if err := c.store.Get(ctx, "created_at", &createdAt); err != nil {
@@ -100,6 +162,10 @@ func (c *Cluster) AddMachine(ctx context.Context, req *pb.AddMachineRequest) (*p
 	if err := c.checkInitialised(ctx); err != nil {
 		return nil, err
 	}
+	secretSalt, secretKey, err := c.verifySecret(ctx, req.ClusterSecret)
+	if err != nil {
+		return nil, err
+	}
 
 	if req.Network == nil {
 		return nil, status.Error(codes.InvalidArgument, "network not set")
@@ -110,6 +176,9 @@ func (c *Cluster) AddMachine(ctx context.Context, req *pb.AddMachineRequest) (*p
 	if len(req.Network.Endpoints) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "endpoints not set")
 	}
+	if err := pb.ValidateMachineRole(req.Role); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
 	machines, err := c.store.ListMachines(ctx)
 	if err != nil {
@@ -160,9 +229,22 @@ func (c *Cluster) AddMachine(ctx context.Context, req *pb.AddMachineRequest) (*p
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "create IPAM manager: %v", err)
 	}
-	subnet, err := ipam.AllocateSubnetLen(DefaultSubnetBits)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "allocate subnet for machine: %v", err)
+	var subnet netip.Prefix
+	if req.Network.Subnet != nil {
+		// Use the explicitly requested subnet instead of allocating the next available one.
+		subnet, _ = req.Network.Subnet.ToPrefix()
+		if err = ValidateSubnet(subnet, clusterNetwork); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err = ipam.AllocateSubnet(subnet); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"allocate subnet %s in cluster network %s: %v", subnet, clusterNetwork, err)
+		}
+	} else {
+		subnet, err = ipam.AllocateSubnetLen(SubnetBits(clusterNetwork))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "allocate subnet for machine: %v", err)
+		}
 	}
 
 	m := &pb.MachineInfo{
@@ -174,6 +256,10 @@ func (c *Cluster) AddMachine(ctx context.Context, req *pb.AddMachineRequest) (*p
 			Endpoints:    req.Network.Endpoints,
 			PublicKey:    req.Network.PublicKey,
 		},
+		Role: req.Role,
+	}
+	if req.Network.ManualEndpoint != nil {
+		m.Network.SetManualEndpoint(req.Network.ManualEndpoint)
 	}
 	// TODO: announce the new machine to the cluster members and achieve consensus.
 	//  We should perhaps not proceed if this machine is in a minority partition.
@@ -181,9 +267,15 @@ func (c *Cluster) AddMachine(ctx context.Context, req *pb.AddMachineRequest) (*p
 		return nil, status.Errorf(codes.Internal, "create machine: %v", err)
 	}
 	slog.Info("Machine added to the cluster.",
-		"id", m.Id, "name", m.Name, "subnet", subnet, "public_key", secret.Secret(m.Network.PublicKey))
+		"id", m.Id, "name", m.Name, "role", m.MachineRole(), "subnet", subnet,
+		"public_key", secret.Secret(m.Network.PublicKey))
 
 	resp := &pb.AddMachineResponse{Machine: m}
+	if secretKey != nil {
+		// Prove to the machine that it joins the cluster with the same secret it expects.
+		resp.SecretSalt = secretSalt
+		resp.JoinProof = JoinProof(secretKey, m.Id, m.Network.PublicKey)
+	}
 	return resp, nil
 }
 
@@ -203,6 +295,12 @@ func (c *Cluster) ListMachines(ctx context.Context, _ *emptypb.Empty) (*pb.ListM
 		return nil, status.Errorf(codes.Internal, "get cluster membership states: %v", err)
 	}
 
+	handshakes, err := network.PeerLastHandshakes()
+	if err != nil {
+		// The handshake times are informational so don't fail the request if they can't be retrieved.
+		slog.Warn("Failed to get last WireGuard handshakes with peers.", "err", err)
+	}
+
 	members := make([]*pb.MachineMember, len(machines))
 	for i, m := range machines {
 		// If the machine is not in the cluster membership states or its state is not ALIVE or SUSPECT, it is DOWN.
@@ -210,10 +308,15 @@ func (c *Cluster) ListMachines(ctx context.Context, _ *emptypb.Empty) (*pb.ListM
 		state := pb.MachineMember_DOWN
 		addr, _ := m.Network.ManagementIp.ToAddr()
 		for _, s := range states {
-			if s.Addr.Addr().Compare(addr) == 0 &&
-				(s.State == corrosion.MembershipStateAlive || s.State == corrosion.MembershipStateSuspect) {
-				state = pb.MachineMember_UP
-				break
+			if s.Addr.Addr().Compare(addr) == 0 {
+				if s.State == corrosion.MembershipStateAlive {
+					state = pb.MachineMember_UP
+					break
+				}
+				if s.State == corrosion.MembershipStateSuspect {
+					state = pb.MachineMember_SUSPECT
+					break
+				}
 			}
 		}
 		// If the machine is the current machine, it is UP.
@@ -224,11 +327,162 @@ func (c *Cluster) ListMachines(ctx context.Context, _ *emptypb.Empty) (*pb.ListM
 			Machine: m,
 			State:   state,
 		}
+		if t, ok := handshakes[secret.Secret(m.Network.PublicKey).String()]; ok && m.Id != c.machineID {
+			members[i].LastHandshake = timestamppb.New(t)
+		}
 	}
 
 	return &pb.ListMachinesResponse{Machines: members}, nil
 }
 
+// UpdateMachine updates the configuration of a machine in the cluster identified by its name or ID.
+func (c *Cluster) UpdateMachine(ctx context.Context, req *pb.UpdateMachineRequest) (*pb.UpdateMachineResponse, error) {
+	if err := c.checkInitialised(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.Machine == "" {
+		return nil, status.Error(codes.InvalidArgument, "machine not set")
+	}
+	if req.Role != nil {
+		if err := pb.ValidateMachineRole(*req.Role); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if req.ManualEndpoint != nil {
+		if req.ClearManualEndpoint {
+			return nil, status.Error(codes.InvalidArgument, "manual endpoint can't be both set and cleared")
+		}
+		if _, err := req.ManualEndpoint.ToAddrPort(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid manual endpoint: %v", err)
+		}
+	}
+
+	m, err := c.getMachine(ctx, req.Machine)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Role != nil {
+		m.Role = *req.Role
+	}
+	if req.Cordoned != nil {
+		m.Cordoned = *req.Cordoned
+	}
+	if req.ManualEndpoint != nil || req.ClearManualEndpoint {
+		if req.ClearManualEndpoint && len(m.Network.Endpoints) == 1 {
+			return nil, status.Error(codes.FailedPrecondition,
+				"machine has no other endpoints than the manual one, set a new manual endpoint instead")
+		}
+		m.Network.SetManualEndpoint(req.ManualEndpoint)
+	}
+	if err = c.store.UpdateMachine(ctx, m); err != nil {
+		return nil, status.Errorf(codes.Internal, "update machine: %v", err)
+	}
+	slog.Info("Machine updated.", "id", m.Id, "name", m.Name, "role", m.MachineRole(), "cordoned", m.Cordoned)
+
+	return &pb.UpdateMachineResponse{Machine: m}, nil
+}
+
+// UpdateMachineLabels adds, updates, and removes labels of a machine in the cluster identified by its name or ID.
+// Labels are removed after the new ones are set.
+func (c *Cluster) UpdateMachineLabels(
+	ctx context.Context, req *pb.UpdateMachineLabelsRequest,
+) (*pb.UpdateMachineResponse, error) {
+	if err := c.checkInitialised(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.Machine == "" {
+		return nil, status.Error(codes.InvalidArgument, "machine not set")
+	}
+	for k := range req.Set {
+		if err := pb.ValidateMachineLabel(k); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	m, err := c.getMachine(ctx, req.Machine)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Labels == nil && len(req.Set) > 0 {
+		m.Labels = make(map[string]string, len(req.Set))
+	}
+	for k, v := range req.Set {
+		m.Labels[k] = v
+	}
+	for _, k := range req.Remove {
+		delete(m.Labels, k)
+	}
+	if err = c.store.UpdateMachine(ctx, m); err != nil {
+		return nil, status.Errorf(codes.Internal, "update machine: %v", err)
+	}
+	slog.Info("Machine labels updated.", "id", m.Id, "name", m.Name, "labels", m.Labels)
+
+	return &pb.UpdateMachineResponse{Machine: m}, nil
+}
+
+// RemoveMachine removes a machine identified by its name or ID from the cluster along with the records
+// of its containers. The remaining machines remove it from their WireGuard peers once the change is synced.
+func (c *Cluster) RemoveMachine(ctx context.Context, req *pb.RemoveMachineRequest) (*emptypb.Empty, error) {
+	if err := c.checkInitialised(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.Machine == "" {
+		return nil, status.Error(codes.InvalidArgument, "machine not set")
+	}
+	m, err := c.getMachine(ctx, req.Machine)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.store.DeleteMachine(ctx, m.Id); err != nil {
+		return nil, status.Errorf(codes.Internal, "delete machine: %v", err)
+	}
+	slog.Info("Machine removed from the cluster.", "id", m.Id, "name", m.Name)
+
+	return &emptypb.Empty{}, nil
+}
+
+// StoreVersion returns the versions of the changes from each site that the cluster store on the machine has applied.
+func (c *Cluster) StoreVersion(ctx context.Context, _ *emptypb.Empty) (*pb.StoreVersionResponse, error) {
+	versions, err := c.store.DBVersion(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get store version: %v", err)
+	}
+	return &pb.StoreVersionResponse{SiteVersions: versions}, nil
+}
+
+// Ingress returns the ingress controller used in the cluster.
+func (c *Cluster) Ingress(ctx context.Context, _ *emptypb.Empty) (*pb.IngressResponse, error) {
+	if err := c.checkInitialised(ctx); err != nil {
+		return nil, err
+	}
+
+	ingress, err := caddyfile.ClusterIngress(ctx, c.store)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get cluster ingress: %v", err)
+	}
+	return &pb.IngressResponse{Ingress: ingress}, nil
+}
+
+// getMachine returns the machine with the given name or ID from the store.
+func (c *Cluster) getMachine(ctx context.Context, nameOrID string) (*pb.MachineInfo, error) {
+	machines, err := c.store.ListMachines(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list machines: %v", err)
+	}
+	for _, m := range machines {
+		if m.Id == nameOrID || m.Name == nameOrID {
+			return m, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "machine %q not found", nameOrID)
+}
+
 //func (c *Cluster) ListServices(ctx context.Context, _ *emptypb.Empty) (*pb.ListServicesResponse, error) {
 //	if err := c.checkInitialised(ctx); err != nil {
 //		return nil, err
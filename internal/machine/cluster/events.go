@@ -0,0 +1,200 @@
+package cluster
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"slices"
+	"strings"
+	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/store"
+)
+
+const (
+	EventTypeContainer = "container"
+	EventTypeService   = "service"
+	EventTypeMachine   = "machine"
+
+	EventActionCreate = "create"
+	EventActionStart  = "start"
+	EventActionStop   = "stop"
+	EventActionRemove = "remove"
+	EventActionJoin   = "join"
+	EventActionUpdate = "update"
+	EventActionLeave  = "leave"
+)
+
+// Events streams the container, service, and machine events in the cluster. The machines sync the state of their
+// Docker containers to the cluster store on every Docker container event so the events are derived from the changes
+// of the containers and machines in the store. Events that happen while the store is catching up on the changes
+// may be coalesced, e.g. a container that was created and removed in between is not reported.
+func (c *Cluster) Events(req *pb.EventsRequest, stream grpc.ServerStreamingServer[pb.Event]) error {
+	ctx := stream.Context()
+	if err := c.checkInitialised(ctx); err != nil {
+		return err
+	}
+	for _, t := range req.Types {
+		if t != EventTypeContainer && t != EventTypeService && t != EventTypeMachine {
+			return status.Errorf(codes.InvalidArgument, "invalid event type: %q", t)
+		}
+	}
+
+	machines, machineChanges, err := c.store.SubscribeMachines(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "subscribe to machine changes: %v", err)
+	}
+	containers, containerChanges, err := c.store.SubscribeContainers(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "subscribe to container changes: %v", err)
+	}
+
+	for {
+		var events []*pb.Event
+		select {
+		case _, ok := <-machineChanges:
+			if !ok {
+				return status.Error(codes.Unavailable, "machines subscription failed")
+			}
+			current, err := c.store.ListMachines(ctx)
+			if err != nil {
+				return status.Errorf(codes.Internal, "list machines: %v", err)
+			}
+			events = machineEvents(machines, current)
+			machines = current
+		case _, ok := <-containerChanges:
+			if !ok {
+				return status.Error(codes.Unavailable, "containers subscription failed")
+			}
+			current, err := c.store.ListContainers(ctx, store.ListOptions{})
+			if err != nil {
+				return status.Errorf(codes.Internal, "list containers: %v", err)
+			}
+			events = containerEvents(containers, current)
+			containers = current
+		case <-ctx.Done():
+			return nil
+		}
+
+		now := timestamppb.Now()
+		for _, e := range events {
+			if len(req.Types) > 0 && !slices.Contains(req.Types, e.Type) {
+				continue
+			}
+			e.Time = now
+			if err = stream.Send(e); err != nil {
+				return status.Errorf(codes.Internal, "send event to stream: %v", err)
+			}
+		}
+	}
+}
+
+// machineEvents returns the events for the changes between the previous and current lists of machines.
+func machineEvents(previous, current []*pb.MachineInfo) []*pb.Event {
+	prevByID := make(map[string]*pb.MachineInfo, len(previous))
+	for _, m := range previous {
+		prevByID[m.Id] = m
+	}
+
+	var events []*pb.Event
+	for _, m := range current {
+		action := ""
+		if prev, ok := prevByID[m.Id]; !ok {
+			action = EventActionJoin
+		} else if !proto.Equal(prev, m) {
+			action = EventActionUpdate
+		}
+		delete(prevByID, m.Id)
+		if action != "" {
+			events = append(events, &pb.Event{Type: EventTypeMachine, Action: action, Id: m.Id, Name: m.Name})
+		}
+	}
+	for _, m := range previous {
+		if _, ok := prevByID[m.Id]; ok {
+			events = append(events, &pb.Event{
+				Type:   EventTypeMachine,
+				Action: EventActionLeave,
+				Id:     m.Id,
+				Name:   m.Name,
+			})
+		}
+	}
+	return events
+}
+
+// containerEvents returns the container and service events for the changes between the previous and current lists
+// of containers. A service is created when its first container appears and removed when its last container is gone.
+func containerEvents(previous, current []*store.ContainerRecord) []*pb.Event {
+	prevByID := make(map[string]*store.ContainerRecord, len(previous))
+	prevServices := make(map[string]struct{})
+	for _, cr := range previous {
+		prevByID[cr.Container.ID] = cr
+		prevServices[cr.Container.ServiceID()] = struct{}{}
+	}
+	currentIDs := make(map[string]struct{}, len(current))
+	currentServices := make(map[string]struct{})
+	for _, cr := range current {
+		currentIDs[cr.Container.ID] = struct{}{}
+		currentServices[cr.Container.ServiceID()] = struct{}{}
+	}
+
+	var events []*pb.Event
+	for _, cr := range current {
+		if _, ok := prevServices[cr.Container.ServiceID()]; !ok {
+			events = append(events, serviceEvent(cr, EventActionCreate))
+			prevServices[cr.Container.ServiceID()] = struct{}{}
+		}
+
+		running := cr.Container.State == "running"
+		prev, ok := prevByID[cr.Container.ID]
+		switch {
+		case !ok:
+			events = append(events, containerEvent(cr, EventActionCreate))
+			if running {
+				events = append(events, containerEvent(cr, EventActionStart))
+			}
+		case running && prev.Container.State != "running":
+			events = append(events, containerEvent(cr, EventActionStart))
+		case !running && prev.Container.State == "running":
+			events = append(events, containerEvent(cr, EventActionStop))
+		}
+	}
+
+	for _, cr := range previous {
+		if _, ok := currentIDs[cr.Container.ID]; ok {
+			continue
+		}
+		events = append(events, containerEvent(cr, EventActionRemove))
+		if _, ok := currentServices[cr.Container.ServiceID()]; !ok {
+			events = append(events, serviceEvent(cr, EventActionRemove))
+			currentServices[cr.Container.ServiceID()] = struct{}{}
+		}
+	}
+	return events
+}
+
+func containerEvent(cr *store.ContainerRecord, action string) *pb.Event {
+	name := cr.Container.ID
+	if len(cr.Container.Names) > 0 {
+		name = strings.TrimPrefix(cr.Container.Names[0], "/")
+	}
+	return &pb.Event{
+		Type:        EventTypeContainer,
+		Action:      action,
+		Id:          cr.Container.ID,
+		Name:        name,
+		MachineId:   cr.MachineID,
+		ServiceId:   cr.Container.ServiceID(),
+		ServiceName: cr.Container.ServiceName(),
+	}
+}
+
+func serviceEvent(cr *store.ContainerRecord, action string) *pb.Event {
+	return &pb.Event{
+		Type:   EventTypeService,
+		Action: action,
+		Id:     cr.Container.ServiceID(),
+		Name:   cr.Container.ServiceName(),
+	}
+}
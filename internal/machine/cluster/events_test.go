@@ -0,0 +1,225 @@
+package cluster
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"uncloud/internal/api"
+	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/store"
+)
+
+func containerRecord(id, serviceID, state, status string) *store.ContainerRecord {
+	return &store.ContainerRecord{
+		Container: &api.Container{Container: types.Container{
+			ID:    id,
+			Names: []string{"/" + serviceID + "-" + id},
+			Labels: map[string]string{
+				api.LabelServiceID:   serviceID,
+				api.LabelServiceName: serviceID + "-name",
+			},
+			State:  state,
+			Status: status,
+		}},
+		MachineID: "machine",
+	}
+}
+
+func TestContainerEvents(t *testing.T) {
+	t.Parallel()
+
+	ctrEvent := func(id, serviceID, action string) *pb.Event {
+		return &pb.Event{
+			Type:        EventTypeContainer,
+			Action:      action,
+			Id:          id,
+			Name:        serviceID + "-" + id,
+			MachineId:   "machine",
+			ServiceId:   serviceID,
+			ServiceName: serviceID + "-name",
+		}
+	}
+	svcEvent := func(serviceID, action string) *pb.Event {
+		return &pb.Event{Type: EventTypeService, Action: action, Id: serviceID, Name: serviceID + "-name"}
+	}
+
+	tests := []struct {
+		name     string
+		previous []*store.ContainerRecord
+		current  []*store.ContainerRecord
+		want     []*pb.Event
+	}{
+		{
+			name: "no changes",
+			previous: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 1 minute"),
+			},
+			current: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 1 minute"),
+			},
+		},
+		{
+			name: "first running container creates service",
+			current: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 1 second"),
+			},
+			want: []*pb.Event{
+				svcEvent("s1", EventActionCreate),
+				ctrEvent("c1", "s1", EventActionCreate),
+				ctrEvent("c1", "s1", EventActionStart),
+			},
+		},
+		{
+			name: "created container is not started",
+			previous: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 1 minute"),
+			},
+			current: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 1 minute"),
+				containerRecord("c2", "s1", "created", "Created"),
+			},
+			want: []*pb.Event{
+				ctrEvent("c2", "s1", EventActionCreate),
+			},
+		},
+		{
+			name: "container started",
+			previous: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "created", "Created"),
+			},
+			current: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 1 second"),
+			},
+			want: []*pb.Event{
+				ctrEvent("c1", "s1", EventActionStart),
+			},
+		},
+		{
+			name: "container stopped",
+			previous: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 1 minute"),
+			},
+			current: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "exited", "Exited (0) 1 second ago"),
+			},
+			want: []*pb.Event{
+				ctrEvent("c1", "s1", EventActionStop),
+			},
+		},
+		{
+			name: "state change without restart",
+			previous: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 1 minute (health: starting)"),
+				containerRecord("c2", "s1", "created", "Created"),
+			},
+			current: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 2 minutes (healthy)"),
+				containerRecord("c2", "s1", "exited", "Exited (1) 1 second ago"),
+			},
+		},
+		{
+			name: "container removed from service with other containers",
+			previous: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 1 minute"),
+				containerRecord("c2", "s1", "running", "Up 1 minute"),
+			},
+			current: []*store.ContainerRecord{
+				containerRecord("c2", "s1", "running", "Up 1 minute"),
+			},
+			want: []*pb.Event{
+				ctrEvent("c1", "s1", EventActionRemove),
+			},
+		},
+		{
+			name: "last containers removed removes service once",
+			previous: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 1 minute"),
+				containerRecord("c2", "s1", "exited", "Exited (0) 1 minute ago"),
+				containerRecord("c3", "s2", "running", "Up 1 minute"),
+			},
+			current: []*store.ContainerRecord{
+				containerRecord("c3", "s2", "running", "Up 1 minute"),
+			},
+			want: []*pb.Event{
+				ctrEvent("c1", "s1", EventActionRemove),
+				svcEvent("s1", EventActionRemove),
+				ctrEvent("c2", "s1", EventActionRemove),
+			},
+		},
+		{
+			name: "container replaced in the same service",
+			previous: []*store.ContainerRecord{
+				containerRecord("c1", "s1", "running", "Up 1 minute"),
+			},
+			current: []*store.ContainerRecord{
+				containerRecord("c2", "s1", "running", "Up 1 second"),
+			},
+			want: []*pb.Event{
+				ctrEvent("c2", "s1", EventActionCreate),
+				ctrEvent("c2", "s1", EventActionStart),
+				ctrEvent("c1", "s1", EventActionRemove),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, containerEvents(tt.previous, tt.current))
+		})
+	}
+}
+
+func TestMachineEvents(t *testing.T) {
+	t.Parallel()
+
+	m1 := &pb.MachineInfo{Id: "m1", Name: "machine-1"}
+	m1Renamed := &pb.MachineInfo{Id: "m1", Name: "machine-1-renamed"}
+	m2 := &pb.MachineInfo{Id: "m2", Name: "machine-2"}
+
+	tests := []struct {
+		name     string
+		previous []*pb.MachineInfo
+		current  []*pb.MachineInfo
+		want     []*pb.Event
+	}{
+		{
+			name:     "no changes",
+			previous: []*pb.MachineInfo{m1, m2},
+			current:  []*pb.MachineInfo{m1, m2},
+		},
+		{
+			name:     "machine joined",
+			previous: []*pb.MachineInfo{m1},
+			current:  []*pb.MachineInfo{m1, m2},
+			want: []*pb.Event{
+				{Type: EventTypeMachine, Action: EventActionJoin, Id: "m2", Name: "machine-2"},
+			},
+		},
+		{
+			name:     "machine updated",
+			previous: []*pb.MachineInfo{m1, m2},
+			current:  []*pb.MachineInfo{m1Renamed, m2},
+			want: []*pb.Event{
+				{Type: EventTypeMachine, Action: EventActionUpdate, Id: "m1", Name: "machine-1-renamed"},
+			},
+		},
+		{
+			name:     "machine left",
+			previous: []*pb.MachineInfo{m1, m2},
+			current:  []*pb.MachineInfo{m2},
+			want: []*pb.Event{
+				{Type: EventTypeMachine, Action: EventActionLeave, Id: "m1", Name: "machine-1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, machineEvents(tt.previous, tt.current))
+		})
+	}
+}
@@ -7,10 +7,57 @@ import (
 	"net/netip"
 )
 
-const DefaultSubnetBits = 24
+const (
+	DefaultSubnetBits = 24
+	// DefaultSubnetBitsIPv6 is the prefix length of the machine subnets allocated from an IPv6 cluster network.
+	DefaultSubnetBitsIPv6 = 64
+)
 
 var DefaultNetwork = netip.MustParsePrefix("10.210.0.0/16")
 
+// SubnetBits returns the prefix length of the machine subnets allocated from the cluster network.
+func SubnetBits(network netip.Prefix) int {
+	if network.Addr().Is6() {
+		return DefaultSubnetBitsIPv6
+	}
+	return DefaultSubnetBits
+}
+
+// ValidateNetwork returns an error if machine subnets can't be allocated from the cluster network.
+func ValidateNetwork(network netip.Prefix) error {
+	if !network.IsValid() || network.Bits() == 0 {
+		return errors.New("invalid network")
+	}
+	if network.Addr().Is4In6() {
+		return fmt.Errorf("invalid network %s: IPv4-mapped IPv6 networks are not supported", network)
+	}
+	if bits := SubnetBits(network); network.Bits() > bits {
+		return fmt.Errorf("invalid network %s: prefix length must be at most /%d to allocate /%d machine subnets",
+			network, bits, bits)
+	}
+	return nil
+}
+
+// ValidateSubnet returns an error if the subnet can't be used as a machine subnet within the cluster network.
+// The subnet must leave room for the network address, the machine IP, at least one container IP, and the broadcast
+// address, so the longest allowed prefix is /30 for IPv4.
+func ValidateSubnet(subnet, clusterNetwork netip.Prefix) error {
+	if !subnet.IsValid() {
+		return errors.New("invalid subnet")
+	}
+	if subnet != subnet.Masked() {
+		return fmt.Errorf("invalid subnet %s: must be a network address, e.g. %s", subnet, subnet.Masked())
+	}
+	if !clusterNetwork.Contains(subnet.Addr()) || subnet.Bits() < clusterNetwork.Bits() {
+		return fmt.Errorf("invalid subnet %s: must be within the cluster network %s", subnet, clusterNetwork)
+	}
+	if maxBits := subnet.Addr().BitLen() - 2; subnet.Bits() > maxBits {
+		return fmt.Errorf("invalid subnet %s: prefix length must be at most /%d to fit the machine and container IPs",
+			subnet, maxBits)
+	}
+	return nil
+}
+
 // IPAM is an in-memory IP address manager for allocating and releasing subnets for machines from a cluster network.
 type IPAM struct {
 	network   netip.Prefix
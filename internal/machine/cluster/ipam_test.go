@@ -0,0 +1,97 @@
+package cluster
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/netip"
+	"testing"
+)
+
+func TestValidateSubnet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		subnet  string
+		wantErr string
+	}{
+		{subnet: "10.210.1.0/24"},
+		{subnet: "10.210.0.0/16"},
+		{subnet: "10.210.1.4/30"},
+		{subnet: "10.210.1.1/24", wantErr: "must be a network address, e.g. 10.210.1.0/24"},
+		{subnet: "10.211.1.0/24", wantErr: "must be within the cluster network 10.210.0.0/16"},
+		{subnet: "10.208.0.0/14", wantErr: "must be within the cluster network 10.210.0.0/16"},
+		{subnet: "10.210.1.0/31", wantErr: "prefix length must be at most /30"},
+		{subnet: "10.210.1.1/32", wantErr: "prefix length must be at most /30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subnet, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateSubnet(netip.MustParsePrefix(tt.subnet), DefaultNetwork)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSubnet_IPv6(t *testing.T) {
+	t.Parallel()
+
+	network := netip.MustParsePrefix("fd10:210::/48")
+
+	assert.NoError(t, ValidateSubnet(netip.MustParsePrefix("fd10:210:0:1::/64"), network))
+	assert.NoError(t, ValidateSubnet(netip.MustParsePrefix("fd10:210:0:1::/126"), network))
+	assert.ErrorContains(t, ValidateSubnet(netip.MustParsePrefix("fd10:210:0:1::/127"), network),
+		"prefix length must be at most /126")
+	assert.ErrorContains(t, ValidateSubnet(netip.MustParsePrefix("fd10:211::/64"), network),
+		"must be within the cluster network")
+	assert.ErrorContains(t, ValidateSubnet(netip.MustParsePrefix("10.210.1.0/24"), network),
+		"must be within the cluster network")
+}
+
+func TestValidateNetwork(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		network string
+		wantErr string
+	}{
+		{network: "10.210.0.0/16"},
+		{network: "10.210.0.0/24"},
+		{network: "fd10:210::/48"},
+		{network: "fd10:210::/64"},
+		{network: "10.210.0.0/25", wantErr: "prefix length must be at most /24"},
+		{network: "fd10:210::/80", wantErr: "prefix length must be at most /64"},
+		{network: "::ffff:10.210.0.0/112", wantErr: "IPv4-mapped IPv6 networks are not supported"},
+		{network: "0.0.0.0/0", wantErr: "invalid network"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.network, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateNetwork(netip.MustParsePrefix(tt.network))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIPAM_AllocateSubnetLen_IPv6(t *testing.T) {
+	t.Parallel()
+
+	network := netip.MustParsePrefix("fd10:210::/48")
+	ipam, err := NewIPAMWithAllocated(network, []netip.Prefix{netip.MustParsePrefix("fd10:210::/64")})
+	require.NoError(t, err)
+
+	subnet, err := ipam.AllocateSubnetLen(SubnetBits(network))
+	require.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("fd10:210:0:1::/64"), subnet)
+}
@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"uncloud/internal/machine/store"
+)
+
+const (
+	// SecretSaltKey is the key in the cluster store of the hex-encoded random salt used to derive the cluster key
+	// from the pre-shared cluster secret.
+	SecretSaltKey = "secret_salt"
+	// SecretKeyKey is the key in the cluster store of the hex-encoded cluster key derived from the pre-shared
+	// cluster secret. The secret itself is never stored.
+	SecretKeyKey = "secret_key"
+
+	secretSaltLen = 16
+	secretKeyLen  = 32
+)
+
+// ErrInvalidJoinProof is returned when the cluster fails to prove that it knows the cluster secret.
+var ErrInvalidJoinProof = errors.New("cluster failed to prove it knows the cluster secret")
+
+// deriveSecretKey derives the cluster key from the pre-shared cluster secret and salt using Argon2id so that
+// the secret can't be practically brute-forced from the key stored in the cluster.
+func deriveSecretKey(clusterSecret string, salt []byte) []byte {
+	return argon2.IDKey([]byte(clusterSecret), salt, 1, 64*1024, 4, secretKeyLen)
+}
+
+// JoinProof returns an HMAC of the machine ID and WireGuard public key with the cluster key. It's bound to the public
+// key so that a proof captured for one machine can't be used to make another machine join a rogue cluster.
+func JoinProof(key []byte, machineID string, publicKey []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("uncloud-join\x00" + machineID + "\x00"))
+	mac.Write(publicKey)
+	return mac.Sum(nil)
+}
+
+// VerifyJoinProof checks that the join proof was produced by a cluster that knows the given cluster secret.
+func VerifyJoinProof(clusterSecret string, salt []byte, machineID string, publicKey, proof []byte) error {
+	if len(salt) == 0 || len(proof) == 0 {
+		return ErrInvalidJoinProof
+	}
+	want := JoinProof(deriveSecretKey(clusterSecret, salt), machineID, publicKey)
+	if !hmac.Equal(want, proof) {
+		return ErrInvalidJoinProof
+	}
+	return nil
+}
+
+// initSecret generates a random salt and stores it with the cluster key derived from the cluster secret.
+func (c *Cluster) initSecret(ctx context.Context, clusterSecret string) error {
+	salt := make([]byte, secretSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("generate salt: %w", err)
+	}
+	if err := c.store.Put(ctx, SecretSaltKey, hex.EncodeToString(salt)); err != nil {
+		return fmt.Errorf("put secret salt to store: %w", err)
+	}
+	key := deriveSecretKey(clusterSecret, salt)
+	if err := c.store.Put(ctx, SecretKeyKey, hex.EncodeToString(key)); err != nil {
+		return fmt.Errorf("put secret key to store: %w", err)
+	}
+	return nil
+}
+
+// secretKey returns the salt and cluster key from the store or nil if the cluster was initialised without a secret.
+func (c *Cluster) secretKey(ctx context.Context) (salt, key []byte, err error) {
+	var saltHex, keyHex string
+	if err = c.store.Get(ctx, SecretSaltKey, &saltHex); err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			return nil, nil, nil
+		}
+		return nil, nil, status.Errorf(codes.Internal, "get secret salt from store: %v", err)
+	}
+	if err = c.store.Get(ctx, SecretKeyKey, &keyHex); err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "get secret key from store: %v", err)
+	}
+	if salt, err = hex.DecodeString(saltHex); err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "decode secret salt: %v", err)
+	}
+	if key, err = hex.DecodeString(keyHex); err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "decode secret key: %v", err)
+	}
+	return salt, key, nil
+}
+
+// verifySecret checks that the given secret matches the pre-shared cluster secret if the cluster has one.
+// It returns the salt and cluster key or nil if the cluster was initialised without a secret.
+func (c *Cluster) verifySecret(ctx context.Context, clusterSecret string) (salt, key []byte, err error) {
+	salt, key, err = c.secretKey(ctx)
+	if err != nil || key == nil {
+		return nil, nil, err
+	}
+	if clusterSecret == "" {
+		return nil, nil, status.Error(codes.PermissionDenied, "cluster requires a secret to add machines")
+	}
+	if subtle.ConstantTimeCompare(deriveSecretKey(clusterSecret, salt), key) != 1 {
+		return nil, nil, status.Error(codes.PermissionDenied, "invalid cluster secret")
+	}
+	return salt, key, nil
+}
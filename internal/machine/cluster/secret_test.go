@@ -0,0 +1,24 @@
+package cluster
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVerifyJoinProof(t *testing.T) {
+	t.Parallel()
+
+	salt := []byte("0123456789abcdef")
+	publicKey := []byte("public-key")
+	proof := JoinProof(deriveSecretKey("secret", salt), "machine-id", publicKey)
+
+	assert.NoError(t, VerifyJoinProof("secret", salt, "machine-id", publicKey, proof))
+	assert.ErrorIs(t, VerifyJoinProof("wrong", salt, "machine-id", publicKey, proof), ErrInvalidJoinProof)
+	assert.ErrorIs(t, VerifyJoinProof("secret", []byte("other-salt"), "machine-id", publicKey, proof),
+		ErrInvalidJoinProof, "different salt")
+	assert.ErrorIs(t, VerifyJoinProof("secret", salt, "other-id", publicKey, proof), ErrInvalidJoinProof)
+	assert.ErrorIs(t, VerifyJoinProof("secret", salt, "machine-id", []byte("other-key"), proof),
+		ErrInvalidJoinProof, "proof is bound to the public key")
+	assert.ErrorIs(t, VerifyJoinProof("secret", salt, "machine-id", publicKey, nil), ErrInvalidJoinProof,
+		"cluster without a secret")
+}
@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"log/slog"
+	"uncloud/internal/machine/api/pb"
+	"uncloud/internal/machine/store"
+)
+
+// CreateServiceSpec stores the desired spec of a service in the cluster store. The spec is stored independently of
+// the service containers which can be created from it later. The service name must be unique among the stored specs.
+func (c *Cluster) CreateServiceSpec(ctx context.Context, req *pb.ServiceSpecRecord) (*emptypb.Empty, error) {
+	if err := c.checkInitialised(ctx); err != nil {
+		return nil, err
+	}
+	if req.Id == "" || req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "service ID and name must be set")
+	}
+	if !json.Valid(req.Spec) {
+		return nil, status.Error(codes.InvalidArgument, "service spec is not valid JSON")
+	}
+
+	// The store can't enforce unique names so check them optimistically.
+	for _, idOrName := range []string{req.Id, req.Name} {
+		if _, err := c.store.GetServiceSpec(ctx, idOrName); err == nil {
+			return nil, status.Errorf(codes.AlreadyExists, "service spec %q already exists", idOrName)
+		} else if !errors.Is(err, store.ErrServiceSpecNotFound) {
+			return nil, status.Errorf(codes.Internal, "get service spec: %v", err)
+		}
+	}
+
+	r := store.ServiceSpecRecord{ID: req.Id, Name: req.Name, Spec: string(req.Spec)}
+	if err := c.store.CreateServiceSpec(ctx, r); err != nil {
+		return nil, status.Errorf(codes.Internal, "create service spec: %v", err)
+	}
+	slog.Info("Service spec created.", "id", req.Id, "name", req.Name)
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetServiceSpec returns the desired spec of the service with the given ID or name from the cluster store.
+func (c *Cluster) GetServiceSpec(ctx context.Context, req *pb.GetServiceSpecRequest) (*pb.ServiceSpecRecord, error) {
+	if err := c.checkInitialised(ctx); err != nil {
+		return nil, err
+	}
+	if req.Service == "" {
+		return nil, status.Error(codes.InvalidArgument, "service not set")
+	}
+
+	r, err := c.store.GetServiceSpec(ctx, req.Service)
+	if err != nil {
+		if errors.Is(err, store.ErrServiceSpecNotFound) {
+			return nil, status.Errorf(codes.NotFound, "service spec %q not found", req.Service)
+		}
+		return nil, status.Errorf(codes.Internal, "get service spec: %v", err)
+	}
+	return serviceSpecRecordToProto(r), nil
+}
+
+// ListServiceSpecs returns the desired specs of all services stored in the cluster store.
+func (c *Cluster) ListServiceSpecs(ctx context.Context, _ *emptypb.Empty) (*pb.ListServiceSpecsResponse, error) {
+	if err := c.checkInitialised(ctx); err != nil {
+		return nil, err
+	}
+
+	records, err := c.store.ListServiceSpecs(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list service specs: %v", err)
+	}
+	resp := &pb.ListServiceSpecsResponse{Specs: make([]*pb.ServiceSpecRecord, len(records))}
+	for i, r := range records {
+		resp.Specs[i] = serviceSpecRecordToProto(r)
+	}
+	return resp, nil
+}
+
+// DeleteServiceSpec deletes the desired spec of the service with the given ID or name from the cluster store.
+// The service containers are not affected.
+func (c *Cluster) DeleteServiceSpec(ctx context.Context, req *pb.GetServiceSpecRequest) (*emptypb.Empty, error) {
+	if err := c.checkInitialised(ctx); err != nil {
+		return nil, err
+	}
+	if req.Service == "" {
+		return nil, status.Error(codes.InvalidArgument, "service not set")
+	}
+
+	r, err := c.store.GetServiceSpec(ctx, req.Service)
+	if err != nil {
+		if errors.Is(err, store.ErrServiceSpecNotFound) {
+			return nil, status.Errorf(codes.NotFound, "service spec %q not found", req.Service)
+		}
+		return nil, status.Errorf(codes.Internal, "get service spec: %v", err)
+	}
+	if err = c.store.DeleteServiceSpec(ctx, r.ID); err != nil {
+		return nil, status.Errorf(codes.Internal, "delete service spec: %v", err)
+	}
+	slog.Info("Service spec deleted.", "id", r.ID, "name", r.Name)
+
+	return &emptypb.Empty{}, nil
+}
+
+func serviceSpecRecordToProto(r store.ServiceSpecRecord) *pb.ServiceSpecRecord {
+	return &pb.ServiceSpecRecord{Id: r.ID, Name: r.Name, Spec: []byte(r.Spec)}
+}
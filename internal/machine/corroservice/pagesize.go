@@ -0,0 +1,49 @@
+package corroservice
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	// jemallocPageSize is the page size the jemalloc allocator in the default corrosion binaries is built for.
+	// jemalloc aborts with "Unsupported system page size" on systems with larger pages, for example, on Raspberry
+	// Pi 5 that runs a kernel with 16 KiB pages by default.
+	jemallocPageSize = 4 << 10
+	// LargePageSuffix is appended to the corrosion command to get the binary built with jemalloc for 64 KiB pages.
+	// Such a binary runs on systems with any page size up to 64 KiB.
+	LargePageSuffix = "-64k"
+)
+
+var ErrUnsupportedPageSize = errors.New("unsupported system page size")
+
+// CheckPageSize returns an ErrUnsupportedPageSize error with a diagnostic if the default corrosion binary can't run
+// on the system because of the kernel page size.
+func CheckPageSize() error {
+	return checkPageSize(os.Getpagesize())
+}
+
+func checkPageSize(pageSize int) error {
+	if pageSize <= jemallocPageSize {
+		return nil
+	}
+	return fmt.Errorf("%w: the kernel uses %d KiB pages but corrosion is built with jemalloc for %d KiB pages "+
+		"and crashes on start. Install the corrosion binary built for large pages with the '%s' suffix or boot "+
+		"the kernel with 4 KiB pages (on Raspberry Pi 5, add 'kernel=kernel8.img' to /boot/firmware/config.txt "+
+		"and reboot)", ErrUnsupportedPageSize, pageSize>>10, jemallocPageSize>>10, LargePageSuffix)
+}
+
+// commandForPageSize returns the corrosion command that can run on a system with the given page size. It falls back
+// to the large page variant of the command if the default binary doesn't support the page size. lookPath is used
+// to check if the large page variant is installed.
+func commandForPageSize(command string, pageSize int, lookPath func(string) (string, error)) (string, error) {
+	err := checkPageSize(pageSize)
+	if err == nil {
+		return command, nil
+	}
+	if _, lookErr := lookPath(command + LargePageSuffix); lookErr != nil {
+		return "", err
+	}
+	return command + LargePageSuffix, nil
+}
@@ -0,0 +1,93 @@
+package corroservice
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os/exec"
+	"testing"
+)
+
+func TestCheckPageSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		pageSize int
+		wantErr  bool
+	}{
+		{name: "4K pages", pageSize: 4 << 10},
+		{name: "smaller pages", pageSize: 2 << 10},
+		{name: "16K pages", pageSize: 16 << 10, wantErr: true},
+		{name: "64K pages", pageSize: 64 << 10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkPageSize(tt.pageSize)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrUnsupportedPageSize)
+				assert.Contains(t, err.Error(), "kernel=kernel8.img")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCommandForPageSize(t *testing.T) {
+	t.Parallel()
+
+	installed := func(names ...string) func(string) (string, error) {
+		return func(file string) (string, error) {
+			for _, name := range names {
+				if file == name {
+					return "/usr/local/bin/" + name, nil
+				}
+			}
+			return "", exec.ErrNotFound
+		}
+	}
+
+	tests := []struct {
+		name     string
+		pageSize int
+		lookPath func(string) (string, error)
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "supported page size",
+			pageSize: 4 << 10,
+			lookPath: installed("corrosion", "corrosion-64k"),
+			want:     "corrosion",
+		},
+		{
+			name:     "unsupported page size with large page binary",
+			pageSize: 16 << 10,
+			lookPath: installed("corrosion", "corrosion-64k"),
+			want:     "corrosion-64k",
+		},
+		{
+			name:     "unsupported page size without large page binary",
+			pageSize: 16 << 10,
+			lookPath: installed("corrosion"),
+			wantErr:  ErrUnsupportedPageSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd, err := commandForPageSize("corrosion", tt.pageSize, tt.lookPath)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cmd)
+		})
+	}
+}
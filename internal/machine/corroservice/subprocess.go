@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"sync"
@@ -65,7 +66,14 @@ func (s *SubprocessService) Running() bool {
 }
 
 func (s *SubprocessService) startProcess(ctx context.Context) error {
-	s.cmd = exec.Command(s.Command, "agent", "-c", filepath.Join(s.DataDir, "config.toml"))
+	command, err := commandForPageSize(s.Command, os.Getpagesize(), exec.LookPath)
+	if err != nil {
+		return err
+	}
+	if command != s.Command {
+		slog.Info("Using corrosion binary built for large pages.", "command", command)
+	}
+	s.cmd = exec.Command(command, "agent", "-c", filepath.Join(s.DataDir, "config.toml"))
 
 	// Redirect stdout and stderr to the logger.
 	stdout, err := s.cmd.StdoutPipe()
@@ -47,6 +47,14 @@ func (s *SystemdService) startOrRestart(ctx context.Context, cmd string) error {
 		return nil
 	}
 
+	// The service crashes and restarts in a loop if the corrosion binary doesn't support the kernel page size.
+	// Report the cause instead of letting the store requests fail with an opaque connection error.
+	if pageErr := CheckPageSize(); pageErr != nil {
+		if err := exec.Command("systemctl", "is-active", "--quiet", s.Unit).Run(); err != nil {
+			return fmt.Errorf("corrosion systemd service %s is not active: %w", s.Unit, pageErr)
+		}
+	}
+
 	// TODO: run a goroutine to check the status of the service and log any errors in the uncloud log.
 	s.running = true
 	return nil
@@ -0,0 +1,15 @@
+package machine
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// diskSpace returns the total and available to unprivileged users bytes of the filesystem containing the path.
+func diskSpace(path string) (total, available uint64, err error) {
+	var st syscall.Statfs_t
+	if err = syscall.Statfs(path, &st); err != nil {
+		return 0, 0, fmt.Errorf("statfs '%s': %w", path, err)
+	}
+	return st.Blocks * uint64(st.Bsize), st.Bavail * uint64(st.Bsize), nil
+}
@@ -3,17 +3,26 @@ package docker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/jsonmessage"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
 	"io"
+	"time"
 	"uncloud/internal/machine/api/pb"
 )
 
@@ -107,6 +116,41 @@ func (c *Client) StartContainer(ctx context.Context, id string, opts container.S
 	return err
 }
 
+// InspectContainer returns the low-level information about a container with the given ID or name.
+func (c *Client) InspectContainer(ctx context.Context, id string) (types.ContainerJSON, error) {
+	var ctr types.ContainerJSON
+
+	resp, err := c.grpcClient.InspectContainer(ctx, &pb.InspectContainerRequest{Id: id})
+	if err != nil {
+		if s, ok := status.FromError(err); ok {
+			if s.Code() == codes.NotFound {
+				return ctr, errdefs.NotFound(err)
+			}
+		}
+		return ctr, err
+	}
+
+	if err = json.Unmarshal(resp.Container, &ctr); err != nil {
+		return ctr, fmt.Errorf("unmarshal container: %w", err)
+	}
+	return ctr, nil
+}
+
+// Info returns system-wide information about the Docker daemon.
+func (c *Client) Info(ctx context.Context) (system.Info, error) {
+	var info system.Info
+
+	resp, err := c.grpcClient.Info(ctx, &emptypb.Empty{})
+	if err != nil {
+		return info, err
+	}
+
+	if err = json.Unmarshal(resp.Info, &info); err != nil {
+		return info, fmt.Errorf("unmarshal info: %w", err)
+	}
+	return info, nil
+}
+
 type MachineContainers struct {
 	Metadata   *pb.Metadata
 	Containers []types.Container
@@ -159,6 +203,115 @@ func (c *Client) RemoveContainer(ctx context.Context, id string, opts container.
 	return err
 }
 
+type MachinePruneReport struct {
+	Metadata *pb.Metadata
+	Report   image.PruneReport
+}
+
+// PruneImages removes unused images matching the filters on the machines the request is proxied to and returns
+// a report for each machine. Reports of machines that failed to prune images have the error set in the metadata.
+func (c *Client) PruneImages(ctx context.Context, pruneFilters filters.Args) ([]MachinePruneReport, error) {
+	filtersJSON, err := filters.ToJSON(pruneFilters)
+	if err != nil {
+		return nil, fmt.Errorf("marshal filters: %w", err)
+	}
+
+	resp, err := c.grpcClient.PruneImages(ctx, &pb.PruneImagesRequest{Filters: []byte(filtersJSON)})
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]MachinePruneReport, len(resp.Messages))
+	for i, msg := range resp.Messages {
+		reports[i].Metadata = msg.Metadata
+		if msg.Metadata != nil && msg.Metadata.Error != "" {
+			continue
+		}
+
+		if err = json.Unmarshal(msg.Report, &reports[i].Report); err != nil {
+			return nil, fmt.Errorf("unmarshal prune report: %w", err)
+		}
+	}
+	return reports, nil
+}
+
+type MachineVolumes struct {
+	Metadata *pb.Metadata
+	Volumes  []*volume.Volume
+}
+
+// ListVolumes returns the volumes on the machines the request is proxied to. If size is true, the volumes include
+// the disk usage data which can be slow to calculate for large volumes. A non-zero sizeTimeout limits the time
+// each machine spends calculating it, so a slow machine fails alone instead of failing the whole request.
+func (c *Client) ListVolumes(ctx context.Context, size bool, sizeTimeout time.Duration) ([]MachineVolumes, error) {
+	req := &pb.ListVolumesRequest{Size: size}
+	if size && sizeTimeout > 0 {
+		req.SizeTimeout = durationpb.New(sizeTimeout)
+	}
+	resp, err := c.grpcClient.ListVolumes(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	machineVolumes := make([]MachineVolumes, len(resp.Messages))
+	for i, msg := range resp.Messages {
+		machineVolumes[i].Metadata = msg.Metadata
+		if msg.Metadata != nil && msg.Metadata.Error != "" {
+			continue
+		}
+
+		if err = json.Unmarshal(msg.Volumes, &machineVolumes[i].Volumes); err != nil {
+			return nil, fmt.Errorf("unmarshal volumes: %w", err)
+		}
+	}
+	return machineVolumes, nil
+}
+
+type ContainerStatsMessage struct {
+	Stats *pb.ContainerStatsResponse
+	Err   error
+}
+
+// ContainerStats returns a channel of resource usage statistics of a container. If stream is false, only one stats
+// message is sent. The channel is closed when the stats stream ends or the context is canceled.
+func (c *Client) ContainerStats(ctx context.Context, id string, stream bool) (<-chan ContainerStatsMessage, error) {
+	statsStream, err := c.grpcClient.ContainerStats(ctx, &pb.ContainerStatsRequest{Id: id, Stream: stream})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan ContainerStatsMessage)
+
+	go func() {
+		defer close(ch)
+
+		for {
+			stats, err := statsStream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+					err = errdefs.NotFound(err)
+				}
+				select {
+				case ch <- ContainerStatsMessage{Err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+
+			select {
+			case ch <- ContainerStatsMessage{Stats: stats}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
 type PullImageMessage struct {
 	Message jsonmessage.JSONMessage
 	Err     error
@@ -196,3 +349,201 @@ func (c *Client) PullImage(ctx context.Context, image string) (<-chan PullImageM
 
 	return ch, nil
 }
+
+// CopyToContainer copies the content of a tar archive created from the source described by srcInfo to the destination
+// path in a container. The archive is extracted on the machine the same way as 'docker cp' does.
+func (c *Client) CopyToContainer(
+	ctx context.Context,
+	id, dstPath string,
+	srcInfo archive.CopyInfo,
+	content io.Reader,
+	opts container.CopyToContainerOptions,
+) error {
+	srcInfoBytes, err := json.Marshal(srcInfo)
+	if err != nil {
+		return fmt.Errorf("marshal source info: %w", err)
+	}
+	optsBytes, err := json.Marshal(opts)
+	if err != nil {
+		return fmt.Errorf("marshal options: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.grpcClient.CopyToContainer(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := &pb.CopyToContainerRequest{
+		Id:      id,
+		Path:    dstPath,
+		SrcInfo: srcInfoBytes,
+		Options: optsBytes,
+	}
+	buf := make([]byte, copyChunkSize)
+	for {
+		n, readErr := content.Read(buf)
+		if n > 0 {
+			req.Content = buf[:n]
+			if err = stream.Send(req); err != nil {
+				// The server failed, the actual error is returned by CloseAndRecv.
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return fmt.Errorf("send archive chunk: %w", err)
+			}
+			req = &pb.CopyToContainerRequest{}
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("read archive: %w", readErr)
+		}
+	}
+	if req.Id != "" {
+		// The archive is empty, send the first message anyway to start the copy.
+		if err = stream.Send(req); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("send copy request: %w", err)
+		}
+	}
+
+	if _, err = stream.CloseAndRecv(); err != nil {
+		if s, ok := status.FromError(err); ok {
+			if s.Code() == codes.NotFound {
+				return errdefs.NotFound(err)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// CopyFromContainer returns a tar archive of the file or directory at the source path in a container and its stat.
+// The caller must close the returned reader.
+func (c *Client) CopyFromContainer(
+	ctx context.Context, id, srcPath string,
+) (io.ReadCloser, container.PathStat, error) {
+	var stat container.PathStat
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.grpcClient.CopyFromContainer(ctx, &pb.CopyFromContainerRequest{Id: id, Path: srcPath})
+	if err != nil {
+		cancel()
+		return nil, stat, err
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		cancel()
+		if s, ok := status.FromError(err); ok {
+			if s.Code() == codes.NotFound {
+				return nil, stat, errdefs.NotFound(err)
+			}
+		}
+		return nil, stat, err
+	}
+	if err = json.Unmarshal(resp.Stat, &stat); err != nil {
+		cancel()
+		return nil, stat, fmt.Errorf("unmarshal path stat: %w", err)
+	}
+
+	content := &chunkReader{recv: func() ([]byte, error) {
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		return msg.Content, nil
+	}}
+	return &readCloser{Reader: content, close: cancel}, stat, nil
+}
+
+// BackupVolume returns a tar archive of the contents of a volume. The archive contains a single 'volume' directory
+// with the volume contents. The caller must close the returned reader.
+func (c *Client) BackupVolume(ctx context.Context, name string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.grpcClient.BackupVolume(ctx, &pb.BackupVolumeRequest{Name: name})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// Receive the first chunk to return an error early if the volume doesn't exist.
+	resp, err := stream.Recv()
+	if err != nil {
+		cancel()
+		if s, ok := status.FromError(err); ok {
+			if s.Code() == codes.NotFound {
+				return nil, errdefs.NotFound(err)
+			}
+		}
+		return nil, err
+	}
+
+	content := &chunkReader{buf: resp.Content, recv: func() ([]byte, error) {
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		return msg.Content, nil
+	}}
+	return &readCloser{Reader: content, close: cancel}, nil
+}
+
+// RestoreVolume extracts a tar archive created by BackupVolume into a volume. The volume is created if missing.
+// If requireEmpty is true, the restore fails if the volume already has data.
+func (c *Client) RestoreVolume(ctx context.Context, name string, content io.Reader, requireEmpty bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.grpcClient.RestoreVolume(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := &pb.RestoreVolumeRequest{
+		Name:         name,
+		RequireEmpty: requireEmpty,
+	}
+	buf := make([]byte, copyChunkSize)
+	for {
+		n, readErr := content.Read(buf)
+		if n > 0 {
+			req.Content = buf[:n]
+			if err = stream.Send(req); err != nil {
+				// The server failed, the actual error is returned by CloseAndRecv.
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return fmt.Errorf("send archive chunk: %w", err)
+			}
+			req = &pb.RestoreVolumeRequest{}
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("read archive: %w", readErr)
+		}
+	}
+	if req.Name != "" {
+		// The archive is empty, send the first message anyway to start the restore.
+		if err = stream.Send(req); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("send restore request: %w", err)
+		}
+	}
+
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// readCloser is an io.ReadCloser that calls the close function when closed.
+type readCloser struct {
+	io.Reader
+	close func()
+}
+
+func (r *readCloser) Close() error {
+	r.close()
+	return nil
+}
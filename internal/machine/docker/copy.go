@@ -0,0 +1,25 @@
+package docker
+
+// copyChunkSize is the maximum size of a tar archive chunk sent in a single gRPC message when copying files
+// to or from a container.
+const copyChunkSize = 512 << 10
+
+// chunkReader is an io.Reader that reads the content of a gRPC stream of chunks. recv must return the next chunk
+// or io.EOF when the stream is finished.
+type chunkReader struct {
+	recv func() ([]byte, error)
+	buf  []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		chunk, err := r.recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = chunk
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
@@ -67,6 +67,54 @@ func (m *Manager) WaitDaemonReady(ctx context.Context) error {
 	return nil
 }
 
+// ReconcileContainers starts service containers that should be running but Docker left stopped. This includes
+// containers that were created but never started, e.g. because the client or machine crashed in between, and
+// containers with the "always" restart policy that exited while the machine daemon wasn't running. It's supposed
+// to be called once when the machine daemon starts. Containers removed from Docker but still present in the cluster
+// store are cleaned up by the regular sync in WatchAndSyncContainers.
+func (m *Manager) ReconcileContainers(ctx context.Context) error {
+	containers, err := m.client.ContainerList(ctx, dockercontainer.ListOptions{
+		All: true,
+		Filters: filters.NewArgs(
+			filters.Arg("label", api.LabelServiceID),
+			filters.Arg("label", api.LabelServiceName),
+			filters.Arg("status", "created"),
+			filters.Arg("status", "exited"),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("list Docker containers: %w", err)
+	}
+
+	var errs error
+	for _, dc := range containers {
+		c := &api.Container{Container: dc}
+		start := false
+		switch c.State {
+		case "created":
+			start = c.DesiredState() == api.DesiredStateRunning
+		case "exited":
+			inspect, iErr := m.client.ContainerInspect(ctx, c.ID)
+			if iErr != nil {
+				errs = errors.Join(errs, fmt.Errorf("inspect container %q: %w", c.ID, iErr))
+				continue
+			}
+			start = inspect.HostConfig != nil && inspect.HostConfig.RestartPolicy.IsAlways()
+		}
+		if !start {
+			continue
+		}
+
+		if err = m.client.ContainerStart(ctx, c.ID, dockercontainer.StartOptions{}); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("start container %q: %w", c.ID, err))
+			continue
+		}
+		slog.Info("Started service container that should be running.",
+			"id", c.ID, "service", c.ServiceName(), "state", c.State)
+	}
+	return errs
+}
+
 func (m *Manager) WatchAndSyncContainers(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -8,13 +8,17 @@ import (
 	"github.com/docker/docker/libnetwork/iptables"
 	"log/slog"
 	"net/netip"
+	"slices"
 	"uncloud/internal/machine/network"
 )
 
 // EnsureUncloudNetwork creates the Docker bridge network NetworkName with the provided machine subnet
 // if it doesn't exist. If the network exists but has a different subnet, it removes and recreates the network.
-// It also configures iptables to allow container access from the WireGuard network.
+// It also configures iptables to allow container access from the WireGuard network. If the subnet is IPv6,
+// the network is created with IPv6 enabled. Docker then also assigns the containers IPv4 addresses from its default
+// address pools, which are only reachable from the machine itself.
 func (d *Manager) EnsureUncloudNetwork(ctx context.Context, subnet netip.Prefix) error {
+	ipv6 := subnet.Addr().Is6()
 	// Ensure the Docker network 'uncloud' is created with the correct subnet.
 	needsCreation := false
 	nw, err := d.client.NetworkInspect(ctx, NetworkName, dnetwork.InspectOptions{})
@@ -23,12 +27,12 @@ func (d *Manager) EnsureUncloudNetwork(ctx context.Context, subnet netip.Prefix)
 			return fmt.Errorf("inspect Docker network %q: %w", NetworkName, err)
 		}
 		needsCreation = true
-	} else if nw.IPAM.Config[0].Subnet != subnet.String() {
+	} else if !slices.ContainsFunc(nw.IPAM.Config, func(c dnetwork.IPAMConfig) bool {
+		return c.Subnet == subnet.String()
+	}) {
 		// Remove the Docker network if the subnet is different.
 		// It could be a leftover from a previous incomplete cleanup.
-		slog.Info(
-			"Removing Docker network with old subnet.", "name", NetworkName, "subnet", nw.IPAM.Config[0].Subnet,
-		)
+		slog.Info("Removing Docker network with old subnet.", "name", NetworkName, "ipam", nw.IPAM.Config)
 		if err = d.client.NetworkRemove(ctx, NetworkName); err != nil {
 			// It can still fail if the network is in use by a container. Leave it to the user to resolve the issue.
 			return fmt.Errorf("remove Docker network %q: %w", NetworkName, err)
@@ -41,6 +45,8 @@ func (d *Manager) EnsureUncloudNetwork(ctx context.Context, subnet netip.Prefix)
 			ctx, NetworkName, dnetwork.CreateOptions{
 				Driver: "bridge",
 				Scope:  "local",
+				// Set explicitly to override the default-network-opts of the Docker daemon.
+				EnableIPv6: &ipv6,
 				IPAM: &dnetwork.IPAM{
 					Config: []dnetwork.IPAMConfig{
 						{
@@ -67,7 +73,11 @@ func (d *Manager) EnsureUncloudNetwork(ctx context.Context, subnet netip.Prefix)
 	// Bridge name doesn't seem to be documented but this is the source code where it is generated:
 	// https://github.com/moby/moby/blob/v27.2.1/libnetwork/drivers/bridge/bridge_linux.go#L664
 	bridgeName := "br-" + nw.ID[:12]
-	ipt := iptables.GetIptable(iptables.IPv4)
+	ipVersion := iptables.IPv4
+	if ipv6 {
+		ipVersion = iptables.IPv6
+	}
+	ipt := iptables.GetIptable(ipVersion)
 	rule := []string{"--in-interface", network.WireGuardInterfaceName, "--out-interface", bridgeName, "-j", "ACCEPT"}
 	if err = ipt.ProgramRule(iptables.Filter, UserChain, iptables.Insert, rule); err != nil {
 		return fmt.Errorf("insert iptables rule: %w", err)
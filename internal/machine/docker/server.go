@@ -4,29 +4,47 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"io"
+	"log/slog"
 	"uncloud/internal/machine/api/pb"
 )
 
+// DefaultMaxConcurrentPulls is the default maximum number of images that can be pulled concurrently on a machine.
+const DefaultMaxConcurrentPulls = 3
+
 // Server implements the gRPC Docker service that proxies requests to the Docker daemon.
 type Server struct {
 	pb.UnimplementedDockerServer
 	client *client.Client
+	// pullSem limits the number of concurrent image pulls to avoid saturating the machine's disk and network.
+	pullSem *semaphore.Weighted
 }
 
-// NewServer creates a new Docker gRPC server with the provided Docker client.
-func NewServer(cli *client.Client) *Server {
-	return &Server{client: cli}
+// NewServer creates a new Docker gRPC server with the provided Docker client. maxConcurrentPulls limits the number
+// of images pulled concurrently, other pulls are queued. If it's not positive, DefaultMaxConcurrentPulls is used.
+func NewServer(cli *client.Client, maxConcurrentPulls int) *Server {
+	if maxConcurrentPulls <= 0 {
+		maxConcurrentPulls = DefaultMaxConcurrentPulls
+	}
+	return &Server{
+		client:  cli,
+		pullSem: semaphore.NewWeighted(int64(maxConcurrentPulls)),
+	}
 }
 
 // CreateContainer creates a new container based on the given configuration.
@@ -85,6 +103,26 @@ func (s *Server) StartContainer(ctx context.Context, req *pb.StartContainerReque
 	return &emptypb.Empty{}, nil
 }
 
+// InspectContainer returns the low-level information about a container with the given ID or name.
+func (s *Server) InspectContainer(
+	ctx context.Context, req *pb.InspectContainerRequest,
+) (*pb.InspectContainerResponse, error) {
+	ctr, err := s.client.ContainerInspect(ctx, req.Id)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, status.Errorf(codes.NotFound, "inspect container: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "inspect container: %v", err)
+	}
+
+	ctrBytes, err := json.Marshal(ctr)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "marshal container: %v", err)
+	}
+
+	return &pb.InspectContainerResponse{Container: ctrBytes}, nil
+}
+
 func (s *Server) ListContainers(ctx context.Context, req *pb.ListContainersRequest) (*pb.ListContainersResponse, error) {
 	var opts container.ListOptions
 	if len(req.Options) > 0 {
@@ -135,6 +173,14 @@ func (s *Server) RemoveContainer(ctx context.Context, req *pb.RemoveContainerReq
 		}
 	}
 
+	// Gracefully stop the container first using its configured stop signal and timeout. Force removal alone
+	// would kill the container immediately.
+	if err := s.client.ContainerStop(ctx, req.Id, container.StopOptions{}); err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, status.Errorf(codes.NotFound, "stop container: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "stop container: %v", err)
+	}
 	if err := s.client.ContainerRemove(ctx, req.Id, opts); err != nil {
 		if client.IsErrNotFound(err) {
 			return nil, status.Errorf(codes.NotFound, "remove container: %v", err)
@@ -145,9 +191,16 @@ func (s *Server) RemoveContainer(ctx context.Context, req *pb.RemoveContainerReq
 	return &emptypb.Empty{}, nil
 }
 
+// PullImage pulls an image and streams the pull progress messages. The number of concurrent pulls is limited,
+// so the pull waits until other pulls finish if the limit is reached.
 func (s *Server) PullImage(req *pb.PullImageRequest, stream grpc.ServerStreamingServer[pb.JSONMessage]) error {
 	ctx := stream.Context()
 
+	if err := s.pullSem.Acquire(ctx, 1); err != nil {
+		return status.Errorf(status.FromContextError(err).Code(), "wait for image pull slot: %v", err)
+	}
+	defer s.pullSem.Release(1)
+
 	// TODO: replace with another JSON serializable type (PullOptions.PrivilegeFunc is not serializable).
 	var opts image.PullOptions
 	if len(req.Options) > 0 {
@@ -189,7 +242,343 @@ func (s *Server) PullImage(req *pb.PullImageRequest, stream grpc.ServerStreaming
 		case err = <-errCh:
 			return err
 		case <-ctx.Done():
-			return status.Errorf(codes.Canceled, "pull image: %v", ctx.Err())
+			return status.Errorf(status.FromContextError(ctx.Err()).Code(), "pull image: %v", ctx.Err())
+		}
+	}
+}
+
+// Info returns system-wide information about the Docker daemon.
+func (s *Server) Info(ctx context.Context, _ *emptypb.Empty) (*pb.InfoResponse, error) {
+	info, err := s.client.Info(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "get Docker info: %v", err)
+	}
+
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "marshal info: %v", err)
+	}
+
+	return &pb.InfoResponse{Info: infoBytes}, nil
+}
+
+// CopyToContainer extracts a tar archive streamed by the client into a container. The archive is prepared
+// for extraction at the destination path the same way as 'docker cp' does, e.g. a source directory is copied
+// into an existing destination directory or renamed if the destination doesn't exist.
+func (s *Server) CopyToContainer(stream grpc.ClientStreamingServer[pb.CopyToContainerRequest, emptypb.Empty]) error {
+	ctx := stream.Context()
+
+	req, err := stream.Recv()
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "receive first copy message: %v", err)
+	}
+	if req.Id == "" || req.Path == "" {
+		return status.Error(codes.InvalidArgument, "container ID and destination path must be specified")
+	}
+	var srcInfo archive.CopyInfo
+	if err = json.Unmarshal(req.SrcInfo, &srcInfo); err != nil {
+		return status.Errorf(codes.InvalidArgument, "unmarshal source info: %v", err)
+	}
+	var opts container.CopyToContainerOptions
+	if len(req.Options) > 0 {
+		if err = json.Unmarshal(req.Options, &opts); err != nil {
+			return status.Errorf(codes.InvalidArgument, "unmarshal options: %v", err)
+		}
+	}
+
+	dstInfo := archive.CopyInfo{Path: req.Path}
+	// The destination may not exist yet which is fine, the source is then copied as the destination path.
+	if dstStat, err := s.client.ContainerStatPath(ctx, req.Id, req.Path); err == nil {
+		dstInfo.Exists, dstInfo.IsDir = true, dstStat.Mode.IsDir()
+	}
+
+	content := &chunkReader{buf: req.Content, recv: func() ([]byte, error) {
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		return msg.Content, nil
+	}}
+	dstDir, preparedArchive, err := archive.PrepareArchiveCopy(io.NopCloser(content), srcInfo, dstInfo)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "prepare archive: %v", err)
+	}
+	defer preparedArchive.Close()
+
+	if err = s.client.CopyToContainer(ctx, req.Id, dstDir, preparedArchive, opts); err != nil {
+		if client.IsErrNotFound(err) {
+			return status.Errorf(codes.NotFound, "copy to container: %v", err)
+		}
+		return status.Errorf(codes.Internal, "copy to container: %v", err)
+	}
+
+	return stream.SendAndClose(&emptypb.Empty{})
+}
+
+// CopyFromContainer streams a tar archive of a file or directory in a container. The first message contains
+// the stat of the source path.
+func (s *Server) CopyFromContainer(
+	req *pb.CopyFromContainerRequest, stream grpc.ServerStreamingServer[pb.CopyFromContainerResponse],
+) error {
+	content, stat, err := s.client.CopyFromContainer(stream.Context(), req.Id, req.Path)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return status.Errorf(codes.NotFound, "copy from container: %v", err)
+		}
+		return status.Errorf(codes.Internal, "copy from container: %v", err)
+	}
+	defer content.Close()
+
+	statBytes, err := json.Marshal(stat)
+	if err != nil {
+		return status.Errorf(codes.Internal, "marshal path stat: %v", err)
+	}
+	if err = stream.Send(&pb.CopyFromContainerResponse{Stat: statBytes}); err != nil {
+		return status.Errorf(codes.Internal, "send path stat to stream: %v", err)
+	}
+
+	buf := make([]byte, copyChunkSize)
+	for {
+		n, err := content.Read(buf)
+		if n > 0 {
+			if sendErr := stream.Send(&pb.CopyFromContainerResponse{Content: buf[:n]}); sendErr != nil {
+				return status.Errorf(codes.Internal, "send archive chunk to stream: %v", sendErr)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "read archive from container: %v", err)
+		}
+	}
+}
+
+// ContainerStats streams resource usage statistics of a container. If req.Stream is false, only one stats message
+// is sent.
+func (s *Server) ContainerStats(
+	req *pb.ContainerStatsRequest, stream grpc.ServerStreamingServer[pb.ContainerStatsResponse],
+) error {
+	ctx := stream.Context()
+
+	resp, err := s.client.ContainerStats(ctx, req.Id, req.Stream)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return status.Errorf(codes.NotFound, "get container stats: %v", err)
+		}
+		return status.Errorf(codes.Internal, "get container stats: %v", err)
+	}
+	// Closing the body also stops the stats decoding below when the context is canceled.
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var stats container.StatsResponse
+		if err = decoder.Decode(&stats); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return status.Errorf(codes.Canceled, "get container stats: %v", ctx.Err())
+			}
+			return status.Errorf(codes.Internal, "decode container stats: %v", err)
+		}
+
+		if err = stream.Send(statsToProto(&stats)); err != nil {
+			return status.Errorf(codes.Internal, "send container stats to stream: %v", err)
 		}
 	}
 }
+
+// PruneImages removes unused images matching the filters. By default, only dangling images are removed.
+// Images used by any container, including stopped service containers, are never removed.
+func (s *Server) PruneImages(ctx context.Context, req *pb.PruneImagesRequest) (*pb.PruneImagesResponse, error) {
+	args := filters.NewArgs()
+	if len(req.Filters) > 0 {
+		var err error
+		if args, err = filters.FromJSON(string(req.Filters)); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unmarshal filters: %v", err)
+		}
+	}
+
+	report, err := s.client.ImagesPrune(ctx, args)
+	if err != nil {
+		if errdefs.IsInvalidParameter(err) {
+			return nil, status.Errorf(codes.InvalidArgument, "prune images: %v", err)
+		}
+		if errdefs.IsConflict(err) {
+			return nil, status.Errorf(codes.FailedPrecondition, "prune images: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "prune images: %v", err)
+	}
+
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "marshal prune report: %v", err)
+	}
+
+	return &pb.PruneImagesResponse{
+		Messages: []*pb.MachinePruneImages{
+			{
+				Report: reportBytes,
+			},
+		},
+	}, nil
+}
+
+// ListVolumes returns the volumes on the machine. If req.Size is true, the volumes include the disk usage data
+// which requires Docker to calculate the size of each volume.
+func (s *Server) ListVolumes(ctx context.Context, req *pb.ListVolumesRequest) (*pb.ListVolumesResponse, error) {
+	var volumes []*volume.Volume
+	if req.Size {
+		if req.SizeTimeout != nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, req.SizeTimeout.AsDuration())
+			defer cancel()
+		}
+		du, err := s.client.DiskUsage(ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}})
+		if err != nil {
+			if req.SizeTimeout != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, status.Errorf(codes.DeadlineExceeded,
+					"calculating volumes disk usage took longer than %s", req.SizeTimeout.AsDuration())
+			}
+			return nil, status.Errorf(codes.Internal, "get volumes disk usage: %v", err)
+		}
+		volumes = du.Volumes
+	} else {
+		resp, err := s.client.VolumeList(ctx, volume.ListOptions{})
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list volumes: %v", err)
+		}
+		volumes = resp.Volumes
+	}
+
+	volumesBytes, err := json.Marshal(volumes)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "marshal volumes: %v", err)
+	}
+
+	return &pb.ListVolumesResponse{
+		Messages: []*pb.MachineVolumes{
+			{
+				Volumes: volumesBytes,
+			},
+		},
+	}, nil
+}
+
+// BackupVolume streams a tar archive of the contents of a volume. The volume is mounted read-only into a helper
+// container that is never started so the archive is streamed without buffering the contents on the machine.
+// The archive contains a single directory named after volumeHelperMountPath.
+func (s *Server) BackupVolume(
+	req *pb.BackupVolumeRequest, stream grpc.ServerStreamingServer[pb.BackupVolumeResponse],
+) error {
+	ctx := stream.Context()
+	if req.Name == "" {
+		return status.Error(codes.InvalidArgument, "volume name must be specified")
+	}
+
+	if _, err := s.client.VolumeInspect(ctx, req.Name); err != nil {
+		if client.IsErrNotFound(err) {
+			return status.Errorf(codes.NotFound, "inspect volume: %v", err)
+		}
+		return status.Errorf(codes.Internal, "inspect volume: %v", err)
+	}
+
+	helperID, err := s.createVolumeHelper(ctx, req.Name, true)
+	if err != nil {
+		return status.Errorf(codes.Internal, "%v", err)
+	}
+	defer func() {
+		if err := s.removeVolumeHelper(helperID); err != nil {
+			slog.Error("Failed to remove volume helper container.", "id", helperID, "err", err)
+		}
+	}()
+
+	content, _, err := s.client.CopyFromContainer(ctx, helperID, volumeHelperMountPath)
+	if err != nil {
+		return status.Errorf(codes.Internal, "archive volume: %v", err)
+	}
+	defer content.Close()
+
+	buf := make([]byte, copyChunkSize)
+	for {
+		n, err := content.Read(buf)
+		if n > 0 {
+			if sendErr := stream.Send(&pb.BackupVolumeResponse{Content: buf[:n]}); sendErr != nil {
+				return status.Errorf(codes.Internal, "send archive chunk to stream: %v", sendErr)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "read volume archive: %v", err)
+		}
+	}
+}
+
+// RestoreVolume extracts a tar archive created by BackupVolume into a volume. The volume is created if missing.
+// The archive is extracted by Docker into a helper container with the volume mounted which preserves the file modes
+// and ownership from the archive.
+func (s *Server) RestoreVolume(stream grpc.ClientStreamingServer[pb.RestoreVolumeRequest, emptypb.Empty]) error {
+	ctx := stream.Context()
+
+	req, err := stream.Recv()
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "receive first restore message: %v", err)
+	}
+	if req.Name == "" {
+		return status.Error(codes.InvalidArgument, "volume name must be specified")
+	}
+
+	if _, err = s.client.VolumeInspect(ctx, req.Name); err != nil {
+		if !client.IsErrNotFound(err) {
+			return status.Errorf(codes.Internal, "inspect volume: %v", err)
+		}
+		if _, err = s.client.VolumeCreate(ctx, volume.CreateOptions{Name: req.Name}); err != nil {
+			return status.Errorf(codes.Internal, "create volume: %v", err)
+		}
+		slog.Info("Created volume to restore.", "name", req.Name)
+	}
+
+	helperID, err := s.createVolumeHelper(ctx, req.Name, false)
+	if err != nil {
+		return status.Errorf(codes.Internal, "%v", err)
+	}
+	defer func() {
+		if err := s.removeVolumeHelper(helperID); err != nil {
+			slog.Error("Failed to remove volume helper container.", "id", helperID, "err", err)
+		}
+	}()
+
+	if req.RequireEmpty {
+		empty, err := s.volumeHelperEmpty(ctx, helperID)
+		if err != nil {
+			return status.Errorf(codes.Internal, "check volume is empty: %v", err)
+		}
+		if !empty {
+			return status.Errorf(codes.FailedPrecondition, "volume %q is not empty", req.Name)
+		}
+	}
+
+	content, wait := newVolumeArchiveReader(&chunkReader{buf: req.Content, recv: func() ([]byte, error) {
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		return msg.Content, nil
+	}})
+	// The archive contains the volume directory so it's extracted into the root of the helper container.
+	err = s.client.CopyToContainer(ctx, helperID, "/", content, container.CopyToContainerOptions{})
+	// Unblock the archive check if Docker stopped reading the archive early.
+	content.Close()
+	if checkErr := wait(); errors.Is(checkErr, errInvalidVolumeArchive) {
+		return status.Errorf(codes.InvalidArgument, "%v", checkErr)
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "extract archive into volume: %v", err)
+	}
+
+	return stream.SendAndClose(&emptypb.Empty{})
+}
@@ -0,0 +1,121 @@
+package docker
+
+import (
+	"context"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"uncloud/internal/machine/api/pb"
+)
+
+// pullStream is a fake PullImage server stream that discards the sent messages.
+type pullStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *pullStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *pullStream) Send(*pb.JSONMessage) error {
+	return nil
+}
+
+// newPullServer creates a Server with a fake Docker API that blocks image pulls until release is closed. It reports
+// the number of pulls in progress to the Docker API.
+func newPullServer(t *testing.T, maxConcurrentPulls int, release <-chan struct{}) (*Server, *atomic.Int32) {
+	var inProgress atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/images/create") {
+			http.NotFound(w, r)
+			return
+		}
+		inProgress.Add(1)
+		defer inProgress.Add(-1)
+
+		select {
+		case <-release:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"status": "Pull complete"}`))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cli, err := client.NewClientWithOpts(
+		client.WithHost("tcp://"+srv.Listener.Addr().String()), client.WithVersion("1.47"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { cli.Close() })
+
+	return NewServer(cli, maxConcurrentPulls), &inProgress
+}
+
+func TestServer_PullImage_ConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	s, inProgress := newPullServer(t, 2, release)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.PullImage(&pb.PullImageRequest{Image: "alpine"}, &pullStream{ctx: context.Background()})
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		return inProgress.Load() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	// The other pulls must wait for a free slot.
+	assert.Never(t, func() bool {
+		return inProgress.Load() > 2
+	}, 200*time.Millisecond, 10*time.Millisecond)
+
+	close(release)
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+}
+
+func TestServer_PullImage_WaitForSlotDeadline(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	s, inProgress := newPullServer(t, 1, release)
+	// Release the pull before the fake Docker API is closed as closing it waits for the pending requests.
+	t.Cleanup(func() { close(release) })
+
+	// Occupy the only pull slot.
+	go func() {
+		_ = s.PullImage(&pb.PullImageRequest{Image: "alpine"}, &pullStream{ctx: context.Background()})
+	}()
+	require.Eventually(t, func() bool {
+		return inProgress.Load() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := s.PullImage(&pb.PullImageRequest{Image: "alpine"}, &pullStream{ctx: ctx})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = s.PullImage(&pb.PullImageRequest{Image: "alpine"}, &pullStream{ctx: ctx})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
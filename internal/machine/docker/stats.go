@@ -0,0 +1,56 @@
+package docker
+
+import (
+	"github.com/docker/docker/api/types/container"
+	"strings"
+	"uncloud/internal/machine/api/pb"
+)
+
+// statsToProto converts the Docker container stats to a compact protobuf message calculating the values
+// the same way as 'docker stats' does on Linux.
+func statsToProto(s *container.StatsResponse) *pb.ContainerStatsResponse {
+	resp := &pb.ContainerStatsResponse{
+		CpuPercent:  cpuPercent(s),
+		MemoryUsage: memoryUsage(s.MemoryStats),
+		MemoryLimit: s.MemoryStats.Limit,
+		Pids:        s.PidsStats.Current,
+	}
+	for _, n := range s.Networks {
+		resp.NetworkRx += n.RxBytes
+		resp.NetworkTx += n.TxBytes
+	}
+	for _, e := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			resp.BlockRead += e.Value
+		case "write":
+			resp.BlockWrite += e.Value
+		}
+	}
+	return resp
+}
+
+// cpuPercent calculates the CPU usage as a percentage of a single CPU since the previous stats read.
+func cpuPercent(s *container.StatsResponse) float64 {
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(s.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	return cpuDelta / systemDelta * onlineCPUs * 100
+}
+
+// memoryUsage returns the memory usage excluding the inactive page cache which can be reclaimed by the kernel.
+func memoryUsage(m container.MemoryStats) uint64 {
+	// cgroup v1 reports the cache as total_inactive_file while cgroup v2 as inactive_file.
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if v, ok := m.Stats[key]; ok && v < m.Usage {
+			return m.Usage - v
+		}
+	}
+	return m.Usage
+}
@@ -0,0 +1,150 @@
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"io"
+	"path"
+	"strings"
+)
+
+// errInvalidVolumeArchive is returned when an archive to restore into a volume is not a valid tar archive
+// created by BackupVolume.
+var errInvalidVolumeArchive = errors.New("invalid volume archive")
+
+const (
+	// volumeHelperImage is the image of the helper containers used to access the contents of volumes. The helper
+	// containers are never started, the image is only needed to create them.
+	volumeHelperImage = "busybox:1.37"
+	// volumeHelperMountPath is the path in a helper container where the volume is mounted. Volume archives contain
+	// the volume contents in the directory with the base name of this path.
+	volumeHelperMountPath = "/volume"
+)
+
+// createVolumeHelper creates a helper container with the volume mounted at volumeHelperMountPath. The container
+// is not started as Docker can copy files to and from created containers. The caller must remove the container.
+func (s *Server) createVolumeHelper(ctx context.Context, volume string, readOnly bool) (string, error) {
+	if err := s.ensureVolumeHelperImage(ctx); err != nil {
+		return "", err
+	}
+
+	config := &container.Config{
+		Image: volumeHelperImage,
+	}
+	hostConfig := &container.HostConfig{
+		Mounts: []mount.Mount{
+			{
+				Type:     mount.TypeVolume,
+				Source:   volume,
+				Target:   volumeHelperMountPath,
+				ReadOnly: readOnly,
+			},
+		},
+	}
+	resp, err := s.client.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("create volume helper container: %w", err)
+	}
+	return resp.ID, nil
+}
+
+// removeVolumeHelper removes the helper container created by createVolumeHelper. It uses a new context to clean up
+// the container even if the request context is canceled.
+func (s *Server) removeVolumeHelper(id string) error {
+	if err := s.client.ContainerRemove(context.Background(), id, container.RemoveOptions{Force: true}); err != nil {
+		return fmt.Errorf("remove volume helper container: %w", err)
+	}
+	return nil
+}
+
+// ensureVolumeHelperImage pulls the volume helper image if it's missing on the machine.
+func (s *Server) ensureVolumeHelperImage(ctx context.Context) error {
+	if _, _, err := s.client.ImageInspectWithRaw(ctx, volumeHelperImage); err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return fmt.Errorf("inspect volume helper image: %w", err)
+	}
+
+	if err := s.pullSem.Acquire(ctx, 1); err != nil {
+		return fmt.Errorf("wait for image pull slot: %w", err)
+	}
+	defer s.pullSem.Release(1)
+
+	respBody, err := s.client.ImagePull(ctx, volumeHelperImage, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("pull volume helper image: %w", err)
+	}
+	defer respBody.Close()
+	// The pull completes when the progress stream is fully read. Pull errors are reported in the stream messages.
+	if err = jsonmessage.DisplayJSONMessagesStream(respBody, io.Discard, 0, false, nil); err != nil {
+		return fmt.Errorf("pull volume helper image: %w", err)
+	}
+	return nil
+}
+
+// volumeHelperEmpty returns true if the volume mounted into the helper container has no files.
+func (s *Server) volumeHelperEmpty(ctx context.Context, helperID string) (bool, error) {
+	content, _, err := s.client.CopyFromContainer(ctx, helperID, volumeHelperMountPath)
+	if err != nil {
+		return false, fmt.Errorf("archive volume: %w", err)
+	}
+	defer content.Close()
+
+	// The first entry is the volume directory itself so the volume is empty if there is no second entry.
+	tr := tar.NewReader(content)
+	for range 2 {
+		if _, err = tr.Next(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return true, nil
+			}
+			return false, fmt.Errorf("read volume archive: %w", err)
+		}
+	}
+	return false, nil
+}
+
+// newVolumeArchiveReader returns a reader that passes through the archive read from r while checking that it's
+// a valid tar archive with all entries in the volume directory. The reader fails with an errInvalidVolumeArchive
+// error if the archive is invalid. wait must be called after closing the reader and returns the check error.
+func newVolumeArchiveReader(r io.Reader) (rc io.ReadCloser, wait func() error) {
+	pr, pw := io.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		err := checkVolumeArchive(io.TeeReader(r, pw))
+		pw.CloseWithError(err)
+		errCh <- err
+	}()
+	return pr, func() error { return <-errCh }
+}
+
+// checkVolumeArchive reads the tar archive until the end checking that all its entries are in the volume directory.
+func checkVolumeArchive(r io.Reader) error {
+	dir := path.Base(volumeHelperMountPath)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if errors.Is(err, io.ErrClosedPipe) {
+				return err
+			}
+			return fmt.Errorf("%w: %w", errInvalidVolumeArchive, err)
+		}
+		name := path.Clean(hdr.Name)
+		if name != dir && !strings.HasPrefix(name, dir+"/") {
+			return fmt.Errorf("%w: entry '%s' is outside the '%s' directory", errInvalidVolumeArchive, hdr.Name, dir)
+		}
+	}
+	// Pass through the padding after the end of the archive.
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
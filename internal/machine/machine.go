@@ -1,10 +1,13 @@
 package machine
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/sockets"
 	"github.com/siderolabs/grpc-proxy/proxy"
@@ -12,6 +15,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"log/slog"
 	"net"
@@ -19,7 +23,12 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"uncloud/internal/api"
 	"uncloud/internal/corrosion"
 	"uncloud/internal/docker"
 	"uncloud/internal/fs"
@@ -37,6 +46,9 @@ const (
 	DefaultMachineSockPath = "/run/uncloud/machine.sock"
 	DefaultUncloudSockPath = "/run/uncloud/uncloud.sock"
 	DefaultSockGroup       = "uncloud"
+	// DefaultStopContainersTimeout is shorter than the default systemd stop timeout of 90s so that the daemon exits
+	// on its own before being killed.
+	DefaultStopContainersTimeout = 80 * time.Second
 )
 
 type Config struct {
@@ -55,10 +67,47 @@ type Config struct {
 
 	// DockerClient manages system and user containers using the local Docker daemon.
 	DockerClient *client.Client
+	// StopContainersOnShutdown specifies whether to gracefully stop all service containers on the machine when
+	// the machine daemon stops. Each container is given its configured stop grace period. By default, containers
+	// are left running.
+	StopContainersOnShutdown bool
+	// StopContainersTimeout is the maximum time to wait for the service containers to stop on shutdown. It should be
+	// longer than the largest stop grace period of the containers. The daemon exits without waiting for
+	// the containers that haven't stopped in time. Default is DefaultStopContainersTimeout.
+	StopContainersTimeout time.Duration
+	// MaxConcurrentImagePulls limits the number of images the machine pulls concurrently. Other pulls are queued
+	// until a slot is available. Default is machinedocker.DefaultMaxConcurrentPulls.
+	MaxConcurrentImagePulls int
+	// MaxMessageSize is the maximum size in bytes of a gRPC message the machine API servers and the proxy
+	// connections to other machines can send or receive. Default is pb.DefaultMaxMessageSize.
+	MaxMessageSize int
 
 	// CaddyfilePath specifies where the machine generates the Caddy reverse proxy configuration file for routing
 	// external traffic to service containers across the internal network. Default is DataDir/caddy/Caddyfile.
 	CaddyfilePath string
+	// CaddyTLS specifies how the Caddy ingress obtains certificates for HTTPS hostnames: from Caddy's internal CA,
+	// e.g. on machines not reachable from the internet, or from the operator-provided files. Default is to use
+	// the ACME HTTP-01 and TLS-ALPN-01 challenges.
+	CaddyTLS caddyfile.TLSConfig
+	// PublicIPSource specifies how the machine determines its public IP address to use as a WireGuard endpoint
+	// when the machine state doesn't override it. See network.ValidatePublicIPSource for the supported sources.
+	// Default is network.PublicIPSourceAuto.
+	PublicIPSource string
+	// PreferIPv6Endpoints specifies whether the machine advertises its IPv6 WireGuard endpoints before the IPv4 ones
+	// so that other machines try to connect to it over IPv6 first when it has both.
+	PreferIPv6Endpoints bool
+	// WireGuardMTU is the MTU of the WireGuard interface. Lower it if the path between machines has a smaller MTU
+	// than expected, e.g. PPPoE links or some cloud networks, and the encapsulated packets get dropped. The symptom is
+	// that services are reachable by ping but TLS handshakes and other large transfers hang. The change is applied
+	// in place on restart without recreating the interface. Default is network.DefaultWireGuardMTU.
+	WireGuardMTU int
+	// ClusterSecretFile is the path to a file with the pre-shared cluster secret. If set, the machine only joins
+	// a cluster that proves it knows this secret regardless of the secret provided by the client adding it.
+	ClusterSecretFile string
+
+	// TraefikConfigPath specifies where the machine generates the Traefik dynamic configuration file when
+	// the cluster uses Traefik as the ingress controller. Default is DataDir/traefik/dynamic.yml.
+	TraefikConfigPath string
 }
 
 // SetDefaults returns a new Config with default values set where not provided.
@@ -84,6 +133,18 @@ func (c *Config) SetDefaults() (*Config, error) {
 		cfg.DockerClient = cli
 	}
 
+	if cfg.StopContainersTimeout <= 0 {
+		cfg.StopContainersTimeout = DefaultStopContainersTimeout
+	}
+
+	if cfg.MaxConcurrentImagePulls <= 0 {
+		cfg.MaxConcurrentImagePulls = machinedocker.DefaultMaxConcurrentPulls
+	}
+
+	if cfg.MaxMessageSize <= 0 {
+		cfg.MaxMessageSize = pb.DefaultMaxMessageSize
+	}
+
 	if cfg.CorrosionDir == "" {
 		cfg.CorrosionDir = filepath.Join(cfg.DataDir, "corrosion")
 	}
@@ -124,6 +185,24 @@ func (c *Config) SetDefaults() (*Config, error) {
 	if cfg.CaddyfilePath == "" {
 		cfg.CaddyfilePath = filepath.Join(cfg.DataDir, "caddy", "caddy.json")
 	}
+	if err := cfg.CaddyTLS.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid Caddy TLS configuration: %w", err)
+	}
+	if cfg.PublicIPSource == "" {
+		cfg.PublicIPSource = network.PublicIPSourceAuto
+	}
+	if err := network.ValidatePublicIPSource(cfg.PublicIPSource); err != nil {
+		return nil, err
+	}
+	if cfg.WireGuardMTU == 0 {
+		cfg.WireGuardMTU = network.DefaultWireGuardMTU
+	}
+	if err := network.ValidateMTU(cfg.WireGuardMTU); err != nil {
+		return nil, err
+	}
+	if cfg.TraefikConfigPath == "" {
+		cfg.TraefikConfigPath = filepath.Join(cfg.DataDir, "traefik", "dynamic.yml")
+	}
 
 	return &cfg, nil
 }
@@ -157,6 +236,11 @@ type Machine struct {
 	// It proxies requests to the local or remote machine API servers depending on the request targets
 	// and aggregates responses.
 	localProxyServer *grpc.Server
+
+	// networkCtrlMu protects networkCtrl.
+	networkCtrlMu sync.RWMutex
+	// networkCtrl is the running network controller. It's nil when the machine is not a member of a cluster.
+	networkCtrl *networkController
 }
 
 func NewMachine(config *Config) (*Machine, error) {
@@ -208,11 +292,13 @@ func NewMachine(config *Config) (*Machine, error) {
 	if err != nil {
 		return nil, fmt.Errorf("create Docker client: %w", err)
 	}
-	dockerServer := machinedocker.NewServer(dockerCli)
+	dockerServer := machinedocker.NewServer(dockerCli, config.MaxConcurrentImagePulls)
 
 	// Init a local gRPC proxy server that proxies requests to the local or remote machine API servers.
-	proxyDirector := apiproxy.NewDirector(config.MachineSockPath, APIPort)
+	proxyDirector := apiproxy.NewDirector(config.MachineSockPath, APIPort, config.MaxMessageSize)
 	localProxyServer := grpc.NewServer(
+		grpc.MaxRecvMsgSize(config.MaxMessageSize),
+		grpc.MaxSendMsgSize(config.MaxMessageSize),
 		grpc.ForceServerCodecV2(proxy.Codec()),
 		grpc.UnknownServiceHandler(
 			proxy.TransparentHandler(proxyDirector.Director),
@@ -230,7 +316,7 @@ func NewMachine(config *Config) (*Machine, error) {
 		localProxyServer: localProxyServer,
 		proxyDirector:    proxyDirector,
 	}
-	m.localMachineServer = newGRPCServer(m, c, dockerServer)
+	m.localMachineServer = newGRPCServer(m, c, dockerServer, config.MaxMessageSize)
 
 	if m.Initialised() {
 		m.initialised <- struct{}{}
@@ -239,8 +325,11 @@ func NewMachine(config *Config) (*Machine, error) {
 	return m, nil
 }
 
-func newGRPCServer(m pb.MachineServer, c pb.ClusterServer, d pb.DockerServer) *grpc.Server {
-	s := grpc.NewServer()
+func newGRPCServer(m pb.MachineServer, c pb.ClusterServer, d pb.DockerServer, maxMsgSize int) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMsgSize),
+		grpc.MaxSendMsgSize(maxMsgSize),
+	)
 	pb.RegisterMachineServer(s, m)
 	pb.RegisterClusterServer(s, c)
 	pb.RegisterDockerServer(s, d)
@@ -267,6 +356,10 @@ func (m *Machine) Run(ctx context.Context) error {
 		return fmt.Errorf("wait for Docker daemon: %w", err)
 	}
 
+	if err := corroservice.CheckPageSize(); err != nil {
+		slog.Error("Corrosion service is likely to fail on this system.", "err", err)
+	}
+
 	// Configure and start the corrosion service on the loopback if the machine is not initialised as a cluster
 	// member. This provides the store required for the machine to initialise a new cluster on it. Once the machine
 	// is initialised, the corrosion service is managed by the networkController.
@@ -351,15 +444,19 @@ func (m *Machine) Run(ctx context.Context) error {
 					// the proxy to identify which requests should be proxied to the local machine API server.
 					m.proxyDirector.UpdateLocalAddress(m.state.Network.ManagementIP.String())
 					proxyServer := grpc.NewServer(
+						grpc.MaxRecvMsgSize(m.config.MaxMessageSize),
+						grpc.MaxSendMsgSize(m.config.MaxMessageSize),
 						grpc.ForceServerCodecV2(proxy.Codec()),
 						grpc.UnknownServiceHandler(
 							proxy.TransparentHandler(m.proxyDirector.Director),
 						),
 					)
 
-					caddyfileCtrl, err := caddyfile.NewController(m.store, m.config.CaddyfilePath)
+					caddyfileCtrl, err := caddyfile.NewController(
+						m.store, m.config.CaddyfilePath, m.config.TraefikConfigPath, m.config.CaddyTLS,
+					)
 					if err != nil {
-						return fmt.Errorf("create Caddyfile controller: %w", err)
+						return fmt.Errorf("create ingress configuration controller: %w", err)
 					}
 
 					ctrl, err = newNetworkController(
@@ -369,11 +466,14 @@ func (m *Machine) Run(ctx context.Context) error {
 						m.config.CorrosionService,
 						m.config.DockerClient,
 						caddyfileCtrl,
+						m.config.WireGuardMTU,
 					)
 					if err != nil {
 						return fmt.Errorf("initialise network controller: %w", err)
 					}
 
+					m.setNetworkController(ctrl)
+
 					go func() {
 						if err = ctrl.Run(ctx); err != nil {
 							errCh <- fmt.Errorf("run network controller: %w", err)
@@ -383,6 +483,7 @@ func (m *Machine) Run(ctx context.Context) error {
 						}
 					}()
 				case err := <-errCh:
+					m.setNetworkController(nil)
 					if err != nil {
 						return err
 					}
@@ -416,6 +517,16 @@ func (m *Machine) Run(ctx context.Context) error {
 			m.proxyDirector.Close()
 			slog.Info("Local API proxy server stopped.")
 
+			if m.config.StopContainersOnShutdown {
+				slog.Info("Stopping service containers.")
+				// The run context is already cancelled so use a new one to wait for the containers to stop.
+				if err := m.stopServiceContainers(m.config.StopContainersTimeout); err != nil {
+					slog.Error("Failed to stop service containers.", "err", err)
+				} else {
+					slog.Info("Service containers stopped.")
+				}
+			}
+
 			m.config.DockerClient.Close()
 			return nil
 		},
@@ -424,6 +535,40 @@ func (m *Machine) Run(ctx context.Context) error {
 	return errGroup.Wait()
 }
 
+// stopServiceContainers gracefully stops all running service containers on the machine in parallel. Each container
+// is killed if it doesn't stop within its configured stop timeout. It gives up waiting for the containers after
+// the timeout, e.g. if the Docker daemon hangs.
+func (m *Machine) stopServiceContainers(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	containers, err := m.config.DockerClient.ContainerList(ctx, container.ListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("label", api.LabelServiceID),
+			filters.Arg("label", api.LabelManaged),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("list service containers: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(containers))
+	for i, c := range containers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Nil timeout means the container's configured stop timeout is used.
+			if err := m.config.DockerClient.ContainerStop(ctx, c.ID, container.StopOptions{}); err != nil {
+				errs[i] = fmt.Errorf("stop container %q: %w", c.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
 // listenUnixSocket creates a new Unix socket listener with the specified path. The socket file is created with 0660
 // access mode and uncloud group if the group is found, otherwise it falls back to the root group.
 func listenUnixSocket(path string) (net.Listener, error) {
@@ -512,18 +657,84 @@ func (m *Machine) configureCorrosion() error {
 // InitCluster initialises a new cluster on the local machine with the provided network configuration.
 func (m *Machine) InitCluster(ctx context.Context, req *pb.InitClusterRequest) (*pb.InitClusterResponse, error) {
 	if m.Initialised() {
-		return nil, status.Error(codes.FailedPrecondition, "machine is already configured as a cluster member")
+		if !req.Resume {
+			return nil, status.Error(codes.FailedPrecondition, "machine is already configured as a cluster member")
+		}
+		// A previous initialisation completed on the machine but the caller may have failed after that.
+		clusterNetwork, err := req.Network.ToPrefix()
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid network: %v", err)
+		}
+		if err = m.cluster.CheckInitConfig(ctx, clusterNetwork, req.Ingress, req.ClusterSecret); err != nil {
+			return nil, err
+		}
+		machines, err := m.store.ListMachines(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list machines: %v", err)
+		}
+		for _, sm := range machines {
+			if sm.Id == m.state.ID {
+				slog.Info("Machine already initialised, nothing to resume.", "id", sm.Id, "machine", sm.Name)
+				return &pb.InitClusterResponse{Machine: sm}, nil
+			}
+		}
+		return nil, status.Errorf(codes.Internal, "machine %q not found in the cluster store", m.state.ID)
 	}
 
 	clusterNetwork, err := req.Network.ToPrefix()
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid network: %v", err)
 	}
+	if err = cluster.ValidateNetwork(clusterNetwork); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	clusterInitialised := false
+	if req.Resume {
+		if clusterInitialised, err = m.cluster.Initialised(ctx); err != nil {
+			return nil, err
+		}
+		// The cluster state left by a previous initialisation must match the request. In particular, the subnet
+		// below is validated against the requested network, so it has to be the stored one.
+		if clusterInitialised {
+			if err = m.cluster.CheckInitConfig(ctx, clusterNetwork, req.Ingress, req.ClusterSecret); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err = pb.ValidateMachineRole(req.Role); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err = caddyfile.ValidateIngress(req.Ingress); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.Subnet != nil {
+		// Validate the subnet before initialising the cluster to not leave it half-initialised.
+		subnet, err := req.Subnet.ToPrefix()
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid subnet: %v", err)
+		}
+		if err = cluster.ValidateSubnet(subnet, clusterNetwork); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if err = network.ValidatePublicIPSource(req.PublicIpSource); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.Interface != "" {
+		// Validate the interface exists and has routable addresses before initialising the cluster.
+		if _, err = network.ListInterfaceRoutableIPs(req.Interface); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid interface: %v", err)
+		}
+	}
 
-	if err = m.cluster.Init(ctx, clusterNetwork); err != nil {
-		return nil, status.Errorf(codes.Internal, "init cluster: %v", err)
+	if clusterInitialised {
+		slog.Info("Cluster state already initialised by a previous initialisation, resuming.")
+	} else {
+		if err = m.cluster.Init(ctx, clusterNetwork, req.Ingress, req.ClusterSecret); err != nil {
+			return nil, status.Errorf(codes.Internal, "init cluster: %v", err)
+		}
+		slog.Info("Cluster state initialised.", "network", clusterNetwork.String(), "ingress", req.Ingress)
 	}
-	slog.Info("Cluster state initialised.", "network", clusterNetwork.String())
 
 	machineName := req.MachineName
 	if machineName == "" {
@@ -531,19 +742,12 @@ func (m *Machine) InitCluster(ctx context.Context, req *pb.InitClusterRequest) (
 			return nil, status.Errorf(codes.Internal, "generate machine name: %v", err)
 		}
 	}
-	// Use the public and all routable IPs as endpoints.
-	ips, err := network.ListRoutableIPs()
+	addrPorts, err := m.endpoints(req.Interface, req.PublicIpSource)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "list routable IPs: %v", err)
-	}
-	publicIP, err := network.GetPublicIP()
-	// Ignore the error if failed to get the public IP using API services.
-	if err == nil {
-		ips = append(ips, publicIP)
+		return nil, status.Errorf(codes.Internal, "list endpoints: %v", err)
 	}
-	endpoints := make([]*pb.IPPort, len(ips))
-	for i, addr := range ips {
-		addrPort := netip.AddrPortFrom(addr, network.WireGuardPort)
+	endpoints := make([]*pb.IPPort, len(addrPorts))
+	for i, addrPort := range addrPorts {
 		endpoints[i] = pb.NewIPPort(addrPort)
 	}
 
@@ -552,13 +756,34 @@ func (m *Machine) InitCluster(ctx context.Context, req *pb.InitClusterRequest) (
 	addReq := &pb.AddMachineRequest{
 		Name: machineName,
 		Network: &pb.NetworkConfig{
-			Endpoints: endpoints,
-			PublicKey: m.state.Network.PublicKey,
+			Subnet:         req.Subnet,
+			Endpoints:      endpoints,
+			PublicKey:      m.state.Network.PublicKey,
+			ManualEndpoint: req.WgEndpoint,
 		},
+		Role:          req.Role,
+		ClusterSecret: req.ClusterSecret,
 	}
-	addResp, err := m.cluster.AddMachine(ctx, addReq)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "add machine to cluster: %v", err)
+	var addResp *pb.AddMachineResponse
+	if req.Resume {
+		// The machine may have been registered by a previous initialisation that failed to save the machine state.
+		machines, err := m.store.ListMachines(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "list machines: %v", err)
+		}
+		for _, sm := range machines {
+			if bytes.Equal(sm.Network.PublicKey, m.state.Network.PublicKey) {
+				addResp = &pb.AddMachineResponse{Machine: sm}
+				slog.Info("Machine already registered in the cluster by a previous initialisation, resuming.",
+					"id", sm.Id, "machine", sm.Name)
+				break
+			}
+		}
+	}
+	if addResp == nil {
+		if addResp, err = m.cluster.AddMachine(ctx, addReq); err != nil {
+			return nil, status.Errorf(codes.Internal, "add machine to cluster: %v", err)
+		}
 	}
 
 	subnet, err := addResp.Machine.Network.Subnet.ToPrefix()
@@ -572,6 +797,8 @@ func (m *Machine) InitCluster(ctx context.Context, req *pb.InitClusterRequest) (
 	// Update the machine state with the new cluster configuration.
 	m.state.ID = addResp.Machine.Id
 	m.state.Name = addResp.Machine.Name
+	m.state.Interface = req.Interface
+	m.state.PublicIPSource = req.PublicIpSource
 	m.state.Network = &network.Config{
 		Subnet:       subnet,
 		ManagementIP: manageIP,
@@ -591,6 +818,30 @@ func (m *Machine) InitCluster(ctx context.Context, req *pb.InitClusterRequest) (
 	return resp, nil
 }
 
+// verifyJoinProof checks that the cluster the machine is joining knows the pre-shared cluster secret. The secret from
+// the machine daemon config takes precedence over the one in the request. No check is done if neither is set.
+func (m *Machine) verifyJoinProof(req *pb.JoinClusterRequest) error {
+	clusterSecret := req.ClusterSecret
+	if m.config.ClusterSecretFile != "" {
+		data, err := os.ReadFile(m.config.ClusterSecretFile)
+		if err != nil {
+			return status.Errorf(codes.Internal, "read cluster secret file: %v", err)
+		}
+		clusterSecret = strings.TrimSpace(string(data))
+	}
+	if clusterSecret == "" {
+		return nil
+	}
+
+	err := cluster.VerifyJoinProof(
+		clusterSecret, req.SecretSalt, req.Machine.Id, req.Machine.Network.PublicKey, req.JoinProof,
+	)
+	if err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
 // JoinCluster configures the local machine to join an existing cluster.
 func (m *Machine) JoinCluster(_ context.Context, req *pb.JoinClusterRequest) (*emptypb.Empty, error) {
 	if m.Initialised() {
@@ -614,6 +865,9 @@ func (m *Machine) JoinCluster(_ context.Context, req *pb.JoinClusterRequest) (*e
 			codes.InvalidArgument, "public key in the request does not match the public key on the machine",
 		)
 	}
+	if err := m.verifyJoinProof(req); err != nil {
+		return nil, err
+	}
 
 	// Update the machine state with the provided cluster configuration.
 	subnet, _ := req.Machine.Network.Subnet.ToPrefix()
@@ -668,18 +922,9 @@ func (m *Machine) Token(_ context.Context, _ *emptypb.Empty) (*pb.TokenResponse,
 		return nil, status.Error(codes.FailedPrecondition, "public key is not set in machine state")
 	}
 
-	ips, err := network.ListRoutableIPs()
+	endpoints, err := m.endpoints(m.state.Interface, m.state.PublicIPSource)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "list routable IPs: %v", err)
-	}
-	publicIP, err := network.GetPublicIP()
-	// Ignore the error if failed to get the public IP using API services.
-	if err == nil {
-		ips = append(ips, publicIP)
-	}
-	endpoints := make([]netip.AddrPort, len(ips))
-	for i, ip := range ips {
-		endpoints[i] = netip.AddrPortFrom(ip, network.WireGuardPort)
+		return nil, status.Errorf(codes.Internal, "list endpoints: %v", err)
 	}
 
 	token := NewToken(m.state.Network.PublicKey, endpoints)
@@ -691,8 +936,84 @@ func (m *Machine) Token(_ context.Context, _ *emptypb.Empty) (*pb.TokenResponse,
 	return &pb.TokenResponse{Token: tokenStr}, nil
 }
 
-func (m *Machine) Inspect(_ context.Context, _ *emptypb.Empty) (*pb.MachineInfo, error) {
-	return &pb.MachineInfo{
+// ReconfigureNetwork forcibly reconfigures the WireGuard network peers with the current machines from
+// the cluster store. It can be used to recover the network from a bad state without restarting the daemon.
+func (m *Machine) ReconfigureNetwork(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	if !m.Initialised() {
+		return nil, status.Error(codes.FailedPrecondition, "machine is not initialised as a cluster member")
+	}
+	m.networkCtrlMu.RLock()
+	ctrl := m.networkCtrl
+	m.networkCtrlMu.RUnlock()
+	if ctrl == nil {
+		return nil, status.Error(codes.FailedPrecondition, "network controller is not running")
+	}
+
+	slog.Info("Reconfiguring network peers on demand.")
+	if err := ctrl.reconfigurePeers(ctx); err != nil {
+		return nil, status.Errorf(codes.Internal, "reconfigure network peers: %v", err)
+	}
+	slog.Info("Network peers reconfigured.")
+
+	return &emptypb.Empty{}, nil
+}
+
+func (m *Machine) setNetworkController(ctrl *networkController) {
+	m.networkCtrlMu.Lock()
+	defer m.networkCtrlMu.Unlock()
+	m.networkCtrl = ctrl
+}
+
+// endpoints returns the WireGuard endpoints the machine advertises when joining a cluster and in its token.
+// If iface is not empty, only the addresses of the interface are used. Otherwise, the public IP is resolved using
+// publicIPSource falling back to the source in the daemon config if empty.
+func (m *Machine) endpoints(iface, publicIPSource string) ([]netip.AddrPort, error) {
+	if publicIPSource == "" {
+		publicIPSource = m.config.PublicIPSource
+	}
+	ips, err := endpointIPs(iface, publicIPSource, m.config.PreferIPv6Endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make([]netip.AddrPort, len(ips))
+	for i, ip := range ips {
+		endpoints[i] = netip.AddrPortFrom(ip, network.WireGuardPort)
+	}
+	return endpoints, nil
+}
+
+// endpointIPs returns the IP addresses to use as WireGuard endpoints of the machine. If iface is not empty,
+// only the routable addresses of the interface are used. Otherwise, all routable IPs and the public IP resolved
+// using publicIPSource are used. IPv6 addresses are ordered first if preferIPv6 is true.
+func endpointIPs(iface, publicIPSource string, preferIPv6 bool) ([]netip.Addr, error) {
+	var ips []netip.Addr
+	var err error
+	if iface != "" {
+		if ips, err = network.ListInterfaceRoutableIPs(iface); err != nil {
+			return nil, err
+		}
+	} else {
+		if ips, err = network.ListRoutableIPs(); err != nil {
+			return nil, fmt.Errorf("list routable IPs: %w", err)
+		}
+		publicIP, err := network.ResolvePublicIP(publicIPSource)
+		if err != nil {
+			return nil, err
+		}
+		if publicIP.IsValid() && !slices.Contains(ips, publicIP) {
+			ips = append(ips, publicIP)
+		}
+	}
+
+	if preferIPv6 {
+		network.PreferIPv6(ips)
+	}
+	return ips, nil
+}
+
+func (m *Machine) Inspect(ctx context.Context, _ *emptypb.Empty) (*pb.MachineInfo, error) {
+	info := &pb.MachineInfo{
 		Id:   m.state.ID,
 		Name: m.state.Name,
 		Network: &pb.NetworkConfig{
@@ -700,7 +1021,29 @@ func (m *Machine) Inspect(_ context.Context, _ *emptypb.Empty) (*pb.MachineInfo,
 			ManagementIp: pb.NewIP(m.state.Network.ManagementIP),
 			PublicKey:    m.state.Network.PublicKey,
 		},
-	}, nil
+	}
+	if total, available, err := diskSpace(m.config.DataDir); err != nil {
+		slog.Debug("Failed to get disk space of the data directory.", "dir", m.config.DataDir, "err", err)
+	} else {
+		info.DiskTotal, info.DiskAvailable = total, available
+	}
+	if !m.Initialised() {
+		return info, nil
+	}
+
+	// The role, cordon state, and labels are managed through the cluster API and stored only in the cluster store.
+	machines, err := m.store.ListMachines(ctx)
+	if err != nil {
+		slog.Debug("Failed to list machines from store to inspect machine.", "err", err)
+		return info, nil
+	}
+	for _, sm := range machines {
+		if sm.Id == info.Id {
+			info.Role, info.Cordoned, info.Labels = sm.Role, sm.Cordoned, sm.Labels
+			break
+		}
+	}
+	return info, nil
 }
 
 // InspectService returns detailed information about a service and its containers.
@@ -719,10 +1062,109 @@ func (m *Machine) InspectService(
 	if len(records) == 0 {
 		return nil, status.Error(codes.NotFound, "service not found")
 	}
+
+	svc, err := serviceFromRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.InspectServiceResponse{Service: svc}, nil
+}
+
+// WatchService streams the state of the service every time its containers or spec change in the cluster store.
+// The state is only sent if it differs from the previously sent one. Each message contains the full state
+// of the service rather than a delta to keep clients simple, the state is small enough to resend. A service with
+// a stored spec but no containers, e.g. before its first container is created or during a redeployment, is sent
+// without containers. The stream ends with an empty message when the service is removed.
+func (m *Machine) WatchService(
+	req *pb.InspectServiceRequest, stream grpc.ServerStreamingServer[pb.WatchServiceResponse],
+) error {
+	ctx := stream.Context()
+	_, containerChanges, err := m.store.SubscribeContainers(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "subscribe to container changes: %v", err)
+	}
+	specChanges, err := m.store.SubscribeServiceSpecs(ctx)
+	if err != nil {
+		return status.Errorf(codes.Internal, "subscribe to service spec changes: %v", err)
+	}
+
+	var sent *pb.Service
+	for {
+		svc, err := m.serviceState(ctx, req.Id)
+		if err != nil {
+			return err
+		}
+		if svc == nil {
+			if sent == nil {
+				return status.Error(codes.NotFound, "service not found")
+			}
+			if err = stream.Send(&pb.WatchServiceResponse{}); err != nil {
+				return status.Errorf(codes.Internal, "send service to stream: %v", err)
+			}
+			return nil
+		}
+
+		if !proto.Equal(svc, sent) {
+			if err = stream.Send(&pb.WatchServiceResponse{Service: svc}); err != nil {
+				return status.Errorf(codes.Internal, "send service to stream: %v", err)
+			}
+			sent = svc
+		}
+
+		select {
+		case _, ok := <-containerChanges:
+			if !ok {
+				return status.Error(codes.Unavailable, "containers subscription failed")
+			}
+		case _, ok := <-specChanges:
+			if !ok {
+				return status.Error(codes.Unavailable, "service specs subscription failed")
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// serviceState returns the current state of the service from its containers in the cluster store or, if it has
+// no containers, from its stored spec. It returns nil if the service doesn't exist.
+func (m *Machine) serviceState(ctx context.Context, idOrName string) (*pb.Service, error) {
+	opts := store.ListOptions{ServiceIDOrName: store.ServiceIDOrNameOptions{
+		ID:   idOrName,
+		Name: idOrName,
+	}}
+	records, err := m.store.ListContainers(ctx, opts)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list containers: %v", err)
+	}
+	if len(records) > 0 {
+		return serviceFromRecords(records)
+	}
+
+	r, err := m.store.GetServiceSpec(ctx, idOrName)
+	if err != nil {
+		if errors.Is(err, store.ErrServiceSpecNotFound) {
+			return nil, nil
+		}
+		return nil, status.Errorf(codes.Internal, "get service spec: %v", err)
+	}
+	var spec api.ServiceSpec
+	if err = json.Unmarshal([]byte(r.Spec), &spec); err != nil {
+		return nil, status.Errorf(codes.Internal, "unmarshal service spec: %v", err)
+	}
+	mode := spec.Mode
+	if mode == "" {
+		mode = api.ServiceModeReplicated
+	}
+	return &pb.Service{Id: r.ID, Name: r.Name, Mode: mode}, nil
+}
+
+// serviceFromRecords returns the service with the containers from the store records. The records must belong
+// to the same service and not be empty.
+func serviceFromRecords(records []*store.ContainerRecord) (*pb.Service, error) {
 	// TODO: handle SyncStatus to return only trusted container statuses.
 	// TODO: handle multiple services with the same name but different IDs. This can happen when two services
 	//  with the same name are created concurrently on different machines.
-
 	containers := make([]*pb.Service_Container, len(records))
 	for i, r := range records {
 		containerJSON, err := json.Marshal(r.Container)
@@ -735,11 +1177,10 @@ func (m *Machine) InspectService(
 		}
 	}
 
-	svc := &pb.Service{
+	return &pb.Service{
 		Id:         records[0].Container.ServiceID(),
 		Name:       records[0].Container.ServiceName(),
 		Mode:       records[0].Container.ServiceMode(),
 		Containers: containers,
-	}
-	return &pb.InspectServiceResponse{Service: svc}, nil
+	}, nil
 }
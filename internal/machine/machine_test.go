@@ -0,0 +1,45 @@
+package machine
+
+import (
+	"context"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStopServiceContainers_Timeout(t *testing.T) {
+	t.Parallel()
+
+	// Fake Docker API that lists a service container and never finishes stopping it.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/json"):
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[{"Id": "c1"}]`))
+		case strings.HasSuffix(r.URL.Path, "/containers/c1/stop"):
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	dockerCli, err := client.NewClientWithOpts(
+		client.WithHost("tcp://"+srv.Listener.Addr().String()), client.WithVersion("1.47"),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { dockerCli.Close() })
+
+	m := &Machine{config: Config{DockerClient: dockerCli}}
+	start := time.Now()
+	err = m.stopServiceContainers(100 * time.Millisecond)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, `stop container "c1"`)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -13,6 +13,7 @@ import (
 	"net/netip"
 	"slices"
 	"strconv"
+	"sync"
 	"time"
 	"uncloud/internal/machine/api/pb"
 	"uncloud/internal/machine/caddyfile"
@@ -32,6 +33,10 @@ type networkController struct {
 
 	wgnet           *network.WireGuardNetwork
 	endpointChanges <-chan network.EndpointChangeEvent
+	// peersMu serialises peer reconfigurations triggered by machine changes and on demand.
+	peersMu sync.Mutex
+	// wgMTU is the MTU of the WireGuard interface from the machine config. It's not stored in the machine state.
+	wgMTU int
 
 	server        *grpc.Server
 	corroService  corroservice.Service
@@ -49,6 +54,7 @@ func newNetworkController(
 	corroService corroservice.Service,
 	dockerCli *client.Client,
 	caddyfileCtrl *caddyfile.Controller,
+	wgMTU int,
 ) (
 	*networkController, error,
 ) {
@@ -68,11 +74,12 @@ func newNetworkController(
 		corroService:    corroService,
 		dockerCli:       dockerCli,
 		caddyfileCtrl:   caddyfileCtrl,
+		wgMTU:           wgMTU,
 	}, nil
 }
 
 func (nc *networkController) Run(ctx context.Context) error {
-	if err := nc.wgnet.Configure(*nc.state.Network); err != nil {
+	if err := nc.wgnet.Configure(nc.wireGuardConfig()); err != nil {
 		return fmt.Errorf("configure WireGuard network: %w", err)
 	}
 	slog.Info("WireGuard network configured.")
@@ -92,6 +99,11 @@ func (nc *networkController) Run(ctx context.Context) error {
 	// TODO: Figure out if we need to manually stop the corrosion service when the context is done or just
 	//  rely on systemd to handle service dependencies on its own.
 
+	// Apply pending store migrations. Refuse to run if the store has been migrated by a newer daemon version.
+	if err := nc.store.Migrate(ctx); err != nil {
+		return fmt.Errorf("migrate store: %w", err)
+	}
+
 	errGroup, ctx := errgroup.WithContext(ctx)
 
 	// Start the network API server. Assume the management IP can't be changed when the network is running.
@@ -207,6 +219,12 @@ func (nc *networkController) prepareAndWatchDocker(ctx context.Context) error {
 	}
 	slog.Info("Docker network configured.")
 
+	// Start the containers that should be running before the initial sync so their state is reflected in the store.
+	if err := manager.ReconcileContainers(ctx); err != nil {
+		// Failing to start some containers shouldn't prevent the machine from syncing the rest of them.
+		slog.Error("Failed to reconcile service containers.", "err", err)
+	}
+
 	slog.Info("Watching Docker containers and syncing them to cluster store.")
 	// Retry to watch and sync containers until the context is done.
 	boff := backoff.WithContext(backoff.NewExponentialBackOff(
@@ -292,10 +310,21 @@ func (nc *networkController) handleMachineChanges(ctx context.Context) error {
 	}
 }
 
+// reconfigurePeers forcibly reconfigures the network peers with the current machines from the cluster store.
+func (nc *networkController) reconfigurePeers(ctx context.Context) error {
+	machines, err := nc.store.ListMachines(ctx)
+	if err != nil {
+		return fmt.Errorf("list machines: %w", err)
+	}
+	return nc.configurePeers(machines)
+}
+
 func (nc *networkController) configurePeers(machines []*pb.MachineInfo) error {
 	if len(machines) == 0 {
 		return fmt.Errorf("no machines to configure peers")
 	}
+	nc.peersMu.Lock()
+	defer nc.peersMu.Unlock()
 
 	nc.state.mu.RLock()
 	currentPeerEndpoints := make(map[string]*netip.AddrPort, len(nc.state.Network.Peers))
@@ -331,7 +360,11 @@ func (nc *networkController) configurePeers(machines []*pb.MachineInfo) error {
 		}
 
 		currentEndpoint := currentPeerEndpoints[peer.PublicKey.String()]
-		if currentEndpoint != nil && slices.Contains(endpoints, *currentEndpoint) {
+		if m.Network.ManualEndpoint != nil && len(endpoints) > 0 {
+			// The manual endpoint specified by the operator is the first one and takes precedence over
+			// the current endpoint that may have been auto-discovered.
+			peer.Endpoint = &endpoints[0]
+		} else if currentEndpoint != nil && slices.Contains(endpoints, *currentEndpoint) {
 			peer.Endpoint = currentEndpoint
 		} else if len(endpoints) > 0 {
 			peer.Endpoint = &endpoints[0]
@@ -351,10 +384,17 @@ func (nc *networkController) configurePeers(machines []*pb.MachineInfo) error {
 
 	nc.state.mu.RLock()
 	defer nc.state.mu.RUnlock()
-	if err = nc.wgnet.Configure(*nc.state.Network); err != nil {
+	if err = nc.wgnet.Configure(nc.wireGuardConfig()); err != nil {
 		return fmt.Errorf("configure network peers: %w", err)
 	}
 	return nil
 }
 
+// wireGuardConfig returns the WireGuard network configuration from the machine state with the configured MTU.
+func (nc *networkController) wireGuardConfig() network.Config {
+	config := *nc.state.Network
+	config.MTU = nc.wgMTU
+	return config
+}
+
 // TODO: method to shutdown network when leaving a cluster. Regular context cancellation shouldn't bring it down.
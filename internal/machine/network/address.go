@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"net/netip"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -31,38 +33,75 @@ func ListRoutableIPs() ([]netip.Addr, error) {
 			// Skip Docker bridge interfaces.
 			continue
 		}
-
-		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagRunning == 0 || iface.Flags&net.FlagLoopback != 0 {
-			// Skip interfaces:
-			//  * Not administratively UP.
-			//  * The operational status is not RUNNING. This is the closest equivalent to checking for NO-CARRIER.
-			//  * Loopback.
+		if !isInterfaceActive(iface) {
 			continue
 		}
 		// TODO: check for link/ether ifaces?
 
-		addrs, aErr := iface.Addrs()
-		if aErr != nil {
-			return nil, fmt.Errorf("list unicast addresses for interface %q: %w", iface.Name, err)
+		ips, iErr := interfaceRoutableIPs(iface)
+		if iErr != nil {
+			return nil, iErr
 		}
-		for _, addr := range addrs {
-			ipNet, ok := addr.(*net.IPNet)
-			if !ok {
-				continue
-			}
-			// Includes IPv4 private address space and local IPv6 unicast address space.
-			if ipNet.IP.IsGlobalUnicast() {
-				ip, pErr := netip.ParseAddr(ipNet.IP.String())
-				if pErr != nil {
-					return nil, fmt.Errorf("parse IP address %q: %w", ipNet.IP, err)
-				}
-				routable = append(routable, ip)
+		routable = append(routable, ips...)
+	}
+	return routable, nil
+}
+
+// ListInterfaceRoutableIPs returns a list of routable unicast IP addresses of the network interface with the given
+// name. It returns an error if the interface doesn't exist, is not active, or has no routable addresses.
+func ListInterfaceRoutableIPs(name string) ([]netip.Addr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("get network interface %q: %w", name, err)
+	}
+	if !isInterfaceActive(*iface) {
+		return nil, fmt.Errorf("network interface %q is not up, running, or is a loopback", name)
+	}
+
+	ips, err := interfaceRoutableIPs(*iface)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("network interface %q has no routable IP addresses", name)
+	}
+	return ips, nil
+}
+
+// isInterfaceActive returns true if the interface is administratively UP, operationally RUNNING,
+// and is not a loopback.
+func isInterfaceActive(iface net.Interface) bool {
+	// The operational status RUNNING is the closest equivalent to checking for NO-CARRIER.
+	return iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagRunning != 0 && iface.Flags&net.FlagLoopback == 0
+}
+
+// interfaceRoutableIPs returns a list of routable unicast IP addresses assigned to the interface.
+func interfaceRoutableIPs(iface net.Interface) ([]netip.Addr, error) {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("list unicast addresses for interface %q: %w", iface.Name, err)
+	}
+
+	var routable []netip.Addr
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		// Includes IPv4 private address space and local IPv6 unicast address space.
+		if ipNet.IP.IsGlobalUnicast() {
+			ip, pErr := netip.ParseAddr(ipNet.IP.String())
+			if pErr != nil {
+				return nil, fmt.Errorf("parse IP address %q: %w", ipNet.IP, pErr)
 			}
+			routable = append(routable, ip)
 		}
 	}
 	return routable, nil
 }
 
+// GetPublicIP queries well-known API services for the public IP address of the machine. It returns the public IPv4
+// address if the machine has one, otherwise the public IPv6 address, e.g. on IPv6-only machines.
 func GetPublicIP() (netip.Addr, error) {
 	services := []struct {
 		URL    string
@@ -71,6 +110,8 @@ func GetPublicIP() (netip.Addr, error) {
 		{"https://api.ipify.org", parsePlaintextIP},
 		{"https://ipinfo.io/ip", parsePlaintextIP},
 		{"http://ip-api.com/line/?fields=query", parsePlaintextIP},
+		// The above services are only reachable over IPv4 or prefer it. This one is only reachable over IPv6.
+		{"https://api6.ipify.org", parsePlaintextIP},
 	}
 
 	for _, service := range services {
@@ -110,5 +151,33 @@ func queryIP(service string, parser func([]byte) (netip.Addr, error)) (netip.Add
 }
 
 func parsePlaintextIP(data []byte) (netip.Addr, error) {
-	return netip.ParseAddr(string(data))
+	return netip.ParseAddr(strings.TrimSpace(string(data)))
+}
+
+// PreferIPv6 sorts the IP addresses so that IPv6 addresses come first preserving the relative order
+// of the addresses within each family.
+func PreferIPv6(ips []netip.Addr) {
+	slices.SortStableFunc(ips, func(a, b netip.Addr) int {
+		switch {
+		case a.Is6() && !b.Is6():
+			return -1
+		case !a.Is6() && b.Is6():
+			return 1
+		}
+		return 0
+	})
+}
+
+// ParseEndpoint parses a WireGuard endpoint in the HOST[:PORT] format where HOST is an IP address or a DNS name that
+// is resolved to an IP address. The default WireGuard port is used if PORT is not specified.
+func ParseEndpoint(endpoint string) (netip.AddrPort, error) {
+	if _, _, err := net.SplitHostPort(endpoint); err != nil {
+		endpoint = net.JoinHostPort(strings.Trim(endpoint, "[]"), strconv.Itoa(WireGuardPort))
+	}
+	addr, err := net.ResolveUDPAddr("udp", endpoint)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("resolve endpoint %q: %w", endpoint, err)
+	}
+	ap := addr.AddrPort()
+	return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port()), nil
 }
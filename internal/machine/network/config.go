@@ -18,6 +18,8 @@ type Config struct {
 	PrivateKey   secret.Secret
 	PublicKey    secret.Secret
 	Peers        []PeerConfig `json:",omitempty"`
+	// MTU is the MTU of the WireGuard interface. Default is DefaultWireGuardMTU if zero.
+	MTU int `json:"-"`
 }
 
 type PeerConfig struct {
@@ -69,10 +71,7 @@ func (c Config) toDeviceConfig(currentPeers []wgtypes.Peer) (wgtypes.Config, err
 			PersistentKeepaliveInterval: &persistentKeepalive,
 		}
 		if peerConfig.Endpoint != nil {
-			wgPeerConfigs[i].Endpoint = &net.UDPAddr{
-				IP:   peerConfig.Endpoint.Addr().AsSlice(),
-				Port: int(peerConfig.Endpoint.Port()),
-			}
+			wgPeerConfigs[i].Endpoint = net.UDPAddrFromAddrPort(*peerConfig.Endpoint)
 		}
 
 		newPeersSet[wgPeerConfigs[i].PublicKey.String()] = struct{}{}
@@ -3,6 +3,7 @@ package network
 import (
 	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
 	"log/slog"
+	"net"
 	"net/netip"
 	"slices"
 	"time"
@@ -33,7 +34,7 @@ func newPeer(config PeerConfig, wgPeer *wgtypes.Peer) *peer {
 		// Reset the endpoint change time if the endpoint is the same as the one in the current WireGuard peer.
 		// This is to avoid unnecessary endpoint rotation for the already connected peer.
 		if wgPeer != nil && wgPeer.Endpoint != nil {
-			wgEndpoint := wgPeer.Endpoint.AddrPort()
+			wgEndpoint := deviceEndpoint(wgPeer.Endpoint)
 			if *p.config.Endpoint == wgEndpoint {
 				p.lastEndpointChangeTime = time.Time{}
 			}
@@ -52,7 +53,7 @@ func (p *peer) updateConfig(config PeerConfig) {
 
 func (p *peer) updateFromDevice(wgPeer wgtypes.Peer) (endpointChanged bool) {
 	if wgPeer.Endpoint != nil {
-		wgEndpoint := wgPeer.Endpoint.AddrPort()
+		wgEndpoint := deviceEndpoint(wgPeer.Endpoint)
 		if p.config.Endpoint == nil || *p.config.Endpoint != wgEndpoint {
 			// The peer endpoint has been automatically updated on the WireGuard device which normally happens
 			// when the peer establishes a reverse connection to this machine.
@@ -170,3 +171,10 @@ func (p *peer) shouldChangeEndpoint() (netip.AddrPort, bool) {
 	endpoint := p.config.AllEndpoints[(idx+1)%len(p.config.AllEndpoints)]
 	return endpoint, true
 }
+
+// deviceEndpoint returns the endpoint of a WireGuard device peer with an IPv4-mapped IPv6 address unmapped
+// so that it can be compared with the configured IPv4 and IPv6 endpoints.
+func deviceEndpoint(addr *net.UDPAddr) netip.AddrPort {
+	ap := addr.AddrPort()
+	return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port())
+}
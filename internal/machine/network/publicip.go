@@ -0,0 +1,248 @@
+package network
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// PublicIPSourceAuto queries a list of well-known API services for the public IP address and ignores
+	// the failure if none of them is reachable.
+	PublicIPSourceAuto = "auto"
+	// PublicIPSourceNone disables the public IP address discovery.
+	PublicIPSourceNone = "none"
+	// stunPrefix is the prefix of the public IP source that queries a STUN server, e.g. stun:stun.l.google.com:19302
+	stunPrefix        = "stun:"
+	defaultSTUNPort   = "3478"
+	stunMagicCookie   = 0x2112A442
+	stunBindingReq    = 0x0001
+	stunBindingResp   = 0x0101
+	stunHeaderLen     = 20
+	stunAttrMapped    = 0x0001
+	stunAttrXORMapped = 0x0020
+)
+
+// ValidatePublicIPSource returns an error if the public IP source is not valid. A valid source is one of:
+//   - "" or "auto": query well-known API services, ignoring the failure if none is reachable.
+//   - "none": do not use a public IP address.
+//   - an IP address, e.g. "203.0.113.10": use the fixed address.
+//   - an HTTP(S) URL, e.g. "https://ifconfig.me/ip": query the resolver that returns the IP address in plain text.
+//   - "stun:HOST[:PORT]", e.g. "stun:stun.l.google.com:19302": query the STUN server over UDP.
+func ValidatePublicIPSource(source string) error {
+	switch {
+	case source == "", source == PublicIPSourceAuto, source == PublicIPSourceNone:
+		return nil
+	case strings.HasPrefix(source, stunPrefix):
+		if _, err := stunServerAddr(strings.TrimPrefix(source, stunPrefix)); err != nil {
+			return fmt.Errorf("invalid public IP source %q: %w", source, err)
+		}
+		return nil
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		if _, err := url.ParseRequestURI(source); err != nil {
+			return fmt.Errorf("invalid public IP source %q: %w", source, err)
+		}
+		return nil
+	}
+
+	if _, err := netip.ParseAddr(source); err != nil {
+		return fmt.Errorf("invalid public IP source %q: must be 'auto', 'none', an IP address, "+
+			"an HTTP(S) URL, or 'stun:HOST[:PORT]'", source)
+	}
+	return nil
+}
+
+// ResolvePublicIP returns the public IP address of the machine using the given source. See ValidatePublicIPSource
+// for the supported sources. It returns an invalid (zero) address and no error if the source is "none" or
+// the "auto" source failed to resolve the address. An error is returned if an explicitly configured source fails.
+func ResolvePublicIP(source string) (netip.Addr, error) {
+	if err := ValidatePublicIPSource(source); err != nil {
+		return netip.Addr{}, err
+	}
+
+	switch {
+	case source == "", source == PublicIPSourceAuto:
+		ip, err := GetPublicIP()
+		if err != nil {
+			// Not being able to reach the public API services is not an error in the auto mode,
+			// e.g. in restricted environments. Only the routable IPs are used as endpoints then.
+			slog.Warn("Failed to get public IP, configure the public IP source explicitly if needed.", "err", err)
+			return netip.Addr{}, nil
+		}
+		return ip, nil
+	case source == PublicIPSourceNone:
+		return netip.Addr{}, nil
+	case strings.HasPrefix(source, stunPrefix):
+		ip, err := queryIPSTUN(strings.TrimPrefix(source, stunPrefix))
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("get public IP from STUN server '%s': %w", source, err)
+		}
+		return ip, nil
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		ip, err := queryIP(source, parsePlaintextIP)
+		if err != nil {
+			return netip.Addr{}, fmt.Errorf("get public IP from resolver '%s': %w", source, err)
+		}
+		return ip, nil
+	}
+
+	return netip.ParseAddr(source)
+}
+
+// stunServerAddr validates the STUN server in the HOST[:PORT] form and returns its host:port address to dial.
+// The port defaults to defaultSTUNPort. An IPv6 host must be enclosed in square brackets if the port is specified.
+func stunServerAddr(server string) (string, error) {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		// No port is specified.
+		host, port = server, defaultSTUNPort
+		if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+			host = host[1 : len(host)-1]
+			if addr, err := netip.ParseAddr(host); err != nil || !addr.Is6() {
+				return "", fmt.Errorf("invalid STUN server %q: host in square brackets must be an IPv6 address",
+					server)
+			}
+		}
+	} else if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+		return "", fmt.Errorf("invalid STUN server %q: invalid port %q", server, port)
+	}
+
+	if host == "" {
+		return "", fmt.Errorf("invalid STUN server %q: host is empty", server)
+	}
+	if _, err = netip.ParseAddr(host); err != nil && !validHostname(host) {
+		return "", fmt.Errorf("invalid STUN server %q: must be in the HOST[:PORT] form", server)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// validHostname returns true if the host only contains the characters allowed in DNS hostnames.
+func validHostname(host string) bool {
+	for _, c := range host {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// queryIPSTUN sends a STUN binding request (RFC 5389) to the server and returns the reflexive IP address
+// from the response.
+func queryIPSTUN(server string) (netip.Addr, error) {
+	addr, err := stunServerAddr(server)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	conn, err := net.DialTimeout("udp", addr, 5*time.Second)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return netip.Addr{}, err
+	}
+
+	req := make([]byte, stunHeaderLen)
+	binary.BigEndian.PutUint16(req[0:2], stunBindingReq)
+	binary.BigEndian.PutUint32(req[4:8], stunMagicCookie)
+	if _, err = rand.Read(req[8:20]); err != nil {
+		return netip.Addr{}, fmt.Errorf("generate transaction ID: %w", err)
+	}
+	if _, err = conn.Write(req); err != nil {
+		return netip.Addr{}, fmt.Errorf("send binding request: %w", err)
+	}
+
+	resp := make([]byte, 1500)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("read binding response: %w", err)
+	}
+	return parseSTUNBindingResponse(resp[:n], req[8:20])
+}
+
+// parseSTUNBindingResponse extracts the IP address from the XOR-MAPPED-ADDRESS or MAPPED-ADDRESS attribute
+// of a STUN binding success response.
+func parseSTUNBindingResponse(resp, txID []byte) (netip.Addr, error) {
+	if len(resp) < stunHeaderLen {
+		return netip.Addr{}, errors.New("response is too short")
+	}
+	if binary.BigEndian.Uint16(resp[0:2]) != stunBindingResp {
+		return netip.Addr{}, fmt.Errorf("unexpected message type: %#04x", binary.BigEndian.Uint16(resp[0:2]))
+	}
+	if binary.BigEndian.Uint32(resp[4:8]) != stunMagicCookie || !bytes.Equal(resp[8:20], txID) {
+		return netip.Addr{}, errors.New("response doesn't match the request")
+	}
+
+	var mapped netip.Addr
+	attrs := resp[stunHeaderLen:]
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if len(attrs) < 4+attrLen {
+			return netip.Addr{}, errors.New("malformed attribute")
+		}
+		value := attrs[4 : 4+attrLen]
+
+		switch attrType {
+		case stunAttrXORMapped:
+			// XOR-MAPPED-ADDRESS is preferred as it isn't mangled by NATs rewriting addresses in payloads.
+			return parseSTUNAddress(value, resp[4:20])
+		case stunAttrMapped:
+			if ip, err := parseSTUNAddress(value, nil); err == nil {
+				mapped = ip
+			}
+		}
+
+		// Attributes are padded to a multiple of 4 bytes.
+		padded := (attrLen + 3) &^ 3
+		if len(attrs) < 4+padded {
+			break
+		}
+		attrs = attrs[4+padded:]
+	}
+
+	if !mapped.IsValid() {
+		return netip.Addr{}, errors.New("no mapped address in response")
+	}
+	return mapped, nil
+}
+
+// parseSTUNAddress parses the value of a (XOR-)MAPPED-ADDRESS attribute. If xorKey is not nil, the address is
+// XOR'ed with it (the magic cookie followed by the transaction ID).
+func parseSTUNAddress(value, xorKey []byte) (netip.Addr, error) {
+	if len(value) < 4 {
+		return netip.Addr{}, errors.New("malformed address attribute")
+	}
+	var size int
+	switch value[1] {
+	case 0x01:
+		size = 4
+	case 0x02:
+		size = 16
+	default:
+		return netip.Addr{}, fmt.Errorf("unknown address family: %#02x", value[1])
+	}
+	if len(value) < 4+size {
+		return netip.Addr{}, errors.New("malformed address attribute")
+	}
+
+	addr := make([]byte, size)
+	copy(addr, value[4:4+size])
+	if xorKey != nil {
+		for i := range addr {
+			addr[i] ^= xorKey[i]
+		}
+	}
+	ip, _ := netip.AddrFromSlice(addr)
+	return ip, nil
+}
@@ -0,0 +1,203 @@
+package network
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/netip"
+	"testing"
+)
+
+var stunTxID = []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+
+// stunMessage builds a STUN message with the given type, transaction ID, and raw attributes.
+func stunMessage(msgType uint16, txID []byte, attrs ...[]byte) []byte {
+	var body []byte
+	for _, attr := range attrs {
+		body = append(body, attr...)
+	}
+	msg := make([]byte, stunHeaderLen, stunHeaderLen+len(body))
+	binary.BigEndian.PutUint16(msg[0:2], msgType)
+	binary.BigEndian.PutUint16(msg[2:4], uint16(len(body)))
+	binary.BigEndian.PutUint32(msg[4:8], stunMagicCookie)
+	copy(msg[8:20], txID)
+	return append(msg, body...)
+}
+
+// stunAttr builds a STUN attribute with the value padded to a multiple of 4 bytes.
+func stunAttr(attrType uint16, value []byte) []byte {
+	attr := make([]byte, 4, 4+len(value)+3)
+	binary.BigEndian.PutUint16(attr[0:2], attrType)
+	binary.BigEndian.PutUint16(attr[2:4], uint16(len(value)))
+	attr = append(attr, value...)
+	for len(attr)%4 != 0 {
+		attr = append(attr, 0)
+	}
+	return attr
+}
+
+// stunAddress builds the value of a (XOR-)MAPPED-ADDRESS attribute. The address is XOR'ed with the magic cookie
+// and transaction ID if xor is true.
+func stunAddress(ip netip.Addr, xor bool) []byte {
+	family := byte(0x01)
+	if ip.Is6() {
+		family = 0x02
+	}
+	addr := ip.AsSlice()
+	if xor {
+		key := make([]byte, 16)
+		binary.BigEndian.PutUint32(key[0:4], stunMagicCookie)
+		copy(key[4:], stunTxID)
+		for i := range addr {
+			addr[i] ^= key[i]
+		}
+	}
+	// The port is not used by the parser.
+	return append([]byte{0, family, 0, 0}, addr...)
+}
+
+func TestParseSTUNBindingResponse(t *testing.T) {
+	t.Parallel()
+
+	ipv4 := netip.MustParseAddr("203.0.113.10")
+	ipv6 := netip.MustParseAddr("2001:db8::1")
+	mappedIPv4 := netip.MustParseAddr("198.51.100.7")
+
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    netip.Addr
+		wantErr string
+	}{
+		{
+			name: "XOR-MAPPED-ADDRESS IPv4",
+			resp: stunMessage(stunBindingResp, stunTxID, stunAttr(stunAttrXORMapped, stunAddress(ipv4, true))),
+			want: ipv4,
+		},
+		{
+			name: "XOR-MAPPED-ADDRESS IPv6",
+			resp: stunMessage(stunBindingResp, stunTxID, stunAttr(stunAttrXORMapped, stunAddress(ipv6, true))),
+			want: ipv6,
+		},
+		{
+			name: "XOR-MAPPED-ADDRESS preferred over MAPPED-ADDRESS",
+			resp: stunMessage(stunBindingResp, stunTxID,
+				stunAttr(stunAttrMapped, stunAddress(mappedIPv4, false)),
+				stunAttr(stunAttrXORMapped, stunAddress(ipv4, true)),
+			),
+			want: ipv4,
+		},
+		{
+			name: "MAPPED-ADDRESS fallback",
+			resp: stunMessage(stunBindingResp, stunTxID,
+				// SOFTWARE attribute with a value that requires padding.
+				stunAttr(0x8022, []byte("test")),
+				stunAttr(stunAttrMapped, stunAddress(mappedIPv4, false)),
+			),
+			want: mappedIPv4,
+		},
+		{
+			name:    "no mapped address",
+			resp:    stunMessage(stunBindingResp, stunTxID, stunAttr(0x8022, []byte("server"))),
+			wantErr: "no mapped address in response",
+		},
+		{
+			name: "wrong transaction ID",
+			resp: stunMessage(stunBindingResp, []byte{12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+				stunAttr(stunAttrXORMapped, stunAddress(ipv4, true))),
+			wantErr: "response doesn't match the request",
+		},
+		{
+			name:    "truncated attribute",
+			resp:    stunMessage(stunBindingResp, stunTxID, stunAttr(stunAttrXORMapped, stunAddress(ipv4, true))[:6]),
+			wantErr: "malformed attribute",
+		},
+		{
+			name: "truncated address",
+			resp: stunMessage(stunBindingResp, stunTxID,
+				stunAttr(stunAttrXORMapped, stunAddress(ipv6, true)[:8])),
+			wantErr: "malformed address attribute",
+		},
+		{
+			name:    "truncated header",
+			resp:    stunMessage(stunBindingResp, stunTxID)[:stunHeaderLen-1],
+			wantErr: "response is too short",
+		},
+		{
+			name: "error response",
+			// Binding error response with an ERROR-CODE attribute: 400 Bad Request.
+			resp:    stunMessage(0x0111, stunTxID, stunAttr(0x0009, append([]byte{0, 0, 4, 0}, "Bad Request"...))),
+			wantErr: "unexpected message type: 0x0111",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ip, err := parseSTUNBindingResponse(tt.resp, stunTxID)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ip)
+		})
+	}
+}
+
+func TestStunServerAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		server  string
+		want    string
+		wantErr string
+	}{
+		{server: "stun.l.google.com:19302", want: "stun.l.google.com:19302"},
+		{server: "stun.example.com", want: "stun.example.com:3478"},
+		{server: "203.0.113.10", want: "203.0.113.10:3478"},
+		{server: "203.0.113.10:3479", want: "203.0.113.10:3479"},
+		{server: "[2001:db8::1]", want: "[2001:db8::1]:3478"},
+		{server: "[2001:db8::1]:3479", want: "[2001:db8::1]:3479"},
+		{server: "2001:db8::1", want: "[2001:db8::1]:3478"},
+		{server: "", wantErr: "host is empty"},
+		{server: ":3478", wantErr: "host is empty"},
+		{server: "stun.example.com:", wantErr: "invalid port"},
+		{server: "stun.example.com:stun", wantErr: "invalid port"},
+		{server: "stun.example.com:0", wantErr: "invalid port"},
+		{server: "stun.example.com:65536", wantErr: "invalid port"},
+		{server: "[stun.example.com]", wantErr: "must be an IPv6 address"},
+		{server: "[203.0.113.10]", wantErr: "must be an IPv6 address"},
+		{server: "stun.example.com/path", wantErr: "must be in the HOST[:PORT] form"},
+		{server: "2001:db8::1:3478:x", wantErr: "must be in the HOST[:PORT] form"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.server, func(t *testing.T) {
+			t.Parallel()
+
+			addr, err := stunServerAddr(tt.server)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, addr)
+		})
+	}
+}
+
+func TestValidatePublicIPSource(t *testing.T) {
+	t.Parallel()
+
+	for _, source := range []string{
+		"", PublicIPSourceAuto, PublicIPSourceNone, "203.0.113.10", "2001:db8::1", "https://ifconfig.me/ip",
+		"stun:stun.l.google.com:19302", "stun:[2001:db8::1]",
+	} {
+		assert.NoError(t, ValidatePublicIPSource(source), source)
+	}
+	for _, source := range []string{"stun:", "stun:stun.example.com:port", "stun:[stun.example.com]", "invalid"} {
+		assert.Error(t, ValidatePublicIPSource(source), source)
+	}
+}
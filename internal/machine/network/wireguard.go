@@ -13,8 +13,22 @@ const (
 	WireGuardPort          = 51820
 	// WireGuardKeepaliveInterval is sensible interval that works with a wide variety of firewalls.
 	WireGuardKeepaliveInterval = 25 * time.Second
+	// DefaultWireGuardMTU is the MTU of the WireGuard interface that fits the encapsulated packets into the standard
+	// 1500-byte Ethernet MTU over both IPv4 and IPv6. It's the default MTU of a new WireGuard interface on Linux.
+	DefaultWireGuardMTU = 1420
+	// minWireGuardMTU is the minimum MTU required for IPv6 that is used for the management traffic.
+	minWireGuardMTU = 1280
 )
 
+// ValidateMTU returns an error if the WireGuard interface MTU is out of the supported range. Zero means the default.
+func ValidateMTU(mtu int) error {
+	if mtu != 0 && (mtu < minWireGuardMTU || mtu > DefaultWireGuardMTU) {
+		return fmt.Errorf("invalid WireGuard MTU %d: must be between %d and %d", mtu, minWireGuardMTU,
+			DefaultWireGuardMTU)
+	}
+	return nil
+}
+
 type EndpointChangeEvent struct {
 	PublicKey secret.Secret
 	// Endpoint is the new endpoint of the peer.
@@ -5,6 +5,7 @@ package network
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 type WireGuardNetwork struct{}
@@ -24,3 +25,7 @@ func (n *WireGuardNetwork) Run(ctx context.Context) error {
 func (n *WireGuardNetwork) WatchEndpoints() <-chan EndpointChangeEvent {
 	return nil
 }
+
+func PeerLastHandshakes() (map[string]time.Time, error) {
+	return nil, errors.New("not implemented on darwin")
+}
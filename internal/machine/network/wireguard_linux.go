@@ -50,7 +50,7 @@ func createOrGetLink(name string) (netlink.Link, error) {
 		return nil, fmt.Errorf("find WireGuard link %q: %v", name, err)
 	}
 	link = &netlink.GenericLink{
-		// TODO: figure out how to set the most appropriate MTU.
+		// The MTU is set when the network is configured.
 		LinkAttrs: netlink.LinkAttrs{Name: name},
 		LinkType:  "wireguard",
 	}
@@ -78,6 +78,10 @@ func (n *WireGuardNetwork) Configure(config Config) error {
 	}
 	slog.Info("Configured WireGuard interface.", "name", n.link.Attrs().Name)
 
+	if err := n.updateMTU(config.MTU); err != nil {
+		return err
+	}
+
 	machinePrefix := netip.PrefixFrom(MachineIP(config.Subnet), config.Subnet.Bits())
 	managementPrefix, err := addrToSingleIPPrefix(config.ManagementIP)
 	if err != nil {
@@ -110,6 +114,28 @@ func (n *WireGuardNetwork) Configure(config Config) error {
 	return nil
 }
 
+// updateMTU changes the MTU of the WireGuard interface if it differs from the configured one. The interface and
+// its peers are kept so the change doesn't interrupt the established connections.
+func (n *WireGuardNetwork) updateMTU(mtu int) error {
+	if err := ValidateMTU(mtu); err != nil {
+		return err
+	}
+	if mtu == 0 {
+		mtu = DefaultWireGuardMTU
+	}
+	if n.link.Attrs().MTU == mtu {
+		return nil
+	}
+
+	if err := netlink.LinkSetMTU(n.link, mtu); err != nil {
+		return fmt.Errorf("set MTU of WireGuard link %q to %d: %w", n.link.Attrs().Name, mtu, err)
+	}
+	slog.Info("Changed MTU of WireGuard interface.", "name", n.link.Attrs().Name,
+		"old", n.link.Attrs().MTU, "new", mtu)
+	n.link.Attrs().MTU = mtu
+	return nil
+}
+
 func (n *WireGuardNetwork) configureDevice(config Config) error {
 	wg, err := wgctrl.New()
 	if err != nil {
@@ -365,10 +391,7 @@ func (n *WireGuardNetwork) changeWireGuardEndpoints(ctx context.Context) error {
 		wgPeerConfigs = append(wgPeerConfigs, wgtypes.PeerConfig{
 			PublicKey:  publicKey,
 			UpdateOnly: true,
-			Endpoint: &net.UDPAddr{
-				IP:   p.config.Endpoint.Addr().AsSlice(),
-				Port: int(p.config.Endpoint.Port()),
-			},
+			Endpoint:   net.UDPAddrFromAddrPort(*p.config.Endpoint),
 		})
 
 		events = append(events, EndpointChangeEvent{
@@ -427,3 +450,27 @@ func (n *WireGuardNetwork) notifyWatchers(ctx context.Context, events []Endpoint
 	}
 	return nil
 }
+
+// PeerLastHandshakes returns the time of the latest handshake with each peer of the Uncloud WireGuard interface
+// keyed by the peer's public key. Peers that have never completed a handshake are not included.
+func PeerLastHandshakes() (map[string]time.Time, error) {
+	wg, err := wgctrl.New()
+	if err != nil {
+		return nil, fmt.Errorf("create WireGuard client: %w", err)
+	}
+	defer wg.Close()
+
+	dev, err := wg.Device(WireGuardInterfaceName)
+	if err != nil {
+		return nil, fmt.Errorf("get WireGuard device %q: %w", WireGuardInterfaceName, err)
+	}
+
+	handshakes := make(map[string]time.Time, len(dev.Peers))
+	for _, p := range dev.Peers {
+		if p.LastHandshakeTime.IsZero() {
+			continue
+		}
+		handshakes[secret.Secret(p.PublicKey[:]).String()] = p.LastHandshakeTime
+	}
+	return handshakes, nil
+}
@@ -23,6 +23,12 @@ type State struct {
 	Name string
 	// Network specifies the network configuration for this machine.
 	Network *network.Config
+	// Interface is the name of the network interface which addresses are used as WireGuard endpoints
+	// of this machine. If empty, all routable addresses and the public IP are used.
+	Interface string
+	// PublicIPSource specifies how the public IP address of this machine is determined to use as a WireGuard
+	// endpoint. If empty, the source from the daemon config is used.
+	PublicIPSource string
 
 	// path is the file path config is read from and saved to.
 	path string
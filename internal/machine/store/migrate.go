@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"uncloud/internal/corrosion"
+)
+
+// SchemaVersionKey is the key in the cluster table that stores the version of the last applied store migration.
+const SchemaVersionKey = "schema_version"
+
+// Migration is a versioned change of the data in the store. Structural changes such as new tables, columns,
+// and indexes are made declaratively in schema.sql which Corrosion applies on start. Migrations complement them
+// with data changes, e.g. backfilling a new column or rewriting records to a new format.
+//
+// Migrations can be applied concurrently by multiple machines after a cluster upgrade or before the version is
+// replicated to a newly joined machine, so their statements must be idempotent.
+type Migration struct {
+	// Version is the sequential version of the schema after applying the migration starting from 1.
+	Version int
+	// Description is a human-readable description of the migration.
+	Description string
+	// Statements are executed in a single transaction together with updating the schema version.
+	Statements []corrosion.Statement
+}
+
+// migrations is the ordered list of store migrations. New migrations must be appended with the next version.
+var migrations []Migration
+
+// SchemaVersion returns the latest store schema version supported by this binary.
+func SchemaVersion() int {
+	return len(migrations)
+}
+
+// ErrSchemaTooNew is returned when the store schema has been migrated by a newer version of the daemon.
+var ErrSchemaTooNew = errors.New("store schema version is newer than supported")
+
+// Migrate applies the pending store migrations in order and records the applied schema version. It returns
+// ErrSchemaTooNew if the store has been migrated to a version unknown to this binary.
+func (s *Store) Migrate(ctx context.Context) error {
+	var version int
+	if err := s.Get(ctx, SchemaVersionKey, &version); err != nil {
+		if !errors.Is(err, ErrKeyNotFound) {
+			return fmt.Errorf("get schema version: %w", err)
+		}
+		version = 0
+	}
+
+	latest := len(migrations)
+	if version > latest {
+		return fmt.Errorf("%w: store version %d, supported version %d, upgrade the daemon",
+			ErrSchemaTooNew, version, latest)
+	}
+
+	for _, m := range migrations[version:] {
+		slog.Info("Applying store migration.", "version", m.Version, "description", m.Description)
+
+		statements := append(slices.Clone(m.Statements), corrosion.Statement{
+			Query:  "INSERT OR REPLACE INTO cluster (key, value) VALUES (?, ?)",
+			Params: []any{SchemaVersionKey, m.Version},
+		})
+		resp, err := s.corro.ExecMultiContext(ctx, statements...)
+		if err != nil {
+			return fmt.Errorf("apply migration %d: %w", m.Version, err)
+		}
+		for _, r := range resp.Results {
+			if r.Error != nil {
+				return fmt.Errorf("apply migration %d: %s", m.Version, *r.Error)
+			}
+		}
+	}
+
+	if version < latest {
+		slog.Info("Store migrated.", "from_version", version, "to_version", latest)
+	}
+	return nil
+}
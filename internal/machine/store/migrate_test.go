@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"uncloud/internal/corrosion"
+)
+
+const putClusterKeyQuery = "INSERT OR REPLACE INTO cluster (key, value) VALUES (?, ?)"
+
+// fakeCorrosion is a fake Corrosion API that only stores the cluster table and records the other executed statements.
+type fakeCorrosion struct {
+	mu       sync.Mutex
+	cluster  map[string]json.RawMessage
+	executed []string
+}
+
+func (f *fakeCorrosion) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	enc := json.NewEncoder(w)
+	switch r.URL.Path {
+	case "/v1/queries":
+		var st struct {
+			Query  string
+			Params []string
+		}
+		err := json.NewDecoder(r.Body).Decode(&st)
+		if err != nil || st.Query != "SELECT value FROM cluster WHERE key = ?" {
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+			return
+		}
+		_ = enc.Encode(corrosion.QueryEvent{Columns: []string{"value"}})
+		if v, ok := f.cluster[st.Params[0]]; ok {
+			_ = enc.Encode(corrosion.QueryEvent{Row: &corrosion.RowEvent{RowID: 1, Values: []json.RawMessage{v}}})
+		}
+		_ = enc.Encode(corrosion.QueryEvent{EOQ: &corrosion.EndOfQuery{}})
+	case "/v1/transactions":
+		var statements []struct {
+			Query  string
+			Params []json.RawMessage
+		}
+		if err := json.NewDecoder(r.Body).Decode(&statements); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var resp corrosion.ExecResponse
+		for _, st := range statements {
+			if st.Query == putClusterKeyQuery {
+				var key string
+				_ = json.Unmarshal(st.Params[0], &key)
+				f.cluster[key] = st.Params[1]
+			} else {
+				f.executed = append(f.executed, st.Query)
+			}
+			resp.Results = append(resp.Results, corrosion.ExecResult{RowsAffected: 1})
+		}
+		_ = enc.Encode(resp)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestStore_Migrate(t *testing.T) {
+	// Not parallel as the test replaces the package-level migrations.
+	origMigrations := migrations
+	t.Cleanup(func() { migrations = origMigrations })
+
+	fake := &fakeCorrosion{cluster: make(map[string]json.RawMessage)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	addr, err := netip.ParseAddrPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+	corro, err := corrosion.NewAPIClient(addr, corrosion.WithHTTP2Client(srv.Client()))
+	require.NoError(t, err)
+	s := New(corro)
+	ctx := context.Background()
+
+	migrations = []Migration{
+		{Version: 1, Description: "Set a", Statements: []corrosion.Statement{{Query: "UPDATE containers SET a = 1"}}},
+		{Version: 2, Description: "Set b", Statements: []corrosion.Statement{{Query: "UPDATE containers SET b = 2"}}},
+	}
+	require.NoError(t, s.Migrate(ctx))
+	assert.Equal(t, []string{"UPDATE containers SET a = 1", "UPDATE containers SET b = 2"}, fake.executed)
+
+	var version int
+	require.NoError(t, s.Get(ctx, SchemaVersionKey, &version))
+	assert.Equal(t, 2, version)
+
+	t.Run("applied once", func(t *testing.T) {
+		require.NoError(t, s.Migrate(ctx))
+		assert.Len(t, fake.executed, 2)
+	})
+
+	t.Run("pending only", func(t *testing.T) {
+		migrations = append(migrations, Migration{
+			Version: 3, Description: "Set c", Statements: []corrosion.Statement{{Query: "UPDATE containers SET c = 3"}},
+		})
+		require.NoError(t, s.Migrate(ctx))
+		assert.Equal(t, []string{"UPDATE containers SET c = 3"}, fake.executed[2:])
+
+		require.NoError(t, s.Get(ctx, SchemaVersionKey, &version))
+		assert.Equal(t, 3, version)
+	})
+
+	t.Run("schema too new", func(t *testing.T) {
+		require.NoError(t, s.Put(ctx, SchemaVersionKey, 4))
+		assert.ErrorIs(t, s.Migrate(ctx), ErrSchemaTooNew)
+		assert.Len(t, fake.executed, 3)
+	})
+}
@@ -30,8 +30,20 @@ CREATE TABLE containers
     updated_at   TIMESTAMP NOT NULL DEFAULT '1970-01-01 00:00:00'
 );
 
+-- service_specs table stores the desired specs of the services independently of their containers, e.g. services that
+-- have been created but not started yet.
+CREATE TABLE service_specs
+(
+    id   TEXT NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    -- spec is a JSON-serialized api.ServiceSpec struct.
+    spec TEXT NOT NULL DEFAULT '{}' CHECK (json_valid(spec))
+);
+
 CREATE INDEX idx_machines_name ON machines (name);
 
 CREATE INDEX idx_containers_machine_id ON containers (machine_id);
 CREATE INDEX idx_containers_service_id ON containers (service_id);
 CREATE INDEX idx_containers_service_name ON containers (service_name);
+
+CREATE INDEX idx_service_specs_name ON service_specs (name);
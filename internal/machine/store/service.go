@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+var ErrServiceSpecNotFound = errors.New("service spec not found")
+
+// ServiceSpecRecord is the desired spec of a service stored independently of its containers.
+type ServiceSpecRecord struct {
+	ID   string
+	Name string
+	// Spec is the JSON-serialized api.ServiceSpec. The store doesn't decode it so that the machines don't have to
+	// understand specs created by newer clients.
+	Spec string
+}
+
+// CreateServiceSpec creates a new service spec record in the store database.
+func (s *Store) CreateServiceSpec(ctx context.Context, r ServiceSpecRecord) error {
+	_, err := s.corro.ExecContext(ctx, "INSERT INTO service_specs (id, name, spec) VALUES (?, ?, ?)",
+		r.ID, r.Name, r.Spec)
+	if err != nil {
+		return fmt.Errorf("insert query: %w", err)
+	}
+	return nil
+}
+
+// GetServiceSpec returns the service spec record with the given service ID or name from the store database.
+// It returns ErrServiceSpecNotFound if the record doesn't exist.
+func (s *Store) GetServiceSpec(ctx context.Context, idOrName string) (ServiceSpecRecord, error) {
+	records, err := s.queryServiceSpecs(ctx,
+		"SELECT id, name, spec FROM service_specs WHERE id = ? OR name = ? ORDER BY id = ? DESC LIMIT 1",
+		idOrName, idOrName, idOrName)
+	if err != nil {
+		return ServiceSpecRecord{}, err
+	}
+	if len(records) == 0 {
+		return ServiceSpecRecord{}, ErrServiceSpecNotFound
+	}
+	return records[0], nil
+}
+
+// ListServiceSpecs returns all service spec records from the store database.
+func (s *Store) ListServiceSpecs(ctx context.Context) ([]ServiceSpecRecord, error) {
+	return s.queryServiceSpecs(ctx, "SELECT id, name, spec FROM service_specs ORDER BY name")
+}
+
+// DeleteServiceSpec deletes the service spec record with the given service ID from the store database.
+func (s *Store) DeleteServiceSpec(ctx context.Context, id string) error {
+	if _, err := s.corro.ExecContext(ctx, "DELETE FROM service_specs WHERE id = ?", id); err != nil {
+		return fmt.Errorf("delete query: %w", err)
+	}
+	return nil
+}
+
+// SubscribeServiceSpecs returns a channel that signals changes to the service specs. The channel doesn't receive
+// any values, it just signals when a service spec has been added, updated, or deleted in the database.
+func (s *Store) SubscribeServiceSpecs(ctx context.Context) (<-chan struct{}, error) {
+	sub, err := s.corro.SubscribeContext(ctx, "SELECT id, name, spec FROM service_specs", nil, false)
+	if err != nil {
+		return nil, err
+	}
+	events, err := sub.Changes()
+	if err != nil {
+		return nil, fmt.Errorf("get subscription changes: %w", err)
+	}
+
+	changes := make(chan struct{})
+	go func() {
+		defer close(changes)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-events:
+				if !ok {
+					// events channel has been closed.
+					if sub.Err() != nil {
+						slog.Error("Service specs subscription failed.", "id", sub.ID(), "err", sub.Err())
+					}
+					return
+				}
+				// Just signal that there is a change in the service specs.
+				changes <- struct{}{}
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+func (s *Store) queryServiceSpecs(ctx context.Context, query string, args ...any) ([]ServiceSpecRecord, error) {
+	rows, err := s.corro.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query service specs: %w", err)
+	}
+	defer rows.Close()
+
+	var records []ServiceSpecRecord
+	for rows.Next() {
+		var r ServiceSpecRecord
+		if err = rows.Scan(&r.ID, &r.Name, &r.Spec); err != nil {
+			return nil, fmt.Errorf("scan service spec: %w", err)
+		}
+		records = append(records, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate service specs: %w", err)
+	}
+	return records, nil
+}
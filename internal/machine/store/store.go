@@ -61,6 +61,35 @@ func (s *Store) CreateMachine(ctx context.Context, m *pb.MachineInfo) error {
 	return nil
 }
 
+func (s *Store) UpdateMachine(ctx context.Context, m *pb.MachineInfo) error {
+	mJSON, err := protojson.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshal machine info: %w", err)
+	}
+	_, err = s.corro.ExecContext(ctx, "UPDATE machines SET info = ? WHERE id = ?", string(mJSON), m.Id)
+	if err != nil {
+		return fmt.Errorf("update query: %w", err)
+	}
+	return nil
+}
+
+// DeleteMachine deletes the machine and the records of its containers from the store database.
+func (s *Store) DeleteMachine(ctx context.Context, id string) error {
+	resp, err := s.corro.ExecMultiContext(ctx,
+		corrosion.Statement{Query: "DELETE FROM containers WHERE machine_id = ?", Params: []any{id}},
+		corrosion.Statement{Query: "DELETE FROM machines WHERE id = ?", Params: []any{id}},
+	)
+	if err != nil {
+		return fmt.Errorf("delete query: %w", err)
+	}
+	for _, r := range resp.Results {
+		if r.Error != nil {
+			return fmt.Errorf("delete query: %s", *r.Error)
+		}
+	}
+	return nil
+}
+
 func (s *Store) ListMachines(ctx context.Context) ([]*pb.MachineInfo, error) {
 	rows, err := s.corro.QueryContext(ctx, "SELECT info FROM machines ORDER BY name")
 	if err != nil {
@@ -87,6 +116,31 @@ func (s *Store) ListMachines(ctx context.Context) ([]*pb.MachineInfo, error) {
 	return machines, nil
 }
 
+// DBVersion returns the latest versions of the changes from each site that the store database has applied, keyed by
+// the hex-encoded site ID. A store that has applied lower versions than another one hasn't yet synced all changes.
+func (s *Store) DBVersion(ctx context.Context) (map[string]int64, error) {
+	rows, err := s.corro.QueryContext(ctx,
+		"SELECT hex(site_id), MAX(db_version) FROM crsql_changes GROUP BY site_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[string]int64)
+	for rows.Next() {
+		var site string
+		var version int64
+		if err = rows.Scan(&site, &version); err != nil {
+			return nil, err
+		}
+		versions[site] = version
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
 // SubscribeMachines returns a list of machines and a channel that signals changes to the list. The channel doesn't
 // receive any values, it just signals when a machine has been added, updated, or deleted in the database.
 func (s *Store) SubscribeMachines(ctx context.Context) ([]*pb.MachineInfo, <-chan struct{}, error) {
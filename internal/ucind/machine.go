@@ -34,7 +34,7 @@ type Machine struct {
 }
 
 func (m *Machine) Connect(ctx context.Context) (*client.Client, error) {
-	return client.New(ctx, connector.NewTCPConnector(m.APIAddress))
+	return client.New(ctx, connector.NewTCPConnector(m.APIAddress, 0))
 }
 
 type CreateMachineOptions struct {
@@ -11,6 +11,10 @@ UNCLOUD_USER="uncloud"
 # Add the specified Linux user to group $UNCLOUD_USER to allow the user to run uncloud commands without sudo.
 UNCLOUD_GROUP_ADD_USER=${UNCLOUD_GROUP_ADD_USER:-}
 UNCLOUD_DATA_DIR=${UNCLOUD_DATA_DIR:-/var/lib/uncloud}
+# Run preflight checks and report what would be installed or changed without modifying the system.
+# The CLI checks for the following capability marker before running the script in dry-run mode.
+# capability: dry-run
+UNCLOUD_DRY_RUN=${UNCLOUD_DRY_RUN:-}
 
 CORROSION_GITHUB_URL="https://github.com/psviderski/corrosion"
 CORROSION_VERSION=${CORROSION_VERSION:-latest}
@@ -19,6 +23,14 @@ log() {
     echo -e "\033[1;32m$1\033[0m"
 }
 
+plan() {
+    echo -e "\033[1;33m[dry-run] $1\033[0m"
+}
+
+is_dry_run() {
+    [ -n "${UNCLOUD_DRY_RUN}" ]
+}
+
 error() {
     echo -e "\033[1;31mERROR: $1\033[0m" >&2
     exit 1
@@ -52,6 +64,10 @@ install_docker() {
         docker version
         return
     fi
+    if is_dry_run; then
+        plan "Would install Docker using the convenience script https://get.docker.com"
+        return
+    fi
 
     log "⏳ Installing Docker..."
     curl -fsSL https://get.docker.com | sh
@@ -61,6 +77,8 @@ install_docker() {
 create_uncloud_user_and_group() {
     if id "${UNCLOUD_USER}" &> /dev/null; then
         log "✓ Linux user '${UNCLOUD_USER}' already exists."
+    elif is_dry_run; then
+        plan "Would create Linux system user and group '${UNCLOUD_USER}'."
     else
         # In addition to creating the user, create a group with the same name as the user.
         if ! useradd --system --home-dir /nonexistent --shell /usr/sbin/nologin --user-group "${UNCLOUD_USER}"; then
@@ -70,6 +88,12 @@ create_uncloud_user_and_group() {
     fi
 
     if [ -n "${UNCLOUD_GROUP_ADD_USER}" ]; then
+        if is_dry_run; then
+            if ! id -nG "${UNCLOUD_GROUP_ADD_USER}" 2> /dev/null | grep -qw "${UNCLOUD_USER}"; then
+                plan "Would add Linux user '${UNCLOUD_GROUP_ADD_USER}' to group '${UNCLOUD_USER}'."
+            fi
+            return
+        fi
         if ! gpasswd --add "${UNCLOUD_GROUP_ADD_USER}" "${UNCLOUD_USER}" > /dev/null; then
             error "Failed to add user '${UNCLOUD_GROUP_ADD_USER}' to group '${UNCLOUD_USER}'."
         fi
@@ -100,6 +124,11 @@ install_uncloud_binaries() {
         log "✓ uncloudd binary is already installed."
         return
     fi
+    if is_dry_run; then
+        plan "Would download uncloudd binary (version: ${UNCLOUD_VERSION}, arch: ${file_arch}) \
+and install it to ${uncloudd_install_path}"
+        return
+    fi
 
     log "⏳ Installing Uncloud binaries..."
 
@@ -133,6 +162,10 @@ install_uncloud_binaries() {
 
 install_uncloud_systemd() {
     local uncloud_service_path="${INSTALL_SYSTEMD_DIR}/uncloud.service"
+    if is_dry_run; then
+        plan "Would create systemd unit file ${uncloud_service_path} and enable uncloud.service"
+        return
+    fi
     cat > "${uncloud_service_path}" << EOF
 [Unit]
 Description=Uncloud machine daemon
@@ -176,6 +209,11 @@ install_corrosion() {
         log "✓ uncloud-corrosion binary is already installed."
         return
     fi
+    if is_dry_run; then
+        plan "Would download uncloud-corrosion binary (version: ${CORROSION_VERSION}, arch: ${arch}) \
+and install it to ${corrosion_install_path}"
+        return
+    fi
 
     # Create a temporary directory for downloads.
     local tmp_dir
@@ -205,6 +243,10 @@ install_corrosion() {
 
 install_corrosion_systemd() {
     local corrosion_service_path="${INSTALL_SYSTEMD_DIR}/uncloud-corrosion.service"
+    if is_dry_run; then
+        plan "Would create systemd unit file ${corrosion_service_path}"
+        return
+    fi
     cat > "${corrosion_service_path}" << EOF
 [Unit]
 Description=Uncloud gossip-based distributed store
@@ -235,12 +277,21 @@ EOF
 }
 
 start_uncloud() {
+    if is_dry_run; then
+        plan "Would (re)start uncloud.service which creates the data directory ${UNCLOUD_DATA_DIR} \
+and the corrosion config ${UNCLOUD_DATA_DIR}/corrosion/config.toml if they don't exist"
+        return
+    fi
     log "⏳ Starting Uncloud machine daemon (uncloud.service)..."
     systemctl restart uncloud.service
     log "✓ Uncloud machine daemon started."
 }
 
-log "⏳ Running Uncloud install script..."
+if is_dry_run; then
+    log "⏳ Running Uncloud install script in dry-run mode, no changes will be made..."
+else
+    log "⏳ Running Uncloud install script..."
+fi
 
 if [ "$EUID" -ne 0 ]; then
     error "Please run the install script with sudo or as root."
@@ -255,4 +306,8 @@ install_corrosion
 install_corrosion_systemd
 start_uncloud
 
-log "✓ Uncloud installed on the machine successfully! 🎉"
+if is_dry_run; then
+    log "✓ Uncloud install dry run completed."
+else
+    log "✓ Uncloud installed on the machine successfully! 🎉"
+fi
@@ -0,0 +1,118 @@
+#!/usr/bin/env bash
+
+# Uninstall Uncloud from the machine reverting the changes made by install.sh and the machine daemon.
+# Docker is left installed as it may be used by other software on the machine.
+
+set -euo pipefail
+
+INSTALL_BIN_DIR=${INSTALL_BIN_DIR:-/usr/local/bin}
+INSTALL_SYSTEMD_DIR=${INSTALL_SYSTEMD_DIR:-/etc/systemd/system}
+
+UNCLOUD_USER="uncloud"
+UNCLOUD_DATA_DIR=${UNCLOUD_DATA_DIR:-/var/lib/uncloud}
+# Name of the WireGuard interface and Docker network created by the machine daemon.
+UNCLOUD_NETWORK_NAME="uncloud"
+
+log() {
+    echo -e "\033[1;32m$1\033[0m"
+}
+
+warn() {
+    echo -e "\033[1;33mWARNING: $1\033[0m" >&2
+}
+
+error() {
+    echo -e "\033[1;31mERROR: $1\033[0m" >&2
+    exit 1
+}
+
+command_exists() {
+    command -v "$1" >/dev/null 2>&1
+}
+
+stop_uncloud_systemd() {
+    local unit
+    for unit in uncloud.service uncloud-corrosion.service; do
+        if systemctl list-unit-files "${unit}" >/dev/null 2>&1; then
+            systemctl disable --now "${unit}" >/dev/null 2>&1 || warn "Failed to stop and disable ${unit}."
+        fi
+        rm -f "${INSTALL_SYSTEMD_DIR}/${unit}"
+    done
+    systemctl daemon-reload
+    log "✓ Uncloud systemd services stopped and removed."
+}
+
+remove_docker_resources() {
+    if ! command_exists docker; then
+        return
+    fi
+
+    local containers
+    containers=$(docker ps --all --quiet --filter "label=uncloud.managed")
+    if [ -n "${containers}" ]; then
+        # shellcheck disable=SC2086
+        docker rm --force ${containers} >/dev/null || warn "Failed to remove some Uncloud service containers."
+    fi
+    log "✓ Uncloud service containers removed."
+
+    if docker network inspect "${UNCLOUD_NETWORK_NAME}" >/dev/null 2>&1; then
+        docker network rm "${UNCLOUD_NETWORK_NAME}" >/dev/null || warn "Failed to remove Docker network."
+    fi
+    log "✓ Docker network '${UNCLOUD_NETWORK_NAME}' removed."
+}
+
+remove_network_config() {
+    if command_exists iptables; then
+        # Delete the rules in the DOCKER-USER chain that allow traffic from the WireGuard interface to the bridge of
+        # the Docker network: '--in-interface uncloud --out-interface br-<network ID> -j ACCEPT'. iptables -S prints
+        # them in the short form but match the long form as well just in case. The chain may not exist or contain
+        # no Uncloud rules which must not fail the script.
+        local rules rule
+        rules=$(iptables -S DOCKER-USER 2>/dev/null |
+            grep -E -- "(-i|--in-interface) ${UNCLOUD_NETWORK_NAME} (-o|--out-interface) br-[0-9a-f]+ -j ACCEPT" ||
+            true)
+        if [ -n "${rules}" ]; then
+            while read -r rule; do
+                # shellcheck disable=SC2086
+                iptables ${rule/-A /-D } || warn "Failed to delete iptables rule: ${rule}"
+            done <<< "${rules}"
+        fi
+        log "✓ iptables rules removed."
+    fi
+
+    if ip link show "${UNCLOUD_NETWORK_NAME}" >/dev/null 2>&1; then
+        ip link delete "${UNCLOUD_NETWORK_NAME}" || warn "Failed to delete WireGuard interface."
+    fi
+    log "✓ WireGuard interface '${UNCLOUD_NETWORK_NAME}' removed."
+}
+
+remove_uncloud_files() {
+    rm -rf "${UNCLOUD_DATA_DIR}"
+    log "✓ Data directory ${UNCLOUD_DATA_DIR} removed."
+    rm -f "${INSTALL_BIN_DIR}/uncloudd" "${INSTALL_BIN_DIR}/uncloud-corrosion"
+    log "✓ Uncloud binaries removed."
+}
+
+remove_uncloud_user_and_group() {
+    if id "${UNCLOUD_USER}" >/dev/null 2>&1; then
+        userdel "${UNCLOUD_USER}" || warn "Failed to delete Linux user '${UNCLOUD_USER}'."
+    fi
+    if getent group "${UNCLOUD_USER}" >/dev/null 2>&1; then
+        groupdel "${UNCLOUD_USER}" || warn "Failed to delete Linux group '${UNCLOUD_USER}'."
+    fi
+    log "✓ Linux user and group '${UNCLOUD_USER}' removed."
+}
+
+log "⏳ Running Uncloud uninstall script..."
+
+if [ "$EUID" -ne 0 ]; then
+    error "Please run the uninstall script with sudo or as root."
+fi
+
+stop_uncloud_systemd
+remove_docker_resources
+remove_network_config
+remove_uncloud_files
+remove_uncloud_user_and_group
+
+log "✓ Uncloud uninstalled from the machine."
@@ -2,9 +2,16 @@ package e2e
 
 import (
 	"context"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	dockerclient "github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"os"
+	"path/filepath"
 	"testing"
 	"uncloud/internal/api"
 	"uncloud/internal/cli/client"
@@ -128,6 +135,131 @@ func TestRunService(t *testing.T) {
 		assert.Equal(t, spec.Ports, ports)
 	})
 
+	t.Run("1 replica with restart policy", func(t *testing.T) {
+		t.Parallel()
+
+		name := "busybox-1-replica-restart"
+		t.Cleanup(func() {
+			err := cli.RemoveService(ctx, name)
+			if !dockerclient.IsErrNotFound(err) {
+				require.NoError(t, err)
+			}
+
+			_, err = cli.InspectService(ctx, name)
+			require.ErrorIs(t, err, client.ErrNotFound)
+		})
+
+		spec := api.ServiceSpec{
+			Name: name,
+			Mode: api.ServiceModeReplicated,
+			Container: api.ContainerSpec{
+				Command: []string{"sleep", "infinity"},
+				Image:   "busybox:latest",
+				RestartPolicy: &api.RestartPolicySpec{
+					Name:              "on-failure",
+					MaximumRetryCount: 3,
+				},
+			},
+		}
+		resp, err := cli.RunService(ctx, spec)
+		require.NoError(t, err)
+		require.Len(t, resp.Containers, 1)
+
+		ctr := inspectServiceContainer(t, cli, resp.Containers[0])
+		assert.Equal(t, spec.Container.RestartPolicy.DockerRestartPolicy(), ctr.HostConfig.RestartPolicy)
+	})
+
+	t.Run("1 replica with read-only root filesystem", func(t *testing.T) {
+		t.Parallel()
+
+		name := "busybox-1-replica-read-only"
+		t.Cleanup(func() {
+			err := cli.RemoveService(ctx, name)
+			if !dockerclient.IsErrNotFound(err) {
+				require.NoError(t, err)
+			}
+
+			_, err = cli.InspectService(ctx, name)
+			require.ErrorIs(t, err, client.ErrNotFound)
+		})
+
+		spec := api.ServiceSpec{
+			Name: name,
+			Mode: api.ServiceModeReplicated,
+			Container: api.ContainerSpec{
+				// Writing to the read-only root filesystem must fail while the writable volume must work.
+				Command:        []string{"sh", "-c", "! touch /test && touch /scratch/test && sleep infinity"},
+				Image:          "busybox:latest",
+				ReadOnlyRootfs: true,
+				Volumes:        []string{"/tmp:/scratch"},
+			},
+		}
+		resp, err := cli.RunService(ctx, spec)
+		require.NoError(t, err)
+		require.Len(t, resp.Containers, 1)
+
+		ctr := inspectServiceContainer(t, cli, resp.Containers[0])
+		assert.True(t, ctr.HostConfig.ReadonlyRootfs)
+		assert.True(t, ctr.State.Running, "container should keep running if the volume is writable")
+	})
+
+	t.Run("copy files to and from container", func(t *testing.T) {
+		t.Parallel()
+
+		name := "busybox-copy"
+		t.Cleanup(func() {
+			err := cli.RemoveService(ctx, name)
+			if !dockerclient.IsErrNotFound(err) {
+				require.NoError(t, err)
+			}
+		})
+
+		resp, err := cli.RunService(ctx, api.ServiceSpec{
+			Name: name,
+			Container: api.ContainerSpec{
+				Command: []string{"sleep", "infinity"},
+				Image:   "busybox:latest",
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.Containers, 1)
+		mc := resp.Containers[0]
+		machineCtx := machineContext(t, cli, mc.MachineID)
+
+		srcDir := filepath.Join(t.TempDir(), "data")
+		require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "nested"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "nested", "file.txt"), []byte("hello"), 0o640))
+
+		srcInfo, err := archive.CopyInfoSourcePath(srcDir, false)
+		require.NoError(t, err)
+		content, err := archive.TarResource(srcInfo)
+		require.NoError(t, err)
+		defer content.Close()
+		// The destination doesn't exist so the source directory is copied as /data.
+		err = cli.CopyToContainer(machineCtx, mc.ContainerID, "/data", srcInfo, content,
+			container.CopyToContainerOptions{})
+		require.NoError(t, err)
+
+		_, _, err = cli.CopyFromContainer(machineCtx, mc.ContainerID, "/nonexistent")
+		assert.True(t, errdefs.IsNotFound(err), "expected not found error, got: %v", err)
+
+		archiveContent, stat, err := cli.CopyFromContainer(machineCtx, mc.ContainerID, "/data")
+		require.NoError(t, err)
+		defer archiveContent.Close()
+		assert.True(t, stat.Mode.IsDir())
+
+		dstDir := filepath.Join(t.TempDir(), "copied")
+		err = archive.CopyTo(archiveContent, archive.CopyInfo{Path: "/data", Exists: true, IsDir: true}, dstDir)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(dstDir, "nested", "file.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+		fi, err := os.Stat(filepath.Join(dstDir, "nested", "file.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), fi.Mode().Perm(), "file mode should be preserved")
+	})
+
 	t.Run("global mode", func(t *testing.T) {
 		t.Parallel()
 
@@ -165,3 +297,28 @@ func TestRunService(t *testing.T) {
 		assert.Len(t, svc.Containers, 3, "expected 1 container on each machine")
 	})
 }
+
+// inspectServiceContainer returns the Docker inspect response for the service container on its machine.
+func inspectServiceContainer(t *testing.T, cli *client.Client, mc client.MachineContainerID) types.ContainerJSON {
+	ctr, err := cli.InspectContainer(machineContext(t, cli, mc.MachineID), mc.ContainerID)
+	require.NoError(t, err)
+	return ctr
+}
+
+// machineContext returns a context that proxies requests to the machine with the given ID.
+func machineContext(t *testing.T, cli *client.Client, machineID string) context.Context {
+	ctx := context.Background()
+	machines, err := cli.ListMachines(ctx)
+	require.NoError(t, err)
+
+	var machineIP string
+	for _, m := range machines {
+		if m.Machine.Id == machineID {
+			ip, _ := m.Machine.Network.ManagementIp.ToAddr()
+			machineIP = ip.String()
+		}
+	}
+	require.NotEmpty(t, machineIP, "machine not found by ID: %s", machineID)
+
+	return metadata.NewOutgoingContext(ctx, metadata.Pairs("machines", machineIP))
+}